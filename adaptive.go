@@ -0,0 +1,45 @@
+package ttlmap
+
+import "time"
+
+// WithAdaptiveInterval lets the ticker of the map wake up less
+// often while nothing expires. Every tick in which no entry
+// expired doubles the period of the ticker, up to max. A tick
+// in which entries expired resets the period to the interval
+// of the map. This reduces idle wakeups of maps that are
+// mostly empty, while keeping expiration accurate under load.
+//
+// Generations are still advanced per interval, the ticker
+// catches up on every wakeup. Entries of an idle map might
+// expire up to max late.
+func WithAdaptiveInterval[K comparable, V any](max time.Duration) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.maxInterval = max
+	}
+}
+
+// adapt returns the next period of the ticker, and resets the
+// ticker when it changed. The expired argument reports
+// whether entries expired during the last period.
+func (m *TTLMap[K, V]) adapt(period time.Duration, expired bool) time.Duration {
+	next := m.tickInterval()
+	if !expired {
+		next = period * 2
+		if next > m.maxInterval {
+			next = m.maxInterval
+		}
+		if next < m.tickInterval() {
+			next = m.tickInterval()
+		}
+	}
+	if next == period {
+		return period
+	}
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	if !m.closed.Load() {
+		m.ticker.Reset(next)
+	}
+	return next
+}
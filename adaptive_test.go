@@ -0,0 +1,21 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdapt(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithAdaptiveInterval[string, string](3*time.Minute))
+	defer ttlmap.Close()
+
+	if period := ttlmap.adapt(time.Minute, false); period != 2*time.Minute {
+		t.Errorf("Expected period to be 2m, but was %s", period)
+	} else if period = ttlmap.adapt(period, false); period != 3*time.Minute {
+		t.Errorf("Expected period to be 3m, but was %s", period)
+	} else if period = ttlmap.adapt(period, false); period != 3*time.Minute {
+		t.Errorf("Expected period to be 3m, but was %s", period)
+	} else if period = ttlmap.adapt(period, true); period != time.Minute {
+		t.Errorf("Expected period to be 1m, but was %s", period)
+	}
+}
@@ -0,0 +1,139 @@
+package ttlmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AdminOptions configures the handler of AdminHandler.
+type AdminOptions[K comparable] struct {
+	// Auth wraps the handler to authenticate and authorize
+	// operators, like the authentication middleware of the
+	// admin API of the service. The handler rejects all
+	// requests with 403 Forbidden when Auth is nil, so it is
+	// not exposed by accident.
+	Auth func(next http.Handler) http.Handler
+	// ParseKey parses the keys in the paths of requests. It
+	// may be nil for string keys.
+	ParseKey func(s string) (K, error)
+	// Limit is the maximum number of keys listed by GET
+	// /keys, 1000 when it is 0.
+	Limit int
+}
+
+// AdminEntry is an entry served by AdminHandler.
+type AdminEntry[K comparable, V any] struct {
+	Key       K         `json:"key"`
+	Value     *V        `json:"value,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AdminHandler returns a handler to inspect and repair the
+// map while it is used, like evicting a single poisoned entry
+// in production without a redeploy. It serves JSON:
+//
+//	GET    /stats       the Stats of the map
+//	GET    /keys        the keys and their expiry, up to Limit
+//	GET    /keys/{key}  the entry for key, with its value
+//	DELETE /keys/{key}  deletes the entry for key
+//	POST   /clear       deletes all entries, see Clear
+//
+// Mount it under a prefix with http.StripPrefix. Values are
+// encoded with encoding/json. Inspecting the map does not
+// count as hits or misses, and does not touch entries.
+func (m *TTLMap[K, V]) AdminHandler(opts AdminOptions[K]) http.Handler {
+	if opts.Auth == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+	parse := opts.ParseKey
+	if parse == nil {
+		parse = func(s string) (K, error) {
+			key, ok := any(s).(K)
+			if !ok {
+				return key, fmt.Errorf("ttlmap: AdminOptions.ParseKey is required for keys of type %T", key)
+			}
+			return key, nil
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, m.Stats())
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		n := limit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			if v, err := strconv.Atoi(s); err == nil && v > 0 {
+				n = min(v, limit)
+			}
+		}
+
+		tick, nextTick := m.ticks()
+		entries := make([]AdminEntry[K, V], 0, min(n, m.Len()))
+		m.storage().Range(func(key K, e *entry[V]) bool {
+			if expires := e.expires.Load(); expires != 0 && !m.hidden(e) {
+				entries = append(entries, AdminEntry[K, V]{Key: key, ExpiresAt: m.timeOf(expires, tick, nextTick)})
+			}
+			return len(entries) < n
+		})
+		writeJSON(w, http.StatusOK, entries)
+	})
+	mux.HandleFunc("GET /keys/{key}", func(w http.ResponseWriter, r *http.Request) {
+		key, err := parse(r.PathValue("key"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		tick, nextTick := m.ticks()
+		e, ok := m.storage().Load(m.key(key))
+		expires := uint64(0)
+		if ok {
+			expires = e.expires.Load()
+		}
+		if !ok || expires == 0 || m.hidden(e) {
+			http.NotFound(w, r)
+			return
+		}
+		value := m.value(e)
+		writeJSON(w, http.StatusOK, AdminEntry[K, V]{Key: key, Value: &value, ExpiresAt: m.timeOf(expires, tick, nextTick)})
+	})
+	mux.HandleFunc("DELETE /keys/{key}", func(w http.ResponseWriter, r *http.Request) {
+		key, err := parse(r.PathValue("key"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := m.LoadAndDelete(key); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /clear", func(w http.ResponseWriter, r *http.Request) {
+		m.Clear()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return opts.Auth(mux)
+}
+
+// writeJSON writes v as the JSON body of a response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,78 @@
+package ttlmap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// allowAll is an Auth middleware that admits every request.
+func allowAll(next http.Handler) http.Handler {
+	return next
+}
+
+func TestAdminHandler(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	handler := ttlmap.AdminHandler(AdminOptions[string]{Auth: allowAll})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	var entries []AdminEntry[string, string]
+	var entry AdminEntry[string, string]
+	var stats Stats
+	if rec := serve("GET", "/keys"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, but got %d", rec.Code)
+	} else if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 keys, but got %s", rec.Body)
+	} else if rec := serve("GET", "/keys/key1"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, but got %d", rec.Code)
+	} else if err := json.Unmarshal(rec.Body.Bytes(), &entry); err != nil || *entry.Value != "value1" || entry.ExpiresAt.IsZero() {
+		t.Errorf("Expected entry of key1, but got %s", rec.Body)
+	} else if rec := serve("DELETE", "/keys/key1"); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, but got %d", rec.Code)
+	} else if rec := serve("GET", "/keys/key1"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a deleted key, but got %d", rec.Code)
+	} else if rec := serve("POST", "/clear"); rec.Code != http.StatusNoContent || ttlmap.Len() != 0 {
+		t.Errorf("Expected map to be cleared, but got %d and %d entries", rec.Code, ttlmap.Len())
+	} else if rec := serve("GET", "/stats"); json.Unmarshal(rec.Body.Bytes(), &stats) != nil || stats.Stores != 2 {
+		t.Errorf("Expected stats with 2 stores, but got %s", rec.Body)
+	} else if stats.Hits != 0 {
+		t.Errorf("Expected inspection to not count hits, but got %d", stats.Hits)
+	}
+}
+
+func TestAdminHandlerAuth(t *testing.T) {
+	ttlmap := New[int, string](time.Hour, time.Minute)
+	defer ttlmap.Close()
+	ttlmap.Store(1, "value")
+
+	rec := httptest.NewRecorder()
+	ttlmap.AdminHandler(AdminOptions[int]{}).ServeHTTP(rec, httptest.NewRequest("POST", "/clear", nil))
+	if rec.Code != http.StatusForbidden || ttlmap.Len() != 1 {
+		t.Errorf("Expected requests without Auth to be forbidden, but got %d", rec.Code)
+	}
+
+	handler := ttlmap.AdminHandler(AdminOptions[int]{Auth: allowAll})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/keys/1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected int keys without ParseKey to be rejected, but got %d", rec.Code)
+	}
+
+	handler = ttlmap.AdminHandler(AdminOptions[int]{Auth: allowAll, ParseKey: strconv.Atoi})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/keys/1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, but got %d", rec.Code)
+	}
+}
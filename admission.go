@@ -0,0 +1,131 @@
+package ttlmap
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"sync"
+)
+
+// WithFrequencyAdmission protects frequently used keys from
+// scans when the map is at its max entries, like TinyLFU. The
+// map counts the loads and stores of every key in a compact
+// frequency sketch. While the map is full, a new key is only
+// admitted when it was used more often recently than the
+// entry it would displace, the entry closest to expiry among
+// a small sample. A scan of keys that are used once is then
+// rejected, instead of evicting the hot keys.
+//
+// Rejected stores behave like stores rejected by WithAdmission:
+// Store ignores the rejection, TryStore returns
+// ErrNotAdmitted. The counts are halved periodically, so keys
+// that turn hot are admitted after a few uses. It has no
+// effect without WithMaxEntries.
+func WithFrequencyAdmission[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.sketch = &frequencySketch[K]{}
+	}
+}
+
+// admissionSample is the number of entries sampled for the
+// victim of an admission.
+const admissionSample = 8
+
+// admitted reports whether a store of key is admitted by the
+// frequency sketch. It counts the use of key when store is
+// set, loads are counted by record.
+func (m *TTLMap[K, V]) admitted(key K, store bool) bool {
+	if store {
+		m.sketch.increment(key)
+	}
+	if m.count.Load() < m.maxEntries {
+		return true
+	} else if _, ok := m.storage().Load(key); ok {
+		return true
+	}
+
+	var victim K
+	var deadline uint64
+	n := 0
+	m.storage().Range(func(k K, e *entry[V]) bool {
+		if expires := e.expires.Load(); expires != 0 && (deadline == 0 || expires < deadline) {
+			victim, deadline = k, expires
+		}
+		n++
+		return n < admissionSample
+	})
+	return deadline == 0 || m.sketch.estimate(key) > m.sketch.estimate(victim)
+}
+
+// frequencySketch is a count-min sketch of the uses of keys,
+// with four rows of saturating counters.
+type frequencySketch[K comparable] struct {
+	mu       sync.Mutex
+	seed     maphash.Seed
+	counters [4][]uint8
+	mask     uint64
+	// additions counts the increments since the counters were
+	// halved, they are halved when it reaches sample.
+	additions int
+	sample    int
+}
+
+// maxFrequency is the value at which counters saturate.
+const maxFrequency = 15
+
+// init sizes the sketch for n entries, with four counters
+// per entry in every row to keep collisions rare.
+func (s *frequencySketch[K]) init(n int64) {
+	width := uint64(1) << bits.Len64(uint64(max(4*n, 256))-1)
+	s.seed = maphash.MakeSeed()
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	s.mask = width - 1
+	s.sample = 10 * int(width)
+}
+
+// indexes returns the counter of key in every row.
+func (s *frequencySketch[K]) indexes(key K) [4]uint64 {
+	h := maphash.Comparable(s.seed, key)
+	h1, h2 := h, h>>32|h<<32
+	var idx [4]uint64
+	for i := range idx {
+		idx[i] = (h1 + uint64(i)*h2) & s.mask
+	}
+	return idx
+}
+
+// increment counts a use of key.
+func (s *frequencySketch[K]) increment(key K) {
+	idx := s.indexes(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, j := range idx {
+		if s.counters[i][j] < maxFrequency {
+			s.counters[i][j]++
+		}
+	}
+	if s.additions++; s.additions >= s.sample {
+		for _, row := range s.counters {
+			for j := range row {
+				row[j] /= 2
+			}
+		}
+		s.additions /= 2
+	}
+}
+
+// estimate returns the approximate number of recent uses of
+// key.
+func (s *frequencySketch[K]) estimate(key K) uint8 {
+	idx := s.indexes(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := uint8(maxFrequency)
+	for i, j := range idx {
+		n = min(n, s.counters[i][j])
+	}
+	return n
+}
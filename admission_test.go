@@ -0,0 +1,78 @@
+package ttlmap
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWithFrequencyAdmission(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour,
+		WithMaxEntries[string, int](4),
+		WithFrequencyAdmission[string, int]())
+	defer ttlmap.Close()
+	for i := range 4 {
+		key := fmt.Sprintf("hot%d", i)
+		ttlmap.Store(key, i)
+		for range 3 {
+			ttlmap.Load(key)
+		}
+	}
+
+	for i := range 100 {
+		ttlmap.Store(fmt.Sprintf("scan%d", i), i)
+	}
+	for i := range 4 {
+		if _, ok := ttlmap.Load(fmt.Sprintf("hot%d", i)); !ok {
+			t.Errorf("Expected hot%d to survive the scan, but it was evicted", i)
+		}
+	}
+
+	if err := ttlmap.TryStore("new", 0); !errors.Is(err, ErrNotAdmitted) {
+		t.Errorf("Expected ErrNotAdmitted, but got %v", err)
+	}
+	for range 10 {
+		ttlmap.Load("new")
+	}
+	if err := ttlmap.TryStore("new", 0); err != nil {
+		t.Errorf("Expected a frequent key to be admitted, but got %v", err)
+	} else if ttlmap.Len() > 4 {
+		t.Errorf("Expected at most 4 entries, but got %d", ttlmap.Len())
+	}
+}
+
+func TestWithFrequencyAdmissionNotFull(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour,
+		WithMaxEntries[string, int](4),
+		WithFrequencyAdmission[string, int]())
+	defer ttlmap.Close()
+	ttlmap.Store("key1", 1)
+	ttlmap.Store("key2", 2)
+
+	if ttlmap.Len() != 2 {
+		t.Errorf("Expected new keys to be admitted below the limit, but got %d entries", ttlmap.Len())
+	}
+}
+
+func TestFrequencySketch(t *testing.T) {
+	var s frequencySketch[int]
+	s.init(16)
+	for range 20 {
+		s.increment(1)
+	}
+	s.increment(2)
+
+	if n := s.estimate(1); n != maxFrequency {
+		t.Errorf("Expected estimate to saturate at %d, but was %d", maxFrequency, n)
+	} else if n := s.estimate(2); n != 1 {
+		t.Errorf("Expected estimate of 1, but was %d", n)
+	}
+
+	for i := range s.sample {
+		s.increment(i + 100)
+	}
+	if n := s.estimate(1); n >= maxFrequency {
+		t.Errorf("Expected counters to be halved, but estimate was %d", n)
+	}
+}
@@ -0,0 +1,56 @@
+package ttlmap
+
+import "time"
+
+// EvictOlderThan expires the entries that were stored more
+// than d ago, regardless of their remaining TTL, and returns
+// the number of expired entries. Touching an entry doesn't
+// make it younger, storing it again does. This flushes the
+// entries that were fetched before a known point in time,
+// like after discovering that an upstream served corrupt
+// data.
+//
+// The age of entries has the precision of the interval, the
+// entries stored in the tick that contains the time d ago are
+// expired as well. The entries are reported like entries that
+// expired, see WithOnEvict and Expired. It is a no-op while
+// the map is frozen.
+func (m *TTLMap[K, V]) EvictOlderThan(d time.Duration) int {
+	defer m.operation()()
+	if m.Frozen() {
+		return 0
+	}
+
+	tick := m.tick.Load()
+	ticks := uint64(max(d, 0) / m.tickInterval())
+	if ticks > tick {
+		// The map is younger than d.
+		return 0
+	}
+
+	cutoff := tick - ticks
+	var batch []ExpiredEntry[K, V]
+	evicted := 0
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		expires := e.expires.Load()
+		if e.created > cutoff || expires == 0 || !e.expires.CompareAndSwap(expires, 0) {
+			return true
+		} else if !m.storage().CompareAndDelete(key, e) {
+			// The entry was replaced after it was claimed,
+			// the replacement is younger.
+			return true
+		}
+
+		m.evicted(key, e)
+		m.removed(key, e, EvictionExpired)
+		m.unschedule(key)
+		if m.disposing() {
+			batch = append(batch, ExpiredEntry[K, V]{Key: key, Value: m.value(e)})
+		}
+		m.release(e)
+		evicted++
+		return true
+	})
+	m.dispose(batch)
+	return evicted
+}
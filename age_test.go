@@ -0,0 +1,39 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvictOlderThan(t *testing.T) {
+	var evicted []string
+	ttlmap := NewManual(time.Hour, time.Minute, WithStrict[string, string](), WithOnEvict(func(key string, _ string, reason EvictionReason) {
+		if reason == EvictionExpired {
+			evicted = append(evicted, key)
+		}
+	}))
+	ttlmap.Store("old", "value")
+	ttlmap.Advance(10)
+	ttlmap.Store("young", "value")
+	ttlmap.Touch("old")
+	ttlmap.Advance(5)
+
+	if n := ttlmap.EvictOlderThan(20 * time.Minute); n != 0 {
+		t.Errorf("Expected no entry older than the map to be evicted, but %d were", n)
+	} else if n = ttlmap.EvictOlderThan(10 * time.Minute); n != 1 {
+		t.Errorf("Expected 1 evicted entry, but got %d", n)
+	} else if _, ok := ttlmap.Load("old"); ok {
+		t.Errorf("Expected old entry to be evicted, but it was not")
+	} else if _, ok := ttlmap.Load("young"); !ok {
+		t.Errorf("Expected young entry to be kept, but it was not")
+	} else if len(evicted) != 1 || evicted[0] != "old" {
+		t.Errorf("Expected old entry to be reported as expired, but got %v", evicted)
+	} else if stats := ttlmap.Stats(); stats.Expirations != 1 || stats.Entries != 1 {
+		t.Errorf("Expected 1 expiration and 1 entry, but got %d and %d", stats.Expirations, stats.Entries)
+	}
+
+	if n := ttlmap.EvictOlderThan(0); n != 1 {
+		t.Errorf("Expected every entry to be evicted, but %d were", n)
+	}
+	ttlmap.Advance(60)
+}
@@ -0,0 +1,53 @@
+package ttlmap
+
+import "time"
+
+// TTLAggregator merges the values stored under the same key
+// within a window, and emits the aggregate when the window
+// expires. It is a small stream aggregation primitive, for
+// example to batch counters or notifications per key.
+type TTLAggregator[K comparable, V any] struct {
+	values  *TTLMap[K, V]
+	combine func(aggregate, value V) V
+}
+
+// NewTTLAggregator creates a new TTLAggregator. The window of
+// a key starts with the first value stored for it, and lasts
+// for window, see New for the meaning of interval.
+//
+// Values stored within the window are merged into the
+// aggregate with combine. When the window expires, emit is
+// called with the key and the aggregate.
+func NewTTLAggregator[K comparable, V any](window, interval time.Duration, combine func(aggregate, value V) V, emit func(key K, aggregate V)) *TTLAggregator[K, V] {
+	a := &TTLAggregator[K, V]{combine: combine}
+	a.values = New(window, interval, WithOnEvict(func(key K, aggregate V, reason EvictionReason) {
+		if reason == EvictionExpired {
+			emit(key, aggregate)
+		}
+	}))
+	return a
+}
+
+// Store merges value into the aggregate of key, and returns
+// the new aggregate.
+func (a *TTLAggregator[K, V]) Store(key K, value V) V {
+	aggregate, _ := a.values.update(key, func(old V, loaded bool) V {
+		if !loaded {
+			return value
+		}
+		return a.combine(old, value)
+	})
+	return aggregate
+}
+
+// Load returns the current aggregate of key. The ok result
+// indicates whether the window of key is open.
+func (a *TTLAggregator[K, V]) Load(key K) (aggregate V, ok bool) {
+	return a.values.Load(key)
+}
+
+// Close stops the aggregator. Aggregates of open windows are
+// not emitted.
+func (a *TTLAggregator[K, V]) Close() {
+	a.values.Close()
+}
@@ -0,0 +1,28 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLAggregator(t *testing.T) {
+	emitted := make(map[string]int)
+	aggregator := NewTTLAggregator(2*time.Hour, time.Hour, func(aggregate, value int) int {
+		return aggregate + value
+	}, func(key string, aggregate int) {
+		emitted[key] = aggregate
+	})
+	aggregator.Store("key", 1)
+	aggregator.values.nextGeneration()
+
+	if aggregate := aggregator.Store("key", 2); aggregate != 3 {
+		t.Errorf("Expected aggregate to be 3, but was %d", aggregate)
+	}
+
+	aggregator.values.nextGeneration()
+	if _, ok := aggregator.Load("key"); ok {
+		t.Errorf("Expected window to be closed, but was not")
+	} else if emitted["key"] != 3 {
+		t.Errorf("Expected aggregate 3 to be emitted, but got %d", emitted["key"])
+	}
+}
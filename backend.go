@@ -0,0 +1,94 @@
+package ttlmap
+
+import "time"
+
+// Backend is a second level cache behind a map, like Redis or
+// memcached, see WithBackend.
+type Backend[K comparable, V any] interface {
+	// Get returns the value of key and its remaining TTL, or
+	// false when key is missing. A TTL of 0 means the value
+	// gets the TTL of the map.
+	Get(key K) (value V, ttl time.Duration, ok bool, err error)
+	// Set stores the value of key, which expires after ttl.
+	Set(key K, value V, ttl time.Duration) error
+	// Delete deletes key.
+	Delete(key K) error
+}
+
+// BackendOptions configures WithBackend.
+type BackendOptions struct {
+	// PropagateExpirations deletes entries from the backend
+	// when they expire in the map. By default the backend
+	// expires entries by itself.
+	PropagateExpirations bool
+	// OnError is called with the errors of the backend, which
+	// are otherwise discarded. The map keeps working when the
+	// backend fails, loads miss and stores are only local.
+	OnError func(err error)
+}
+
+// WithBackend makes the map an L1 cache in front of backend.
+// Loads that miss fall through to the backend, and the value
+// found there is stored in the map with its remaining TTL.
+// Stores write through to the backend, with the TTL of the
+// entry, and deletes are applied to it. The calls are
+// synchronous.
+//
+// Load, stores, LoadOrStore and deletes use the backend, like
+// WithShadow, other operations are local. Concurrent misses of
+// a key each call Get, use LoadOrCompute with a loader that
+// calls the backend to share a call.
+//
+// Experimental: the Backend interface may change in a minor
+// version.
+func WithBackend[K comparable, V any](backend Backend[K, V], opts BackendOptions) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.backend = backend
+		m.backendOpts = opts
+	}
+}
+
+// backendLoad loads key from the backend after a miss, and
+// stores it in the map. The value is not written back.
+func (m *TTLMap[K, V]) backendLoad(key K) (V, bool) {
+	value, ttl, ok, err := m.backend.Get(key)
+	if err != nil {
+		m.backendError(err)
+		return *new(V), false
+	} else if !ok {
+		return *new(V), false
+	}
+
+	e := &entry[V]{value: value}
+	if ttl > 0 {
+		e.ttl.Store(uint64(max(ttl/m.tickInterval(), 1)))
+	}
+	_, _ = m.storeDeferred(OpStore, key, e, nil)
+	return value, true
+}
+
+// backendStore writes a store of key through to the backend.
+// The ttl is the TTL of the entry in ticks, or 0 for the TTL
+// of the map.
+func (m *TTLMap[K, V]) backendStore(key K, value V, ttl uint64) {
+	if ttl == 0 {
+		ttl = m.ttlTicks.Load()
+	}
+	if err := m.backend.Set(key, value, time.Duration(ttl)*m.tickInterval()); err != nil {
+		m.backendError(err)
+	}
+}
+
+// backendDelete deletes key from the backend.
+func (m *TTLMap[K, V]) backendDelete(key K) {
+	if err := m.backend.Delete(key); err != nil {
+		m.backendError(err)
+	}
+}
+
+// backendError reports an error of the backend.
+func (m *TTLMap[K, V]) backendError(err error) {
+	if m.backendOpts.OnError != nil {
+		m.backendOpts.OnError(err)
+	}
+}
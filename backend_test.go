@@ -0,0 +1,110 @@
+package ttlmap
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBackend is a Backend in memory.
+type memoryBackend struct {
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+	gets   int
+	err    error
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{values: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (b *memoryBackend) Get(key string) (string, time.Duration, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+	value, ok := b.values[key]
+	return value, b.ttls[key], ok, b.err
+}
+
+func (b *memoryBackend) Set(key, value string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values[key], b.ttls[key] = value, ttl
+	return b.err
+}
+
+func (b *memoryBackend) Delete(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.values, key)
+	return b.err
+}
+
+func TestBackend(t *testing.T) {
+	backend := newMemoryBackend()
+	ttlmap := NewManual(time.Hour, time.Minute, WithBackend[string, string](backend, BackendOptions{}))
+	defer ttlmap.Close()
+
+	ttlmap.Store("key", "value")
+	ttlmap.StoreWithTTL("short", "value", 2*time.Minute)
+	if backend.values["key"] != "value" || backend.ttls["key"] != time.Hour {
+		t.Errorf("Expected store to write through with the TTL of the map, but got %v", backend.ttls)
+	} else if backend.ttls["short"] != 2*time.Minute {
+		t.Errorf("Expected store to write through with the TTL of the entry, but got %v", backend.ttls["short"])
+	}
+
+	backend.values["remote"], backend.ttls["remote"] = "value", 5*time.Minute
+	if value, ok := ttlmap.Load("remote"); !ok || value != "value" {
+		t.Errorf("Expected miss to fall through to the backend, but got '%s'", value)
+	} else if _, ok := ttlmap.storage().Load("remote"); !ok {
+		t.Errorf("Expected value of the backend to be stored in the map, but it was not")
+	} else if ttlmap.Load("remote"); backend.gets != 1 {
+		t.Errorf("Expected hit not to call the backend, but it was called %d times", backend.gets)
+	} else if _, ok := ttlmap.Load("missing"); ok {
+		t.Errorf("Expected missing key to miss, but it was loaded")
+	}
+
+	ttlmap.Delete("key")
+	if _, ok := backend.values["key"]; ok {
+		t.Errorf("Expected delete to be applied to the backend, but it was not")
+	}
+
+	// Expirations are not propagated by default.
+	ttlmap.Advance(5)
+	if _, ok := backend.values["remote"]; !ok {
+		t.Errorf("Expected expiration not to be propagated, but remote was deleted")
+	}
+}
+
+func TestBackendPropagateExpirations(t *testing.T) {
+	backend := newMemoryBackend()
+	ttlmap := NewManual(2*time.Minute, time.Minute, WithBackend[string, string](backend, BackendOptions{PropagateExpirations: true}))
+	defer ttlmap.Close()
+
+	ttlmap.Store("key", "value")
+	ttlmap.Advance(2)
+	if _, ok := backend.values["key"]; ok {
+		t.Errorf("Expected expiration to be propagated, but key was kept")
+	}
+}
+
+func TestBackendError(t *testing.T) {
+	backend := newMemoryBackend()
+	backend.err = errors.New("unavailable")
+	var errs []error
+	ttlmap := NewManual(time.Hour, time.Minute, WithBackend[string, string](backend, BackendOptions{
+		OnError: func(err error) { errs = append(errs, err) },
+	}))
+	defer ttlmap.Close()
+
+	ttlmap.Store("key", "value")
+	if value, ok := ttlmap.Load("key"); !ok || value != "value" {
+		t.Errorf("Expected store to be local when the backend fails, but got '%s'", value)
+	} else if _, ok := ttlmap.Load("missing"); ok {
+		t.Errorf("Expected load to miss when the backend fails, but it was loaded")
+	} else if len(errs) != 2 {
+		t.Errorf("Expected 2 errors to be reported, but got %v", errs)
+	}
+}
@@ -0,0 +1,114 @@
+package ttlmap
+
+import "errors"
+
+// StoreMany sets the values of all keys in entries, like Store,
+// but schedules the keys that share a deadline at once. This
+// amortizes the locking of the expiry engine, which dominates
+// when a cache is warmed with many entries. It returns the
+// number of stored entries, entries that are rejected, like by
+// WithAdmission, are skipped. It is a no-op while the map is
+// frozen.
+func (m *TTLMap[K, V]) StoreMany(entries map[K]V) int {
+	deferred := make(map[uint64][]K)
+	stored := 0
+	for key, value := range entries {
+		if _, err := m.storeDeferred(OpStore, key, &entry[V]{value: value}, deferred); err == nil {
+			stored++
+			if m.backend != nil {
+				m.backendStore(m.key(key), value, 0)
+			}
+			if m.invalidator != nil {
+				m.invalidator.Publish(m.key(key))
+			}
+		} else if errors.Is(err, ErrFrozen) || errors.Is(err, ErrClosed) {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for deadline, keys := range deferred {
+		m.expirer.Schedule(deadline, keys...)
+		if m.index != nil {
+			m.index.add(deadline, keys...)
+		}
+		m.scheduled(deadline)
+	}
+	return stored
+}
+
+// LoadMany returns the values of the keys that are present in
+// the map, like Load. Keys that are missing are not in the
+// result.
+func (m *TTLMap[K, V]) LoadMany(keys []K) map[K]V {
+	values := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if value, ok := m.Load(key); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// LoadManyConsistent is like LoadMany, but loads all keys at a
+// single point of the expiration of the map: generations are
+// not advanced while the keys are loaded, so no key expires
+// in between, like RangeConsistent. The ok result reports
+// whether all keys were found, the keys that are missing from
+// values were not. This suits invariants that need several
+// values from the same moment, like a price and its currency.
+func (m *TTLMap[K, V]) LoadManyConsistent(keys []K) (values map[K]V, ok bool) {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	values = m.LoadMany(keys)
+	return values, len(values) == len(keys)
+}
+
+// DeleteMany deletes the values of all keys, like Delete, but
+// unregisters the keys from the expiry engine at once. It
+// returns the number of keys that were present. It is a no-op
+// while the map is frozen.
+func (m *TTLMap[K, V]) DeleteMany(keys []K) int {
+	m.checkOpen("Delete")
+	defer m.operation()()
+	if m.Frozen() {
+		return 0
+	}
+
+	deleted := make([]K, 0, len(keys))
+	for _, key := range keys {
+		key = m.key(key)
+		m.record(TraceDelete, key)
+		e, ok := m.storage().LoadAndDelete(key)
+		if m.invalidator != nil {
+			m.invalidator.Publish(key)
+		}
+		if !ok {
+			continue
+		}
+
+		m.removed(key, e, EvictionDeleted)
+		if m.shadow != nil {
+			m.shadow.Delete(key)
+		}
+		if m.backend != nil {
+			m.backendDelete(key)
+		}
+		deleted = append(deleted, key)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range deleted {
+		// The key is not unregistered when it was stored
+		// again concurrently, like unschedule.
+		if _, ok := m.storage().Load(key); !ok {
+			m.expirer.Remove(key)
+			if m.index != nil {
+				m.index.remove(key)
+			}
+		}
+	}
+	return len(deleted)
+}
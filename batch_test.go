@@ -0,0 +1,80 @@
+package ttlmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStoreMany(t *testing.T) {
+	ttlmap := NewManual[string, string](2*time.Second, time.Second)
+	defer ttlmap.Close()
+
+	entries := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		entries[strconv.Itoa(i)] = "value"
+	}
+	if n := ttlmap.StoreMany(entries); n != 100 {
+		t.Errorf("Expected 100 stored entries, but got %d", n)
+	} else if ttlmap.Len() != 100 {
+		t.Errorf("Expected 100 entries, but had %d", ttlmap.Len())
+	} else if value, ok := ttlmap.Load("42"); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	}
+
+	ttlmap.Advance(2)
+	if ttlmap.Len() != 0 {
+		t.Errorf("Expected entries to expire, but had %d entries", ttlmap.Len())
+	}
+
+	ttlmap.Freeze(false)
+	if n := ttlmap.StoreMany(entries); n != 0 {
+		t.Errorf("Expected no entries to be stored while frozen, but got %d", n)
+	}
+}
+
+func TestLoadMany(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+
+	values := ttlmap.LoadMany([]string{"key1", "key2", "key3"})
+	if len(values) != 2 || values["key1"] != "value1" || values["key2"] != "value2" {
+		t.Errorf("Expected key1 and key2 to be loaded, but got %v", values)
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	ttlmap := NewManual(2*time.Second, time.Second, WithGenerationIndex[string, string]())
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value")
+	ttlmap.Store("key2", "value")
+	ttlmap.Store("key3", "value")
+
+	if n := ttlmap.DeleteMany([]string{"key1", "key2", "missing"}); n != 2 {
+		t.Errorf("Expected 2 deleted keys, but got %d", n)
+	} else if ttlmap.Len() != 1 {
+		t.Errorf("Expected 1 entry to be left, but had %d", ttlmap.Len())
+	} else if _, ok := ttlmap.Load("key3"); !ok {
+		t.Errorf("Expected key3 to be kept, but it was not")
+	}
+}
+
+func TestLoadManyConsistent(t *testing.T) {
+	ttlmap := NewManual[string, string](2*time.Second, time.Second)
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.Tick()
+	ttlmap.Store("key2", "value2")
+
+	if values, ok := ttlmap.LoadManyConsistent([]string{"key1", "key2"}); !ok || len(values) != 2 {
+		t.Errorf("Expected all keys to be loaded, but got %v", values)
+	}
+
+	ttlmap.Tick()
+
+	if values, ok := ttlmap.LoadManyConsistent([]string{"key1", "key2"}); ok || len(values) != 1 || values["key2"] != "value2" {
+		t.Errorf("Expected only key2 to be loaded, but got %v", values)
+	}
+}
@@ -0,0 +1,71 @@
+package ttlmap
+
+// BlobStore stores large values outside of the Go heap, see
+// WithBlobStore. It must be safe for concurrent use.
+type BlobStore[V any] interface {
+	// Put stores value and returns a handle to it. The
+	// handle must not be empty.
+	Put(value V) (handle string, err error)
+	// Get returns the value of a handle.
+	Get(handle string) (V, error)
+	// Release is called when the value of a handle left the
+	// map, the handle is not used afterwards.
+	Release(handle string)
+}
+
+// WithBlobStore stores values whose size exceeds threshold in
+// blobs, and keeps only their handle in the map. This allows
+// the map to index large objects, like files or response
+// bodies, without holding them on the Go heap. Values are read
+// from blobs when they are loaded, and handles are released
+// when their entry expires, is deleted or is replaced.
+//
+// Values that fail to be stored in blobs are kept in the map.
+// Loading a value whose blob fails to be read returns the zero
+// value, which can happen when a value is loaded while its
+// entry is removed concurrently.
+func WithBlobStore[K comparable, V any](blobs BlobStore[V], threshold int64, size func(value V) int64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.blobs = blobs
+		m.blobThreshold = threshold
+		m.blobSize = size
+	}
+}
+
+// stash moves the value of e into the blob store when it
+// exceeds the threshold.
+func (m *TTLMap[K, V]) stash(e *entry[V]) {
+	if m.blobs == nil || m.blobSize(e.value) <= m.blobThreshold {
+		return
+	}
+
+	if handle, err := m.blobs.Put(e.value); err == nil {
+		e.value, e.blob = *new(V), handle
+	}
+}
+
+// unstash reads the value of e from the blob store.
+func (m *TTLMap[K, V]) unstash(e *entry[V]) V {
+	value, err := m.blobs.Get(e.blob)
+	if err != nil {
+		return *new(V)
+	}
+	return value
+}
+
+// release releases the blob of e, if any. Blobs that are
+// pinned by a Handle are released by their last handle.
+func (m *TTLMap[K, V]) release(e *entry[V]) {
+	if e.blob == "" {
+		return
+	}
+
+	m.pinMu.Lock()
+	if p, ok := m.pins[e.blob]; ok {
+		p.dropped = true
+		m.pinMu.Unlock()
+		return
+	}
+	m.pinMu.Unlock()
+	m.blobs.Release(e.blob)
+}
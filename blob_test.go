@@ -0,0 +1,63 @@
+package ttlmap
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBlobs is a BlobStore that keeps values in a map.
+type memoryBlobs struct {
+	mu    sync.Mutex
+	blobs map[string]string
+	next  int
+}
+
+func (b *memoryBlobs) Put(value string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next++
+	handle := strconv.Itoa(b.next)
+	b.blobs[handle] = value
+	return handle, nil
+}
+
+func (b *memoryBlobs) Get(handle string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	value, ok := b.blobs[handle]
+	if !ok {
+		return "", errors.New("missing blob")
+	}
+	return value, nil
+}
+
+func (b *memoryBlobs) Release(handle string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.blobs, handle)
+}
+
+func TestWithBlobStore(t *testing.T) {
+	blobs := &memoryBlobs{blobs: make(map[string]string)}
+	ttlmap := New(time.Hour, time.Hour, WithBlobStore[string, string](blobs, 4, func(value string) int64 {
+		return int64(len(value))
+	}))
+	ttlmap.Store("small", "tiny")
+	ttlmap.Store("large", "large value")
+	ttlmap.Store("replaced", "large value")
+	ttlmap.Store("replaced", "other large value")
+
+	if value, ok := ttlmap.Load("large"); !ok || value != "large value" {
+		t.Errorf("Expected value to be 'large value', but was '%s'", value)
+	} else if len(blobs.blobs) != 2 {
+		t.Errorf("Expected 2 blobs, but got %d", len(blobs.blobs))
+	}
+
+	ttlmap.nextGeneration()
+	if len(blobs.blobs) != 0 {
+		t.Errorf("Expected blobs to be released after expiry, but got %d", len(blobs.blobs))
+	}
+}
@@ -0,0 +1,45 @@
+package ttlmap
+
+import (
+	"cmp"
+	"slices"
+)
+
+// RangeByExpiry calls f sequentially for each key and value
+// present in the map, starting with the entries that expire
+// first. Entries that expire in the same tick are visited in
+// insertion order when the map is created with
+// WithOrderedExpiry, otherwise in unspecified order. If f
+// returns false, RangeByExpiry stops the iteration. This
+// allows processing entries before they lapse, like
+// refreshing the tokens of sessions that are about to expire.
+//
+// The order is of the deadlines when RangeByExpiry is called.
+// Entries that are stored, touched or expired during the
+// iteration might be visited out of order, or not at all. It
+// sorts the entries first, so it costs O(n log n).
+func (m *TTLMap[K, V]) RangeByExpiry(f func(key K, value V) bool) {
+	type deadlined struct {
+		key     K
+		e       *entry[V]
+		expires uint64
+	}
+	var entries []deadlined
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if expires := e.expires.Load(); expires != 0 {
+			entries = append(entries, deadlined{key, e, expires})
+		}
+		return true
+	})
+	slices.SortFunc(entries, func(a, b deadlined) int {
+		return cmp.Or(cmp.Compare(a.expires, b.expires), cmp.Compare(a.e.seq, b.e.seq))
+	})
+
+	for _, d := range entries {
+		if d.e.expires.Load() == 0 || m.hidden(d.e) {
+			continue
+		} else if !f(d.key, m.value(d.e)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package ttlmap
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRangeByExpiry(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithOrderedExpiry[string, int]())
+	defer ttlmap.Close()
+	ttlmap.Store("key1", 1)
+	ttlmap.nextGeneration()
+	ttlmap.StoreWithTTL("key2", 2, time.Hour)
+	ttlmap.Store("key3", 3)
+	ttlmap.Store("key4", 4)
+
+	var keys []string
+	ttlmap.RangeByExpiry(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !slices.Equal(keys, []string{"key2", "key1", "key3", "key4"}) {
+		t.Errorf("Expected keys in order of expiry, but got %v", keys)
+	}
+
+	keys = nil
+	ttlmap.RangeByExpiry(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return false
+	})
+	if len(keys) != 1 {
+		t.Errorf("Expected iteration to stop, but got %v", keys)
+	}
+}
@@ -0,0 +1,58 @@
+package ttlmap
+
+// generationPool is the number of backing arrays a
+// GenerationExpirer keeps for reuse.
+const generationPool = 4
+
+// WithCapacityHint sets the number of keys that a generation
+// of the GenerationExpirer is expected to hold. Generations
+// start with room for perGeneration keys, and keep it when
+// they are advanced. The arrays of generations that outgrew
+// the hint are pooled when they are advanced, and reused by the
+// generations that receive keys next. This smooths the
+// allocations of bursty traffic, which would otherwise grow
+// new arrays in every tick of a burst.
+//
+// The hint only applies to a GenerationExpirer, the default
+// expiry engine. Without a hint, arrays are pooled as well.
+func WithCapacityHint[K comparable, V any](perGeneration int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.capacityHint = perGeneration
+	}
+}
+
+// generation returns the keys of generation gen, with a backing
+// array from the pool when it has none.
+func (g *GenerationExpirer[K]) generation(gen uint64) []K {
+	if keys := g.generations[gen]; keys != nil {
+		return keys
+	} else if n := len(g.free); n > 0 {
+		keys = g.free[n-1]
+		g.free[n-1] = nil
+		g.free = g.free[:n-1]
+		return keys
+	} else if g.hint > 0 {
+		return make([]K, 0, g.hint)
+	}
+	return nil
+}
+
+// recycle empties generation gen after it was advanced. Arrays
+// that outgrew the hint are moved to the pool when they were at
+// least a quarter full, and dropped otherwise, so a generation
+// doesn't hold on to the array of a burst.
+func (g *GenerationExpirer[K]) recycle(gen uint64) {
+	keys := g.generations[gen]
+	clear(keys)
+	if cap(keys) <= g.hint {
+		g.generations[gen] = keys[:0]
+		return
+	}
+
+	g.generations[gen] = nil
+	if len(keys) >= cap(keys)/4 && len(g.free) < generationPool {
+		g.free = append(g.free, keys[:0])
+	} else if len(keys) < cap(keys)/4 {
+		g.shrunk++
+	}
+}
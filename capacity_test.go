@@ -0,0 +1,61 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithCapacityHint(t *testing.T) {
+	ttlmap := NewManual(4*time.Second, time.Second, WithCapacityHint[int, int](16))
+	defer ttlmap.Close()
+	g := ttlmap.expirer.(*GenerationExpirer[int])
+
+	ttlmap.Store(1, 1)
+	gen := g.slot(ttlmap.deadline())
+	if c := cap(g.generations[gen]); c != 16 {
+		t.Errorf("Expected generation with the hinted capacity, but got %d", c)
+	}
+	ttlmap.Advance(4)
+	if c := cap(g.generations[gen]); c != 16 || len(g.free) != 0 {
+		t.Errorf("Expected generation to keep the hinted capacity, but got %d and %d pooled", c, len(g.free))
+	}
+
+	// A burst outgrows the hint, its array is reused by the
+	// next burst.
+	for i := range 100 {
+		ttlmap.Store(i, i)
+	}
+	gen = g.slot(ttlmap.deadline())
+	burst := &g.generations[gen][0]
+	ttlmap.Advance(4)
+	if g.generations[gen] != nil || len(g.free) != 1 {
+		t.Errorf("Expected the array of the burst to be pooled, but got %d pooled", len(g.free))
+	}
+	ttlmap.Advance(1)
+	for i := range 100 {
+		ttlmap.Store(i, i)
+	}
+	if &g.generations[g.slot(ttlmap.deadline())][0] != burst {
+		t.Errorf("Expected the array of the burst to be reused, but it was not")
+	}
+}
+
+func TestGenerationExpirerPool(t *testing.T) {
+	g := NewGenerationExpirer[int](4)
+	for i := range 64 {
+		g.Schedule(1, i)
+	}
+	g.Schedule(2, 1)
+	g.generations[g.slot(2)] = append(make([]int, 0, 64), g.generations[g.slot(2)]...)
+
+	g.Advance(1, nil)
+	g.Advance(2, nil)
+	if len(g.free) != 1 || cap(g.free[0]) < 64 {
+		t.Errorf("Expected the full array to be pooled, and the sparse one to be dropped, but got %d pooled", len(g.free))
+	}
+
+	g.Reset()
+	if g.free != nil {
+		t.Errorf("Expected Reset to empty the pool, but got %d pooled", len(g.free))
+	}
+}
@@ -0,0 +1,38 @@
+package ttlmap
+
+import "time"
+
+// Child creates a map with its own entries and TTL, that is
+// advanced by the ticker of m. Applications that need many
+// maps with different TTLs can share a single ticker this way,
+// instead of running one per map. The ttl of the child is
+// rounded down to a multiple of the interval of m, a ttl
+// shorter than the interval is rounded up to one interval.
+//
+// The child is advanced until it or m is closed. Maps
+// are not affected by freezing their parent or children,
+// every map is frozen individually.
+func (m *TTLMap[K, V]) Child(ttl time.Duration, opts ...Option[K, V]) *TTLMap[K, V] {
+	opts = append(opts[:len(opts):len(opts)], WithShortTTL[K, V](ShortTTLRoundUp))
+	child := newTTLMap(ttl, m.tickInterval(), opts...)
+	child.parent = m
+	child.clock = m.clock
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	m.children = append(m.children, child)
+	return child
+}
+
+// removeChild stops advancing child.
+func (m *TTLMap[K, V]) removeChild(child *TTLMap[K, V]) {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+
+	for i, c := range m.children {
+		if c == child {
+			m.children = append(m.children[:i], m.children[i+1:]...)
+			return
+		}
+	}
+}
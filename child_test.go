@@ -0,0 +1,37 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChild(t *testing.T) {
+	parent := New[string, string](2*time.Hour, time.Hour)
+	child := parent.Child(time.Hour)
+	parent.Store("key", "parent")
+	child.Store("key", "child")
+
+	if value, _ := child.Load("key"); value != "child" {
+		t.Errorf("Expected value to be 'child', but was '%s'", value)
+	}
+
+	parent.AdvanceTo(time.Now().Add(time.Hour))
+	if _, ok := parent.Load("key"); !ok {
+		t.Errorf("Expected to find key in parent, but did not")
+	} else if _, ok := child.Load("key"); ok {
+		t.Errorf("Expected child key to be expired, but was not")
+	}
+}
+
+func TestChildClose(t *testing.T) {
+	parent := New[string, string](2*time.Hour, time.Hour)
+	child := parent.Child(time.Hour)
+	child.Store("key", "value")
+	child.Close()
+
+	if _, ok := child.Load("key"); ok {
+		t.Errorf("Expected closed child to be cleared, but was not")
+	} else if len(parent.children) != 0 {
+		t.Errorf("Expected child to be detached, but was not")
+	}
+}
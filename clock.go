@@ -0,0 +1,80 @@
+package ttlmap
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of a map, see WithClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Ticker calls f every d, until the returned Ticker is
+	// stopped. Calls of f must not overlap.
+	Ticker(d time.Duration, f func()) Ticker
+}
+
+// Ticker is a ticker started by a Clock.
+type Ticker interface {
+	// Reset changes the period of the ticker to d.
+	Reset(d time.Duration)
+	// Stop stops the ticker, f is not called after Stop
+	// returns, unless it is already running.
+	Stop()
+}
+
+// WithClock sets the source of time of the map, which is the
+// system clock by default. A fake clock, like the one of the
+// ttlmaptest package, allows testing expiration without
+// sleeping. The clock of a map is used by its children, and
+// by a Scheduler that advances the map.
+func WithClock[K comparable, V any](clock Clock) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.clock = clock
+	}
+}
+
+// Clock returns the source of time of the map, see WithClock.
+func (m *TTLMap[K, V]) Clock() Clock {
+	return m.clock
+}
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+// Now returns time.Now.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Ticker calls f every d from a goroutine, which exits when
+// the ticker is stopped.
+func (systemClock) Ticker(d time.Duration, f func()) Ticker {
+	t := &systemTicker{Ticker: time.NewTicker(d), done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				f()
+			case <-t.done:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// systemTicker is a Ticker of the system clock.
+type systemTicker struct {
+	*time.Ticker
+	done chan struct{}
+	once sync.Once
+}
+
+// Stop stops the ticker and its goroutine.
+func (t *systemTicker) Stop() {
+	t.once.Do(func() {
+		t.Ticker.Stop()
+		close(t.done)
+	})
+}
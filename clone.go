@@ -0,0 +1,77 @@
+package ttlmap
+
+import "time"
+
+// Clone returns an independent copy of the map, with the TTL
+// and interval of the map. Entries keep their remaining TTL.
+// Values are copied like with assignment, so the payload of
+// pointer, slice, map and string values is shared with the
+// map, which never modifies a value in place. The clone has
+// its own ticker and must be closed.
+func (m *TTLMap[K, V]) Clone() *TTLMap[K, V] {
+	interval := m.tickInterval()
+	clone := New[K, V](time.Duration(m.ttlTicks.Load())*interval, interval)
+
+	tick, _ := m.ticks()
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		expires := e.expires.Load()
+		if expires <= tick {
+			// The entry is claimed or due.
+			return true
+		}
+
+		c := &entry[V]{value: clone.encode(m.value(e)), meta: e.meta}
+		c.ttl.Store(e.ttl.Load())
+		clone.stash(c)
+		c.created = clone.tick.Load()
+		c.used.Store(c.created + 1)
+		c.seq = clone.sequence()
+		deadline := c.created + expires - tick
+		c.expires.Store(deadline)
+		if _, loaded := clone.storage().LoadOrStore(key, c); loaded {
+			clone.release(c)
+			return true
+		}
+		clone.schedule(deadline, key)
+		clone.added(key, c)
+		return true
+	})
+	return clone
+}
+
+// Merge stores the entries of other in the map, with their
+// remaining TTL in other. Keys present in both maps get the
+// value conflict(a, b), where a is the value of the map and b
+// the value of other, and the longer of both remaining TTLs.
+// A nil conflict keeps the value of other.
+//
+// Merge is not atomic: entries written to either map during
+// the merge may or may not be merged.
+func (m *TTLMap[K, V]) Merge(other *TTLMap[K, V], conflict func(a, b V) V) {
+	if other == m {
+		return
+	}
+
+	tick, _ := other.ticks()
+	interval := other.tickInterval()
+	other.storage().Range(func(key K, e *entry[V]) bool {
+		expires := e.expires.Load()
+		if expires <= tick {
+			// The entry is claimed or due.
+			return true
+		}
+
+		value := other.value(e)
+		ttl := time.Duration(expires-tick) * interval
+		if current, ok := m.storage().Load(key); ok && !m.hidden(current) {
+			if expires, tick := current.expires.Load(), m.tick.Load(); expires > tick {
+				if conflict != nil {
+					value = conflict(m.value(current), value)
+				}
+				ttl = max(ttl, time.Duration(expires-tick)*m.tickInterval())
+			}
+		}
+		m.StoreWithTTL(key, value, ttl)
+		return true
+	})
+}
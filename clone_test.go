@@ -0,0 +1,98 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloneShared(t *testing.T) {
+	ttlmap := New[string, *string](2*time.Hour, time.Hour)
+	defer ttlmap.Close()
+	value := "value"
+	ttlmap.Store("key1", &value)
+	ttlmap.nextGeneration()
+	ttlmap.Store("key2", &value)
+
+	clone := ttlmap.Clone()
+	defer clone.Close()
+	clone.Store("key3", &value)
+	ttlmap.Delete("key2")
+
+	if shared, _ := clone.Load("key1"); shared != &value {
+		t.Errorf("Expected clone to share the value, but it was copied")
+	} else if _, ok := clone.Load("key2"); !ok {
+		t.Errorf("Expected clone to keep key2, but it was deleted")
+	} else if _, ok := ttlmap.Load("key3"); ok {
+		t.Errorf("Expected map to not see writes of the clone, but it did")
+	}
+
+	clone.nextGeneration()
+	if _, ok := clone.Load("key1"); ok {
+		t.Errorf("Expected key1 to keep its remaining ttl, but it is present")
+	} else if _, ok := clone.Load("key2"); !ok {
+		t.Errorf("Expected key2 to keep its remaining ttl, but it expired")
+	}
+}
+
+func TestClone(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key2", "value2")
+
+	clone := ttlmap.Clone()
+	defer clone.Close()
+	ttlmap.Delete("key2")
+
+	if clone.Len() != 2 {
+		t.Errorf("Expected clone to have 2 entries, but it has %d", clone.Len())
+	} else if value, ok := clone.Load("key2"); !ok || value != "value2" {
+		t.Errorf("Expected clone to keep key2, but it returned %q", value)
+	}
+
+	clone.nextGeneration()
+	if _, ok := clone.Load("key1"); ok {
+		t.Errorf("Expected key1 to keep its remaining ttl, but it is present")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := New[string, int](3*time.Hour, time.Hour)
+	defer a.Close()
+	b := New[string, int](3*time.Hour, time.Hour)
+	defer b.Close()
+
+	a.Store("both", 1)
+	a.nextGeneration()
+	a.nextGeneration()
+	a.Store("a", 2)
+	b.Store("both", 3)
+	b.nextGeneration()
+	b.Store("b", 4)
+
+	a.Merge(b, func(x, y int) int { return x + y })
+	if value, _ := a.Load("both"); value != 4 {
+		t.Errorf("Expected conflict to sum to 4, but it returned %d", value)
+	} else if value, _ := a.Load("b"); value != 4 {
+		t.Errorf("Expected b to be merged, but it returned %d", value)
+	} else if _, ok := b.Load("a"); ok {
+		t.Errorf("Expected other to be unchanged, but it got a")
+	}
+
+	a.nextGeneration()
+	if _, ok := a.Load("both"); !ok {
+		t.Errorf("Expected both to keep the longer ttl, but it expired")
+	}
+	a.nextGeneration()
+	if _, ok := a.Load("both"); ok {
+		t.Errorf("Expected both to expire with the ttl of other, but it is present")
+	} else if _, ok := a.Load("b"); !ok {
+		t.Errorf("Expected b to keep its remaining ttl, but it expired")
+	}
+
+	a.Merge(b, nil)
+	if value, _ := a.Load("both"); value != 3 {
+		t.Errorf("Expected nil conflict to keep the value of other, but it returned %d", value)
+	}
+}
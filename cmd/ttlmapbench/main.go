@@ -0,0 +1,202 @@
+// Command ttlmapbench benchmarks every storage engine and
+// expiry engine of ttlmap across read/write mixes, and writes
+// the results as JSON, so configurations can be compared with
+// data:
+//
+//	go run github.com/job79/ttlmap/cmd/ttlmapbench -benchtime 2s > report.json
+//
+// Every operation loads or stores a random key of the key
+// space, the writes flag sets the percentages of stores. Every
+// goroutine advances the map once per ticks operations, the
+// entries live for 4 ticks, so keys that are not stored again
+// expire.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// storages contains an option for every storage engine.
+var storages = map[string]func() ttlmap.Option[string, string]{
+	"sync.Map": func() ttlmap.Option[string, string] { return func(*ttlmap.TTLMap[string, string]) {} },
+	"sharded":  func() ttlmap.Option[string, string] { return ttlmap.WithShardedStorage[string, string](0) },
+}
+
+// expirers contains an option for every expiry engine.
+var expirers = map[string]func() ttlmap.Option[string, string]{
+	"generations": func() ttlmap.Option[string, string] {
+		return ttlmap.WithExpirer[string, string](ttlmap.NewGenerationExpirer[string](4))
+	},
+	"indexed": func() ttlmap.Option[string, string] {
+		return ttlmap.WithExpirer[string, string](ttlmap.NewIndexedGenerationExpirer[string](4))
+	},
+	"multi-resolution": func() ttlmap.Option[string, string] {
+		return ttlmap.WithExpirer[string, string](ttlmap.NewMultiResolutionExpirer[string](
+			ttlmap.Resolution{Ticks: 1, Generations: 2},
+			ttlmap.Resolution{Ticks: 2, Generations: 4},
+		))
+	},
+	"list": func() ttlmap.Option[string, string] {
+		return ttlmap.WithExpirer[string, string](ttlmap.NewListExpirer[string]())
+	},
+	"heap": func() ttlmap.Option[string, string] {
+		return ttlmap.WithExpirer[string, string](ttlmap.NewHeapExpirer[string]())
+	},
+}
+
+// Case is a configuration that is benchmarked.
+type Case struct {
+	Storage string `json:"storage"`
+	Expirer string `json:"expirer"`
+	Writes  int    `json:"writes"`
+}
+
+// Result is the result of a benchmarked Case.
+type Result struct {
+	Case
+	Keys        int     `json:"keys"`
+	Goroutines  int     `json:"goroutines"`
+	N           int     `json:"n"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+// Config configures a run of the suite.
+type Config struct {
+	Keys  int
+	Ticks int
+}
+
+func main() {
+	var (
+		writes    = flag.String("writes", "0,10,50,100", "comma separated percentages of stores")
+		keys      = flag.Int("keys", 1<<16, "number of distinct keys")
+		ticks     = flag.Int("ticks", 10000, "operations per goroutine between ticks")
+		storage   = flag.String("storage", "", "only benchmark this storage engine")
+		expirer   = flag.String("expirer", "", "only benchmark this expiry engine")
+		benchtime = flag.Duration("benchtime", time.Second, "duration of every benchmark")
+	)
+	testing.Init()
+	flag.Parse()
+	if err := flag.Set("test.benchtime", benchtime.String()); err != nil {
+		fatal(err)
+	}
+
+	var mixes []int
+	for _, s := range strings.Split(*writes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 0 || n > 100 {
+			fatal(fmt.Errorf("invalid write percentage %q", s))
+		}
+		mixes = append(mixes, n)
+	}
+
+	var cases []Case
+	for _, name := range slices.Sorted(maps.Keys(storages)) {
+		for _, expirerName := range slices.Sorted(maps.Keys(expirers)) {
+			if (*storage != "" && name != *storage) || (*expirer != "" && expirerName != *expirer) {
+				continue
+			}
+			for _, w := range mixes {
+				cases = append(cases, Case{Storage: name, Expirer: expirerName, Writes: w})
+			}
+		}
+	}
+	if len(cases) == 0 {
+		fatal(fmt.Errorf("no engine matches -storage %q and -expirer %q", *storage, *expirer))
+	}
+
+	if err := run(os.Stdout, Config{Keys: *keys, Ticks: *ticks}, cases); err != nil {
+		fatal(err)
+	}
+}
+
+// run benchmarks every case, and writes the results to w as
+// a JSON array.
+func run(w io.Writer, config Config, cases []Case) error {
+	keys := make([]string, config.Keys)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		newStorage, ok := storages[c.Storage]
+		if !ok {
+			return fmt.Errorf("unknown storage engine %q", c.Storage)
+		}
+		newExpirer, ok := expirers[c.Expirer]
+		if !ok {
+			return fmt.Errorf("unknown expiry engine %q", c.Expirer)
+		}
+
+		r := testing.Benchmark(func(b *testing.B) {
+			m := ttlmap.NewManual(4*time.Second, time.Second, newStorage(), newExpirer())
+			defer m.Close()
+			for _, key := range keys {
+				m.Store(key, "value")
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			bench(b, m, keys, c.Writes, config.Ticks)
+		})
+		results = append(results, Result{
+			Case:        c,
+			Keys:        config.Keys,
+			Goroutines:  runtime.GOMAXPROCS(0),
+			N:           r.N,
+			NsPerOp:     float64(r.T.Nanoseconds()) / float64(max(r.N, 1)),
+			AllocsPerOp: r.AllocsPerOp(),
+			BytesPerOp:  r.AllocedBytesPerOp(),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(results)
+}
+
+// bench loads and stores random keys of m from parallel
+// goroutines, writes is the percentage of stores.
+func bench(b *testing.B, m *ttlmap.TTLMap[string, string], keys []string, writes, ticks int) {
+	var seed atomic.Uint64
+	b.RunParallel(func(pb *testing.PB) {
+		// xorshift, so the key selection doesn't contend.
+		x := seed.Add(1) * 0x9e3779b97f4a7c15
+		for i := 1; pb.Next(); i++ {
+			x ^= x << 13
+			x ^= x >> 7
+			x ^= x << 17
+			key := keys[x%uint64(len(keys))]
+			if int(x>>32%100) < writes {
+				m.Store(key, "value")
+			} else {
+				m.Load(key)
+			}
+			if ticks > 0 && i%ticks == 0 {
+				m.Tick()
+			}
+		}
+	})
+}
+
+// fatal prints err and exits.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "ttlmapbench: %v\n", err)
+	os.Exit(1)
+}
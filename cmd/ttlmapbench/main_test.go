@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	if err := flag.Set("test.benchtime", "10x"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	cases := []Case{{Storage: "sharded", Expirer: "heap", Writes: 50}}
+	if err := run(&buf, Config{Keys: 16, Ticks: 4}, cases); err != nil {
+		t.Fatalf("Expected run to succeed, but got %v", err)
+	}
+
+	var results []Result
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("Expected a JSON report, but got %v", err)
+	} else if len(results) != 1 {
+		t.Errorf("Expected 1 result, but got %d", len(results))
+	} else if results[0].Case != cases[0] || results[0].N == 0 || results[0].Keys != 16 {
+		t.Errorf("Expected a result for %+v, but got %+v", cases[0], results[0])
+	}
+
+	if err := run(&buf, Config{Keys: 16}, []Case{{Storage: "unknown", Expirer: "heap"}}); err == nil {
+		t.Errorf("Expected an error for an unknown engine, but got none")
+	}
+}
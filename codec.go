@@ -0,0 +1,36 @@
+package ttlmap
+
+// Codec encodes values before they are stored and decodes
+// them when they are loaded, see WithCodec.
+type Codec interface {
+	// Encode returns the stored form of value. It must not
+	// modify value.
+	Encode(value []byte) []byte
+	// Decode returns the value that was encoded as data.
+	Decode(data []byte) ([]byte, error)
+}
+
+// WithCodec passes values through codec around storage. A
+// Codec can compress values with a format like snappy, which
+// cuts the memory of caches of large JSON responses, or
+// encrypt them, so they are not kept in memory in plain text.
+//
+// Values are decoded by every load. A value that fails to
+// decode panics, as it was encoded by codec. The codec uses
+// the transform of the map, it replaces WithTransform and
+// WithCompression.
+func WithCodec[K comparable, V ~[]byte | ~string](codec Codec) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.compression = nil
+		m.encoder = func(value V) V {
+			return V(codec.Encode([]byte(value)))
+		}
+		m.decoder = func(value V) V {
+			data, err := codec.Decode([]byte(value))
+			if err != nil {
+				panic("ttlmap: codec failed to decode value: " + err.Error())
+			}
+			return V(data)
+		}
+	}
+}
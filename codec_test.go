@@ -0,0 +1,52 @@
+package ttlmap
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flateCodec is a Codec that compresses values with DEFLATE.
+type flateCodec struct{}
+
+func (flateCodec) Encode(value []byte) []byte {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	_, _ = w.Write(value)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func (flateCodec) Decode(data []byte) ([]byte, error) {
+	return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+}
+
+func TestWithCodec(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithCodec[string, string](flateCodec{}))
+	defer ttlmap.Close()
+	value := strings.Repeat("value", 100)
+	ttlmap.Store("key", value)
+
+	e, _ := ttlmap.storage().Load("key")
+	if loaded, _ := ttlmap.Load("key"); loaded != value {
+		t.Errorf("Expected value to be decoded, but got '%s'", loaded)
+	} else if len(e.value) >= len(value) {
+		t.Errorf("Expected value to be stored encoded, but has %d bytes", len(e.value))
+	}
+}
+
+func TestWithCodecCorrupt(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithCodec[string, []byte](flateCodec{}))
+	defer ttlmap.Close()
+	ttlmap.storage().Store("key", &entry[[]byte]{value: []byte("corrupt")})
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected a corrupt value to panic, but it did not")
+		}
+	}()
+	ttlmap.Load("key")
+}
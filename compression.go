@@ -0,0 +1,155 @@
+package ttlmap
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Compression formats, stored in the first byte of a value.
+const (
+	compressionRaw byte = iota
+	compressionFlate
+)
+
+// compressionMinSize is the size below which values are not
+// compressed, and compressionProbe the number of values that
+// failed to compress in a row after which only every probe'th
+// value is compressed.
+const (
+	compressionMinSize = 64
+	compressionProbe   = 32
+)
+
+// CompressionStats contains the decisions of the compression
+// of a map, see WithCompression.
+type CompressionStats struct {
+	// Compressed is the number of values that were stored
+	// compressed, and Bypassed the number of values that were
+	// compressed but stored as is, because they did not
+	// compress well enough.
+	Compressed uint64
+	Bypassed   uint64
+	// Skipped is the number of values that were stored as is
+	// without compressing them, because they are small or the
+	// previous values did not compress.
+	Skipped uint64
+	// InputBytes and OutputBytes are the sizes of the
+	// compressed values before and after compression.
+	InputBytes  uint64
+	OutputBytes uint64
+}
+
+// Ratio returns the size of the compressed values after
+// compression, relative to their size before it.
+func (s CompressionStats) Ratio() float64 {
+	if s.InputBytes == 0 {
+		return 0
+	}
+	return float64(s.OutputBytes) / float64(s.InputBytes)
+}
+
+// WithCompression compresses values with DEFLATE before they
+// are stored. Values that don't compress to at most maxRatio of
+// their size are stored as is, so incompressible data, like
+// images, doesn't pay for decompression. After a series of
+// values that did not compress, only an occasional value is
+// compressed to detect when the data changes. The decisions
+// are reported by Stats, so maxRatio can be tuned.
+//
+// Compression uses the transform of the map, it replaces
+// WithTransform.
+func WithCompression[K comparable, V ~[]byte | ~string](maxRatio float64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		c := &compression{maxRatio: maxRatio}
+		m.compression = c
+		m.encoder = func(value V) V {
+			return V(c.compress([]byte(value)))
+		}
+		m.decoder = func(value V) V {
+			return V(c.decompress([]byte(value)))
+		}
+	}
+}
+
+// compression compresses the values of a map.
+type compression struct {
+	maxRatio float64
+	writers  sync.Pool
+
+	// failures is the number of values in a row that did not
+	// compress.
+	failures atomic.Uint64
+
+	compressed, bypassed, skipped atomic.Uint64
+	inputBytes, outputBytes       atomic.Uint64
+}
+
+// compress returns the value to store for value.
+func (c *compression) compress(value []byte) []byte {
+	if len(value) < compressionMinSize {
+		c.skipped.Add(1)
+		return raw(value)
+	} else if n := c.failures.Load(); n >= compressionProbe && n%compressionProbe != 0 {
+		c.failures.Add(1)
+		c.skipped.Add(1)
+		return raw(value)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(compressionFlate)
+	w, _ := c.writers.Get().(*flate.Writer)
+	if w == nil {
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	} else {
+		w.Reset(&buf)
+	}
+	_, _ = w.Write(value)
+	_ = w.Close()
+	c.writers.Put(w)
+
+	if float64(buf.Len()-1) > c.maxRatio*float64(len(value)) {
+		c.failures.Add(1)
+		c.bypassed.Add(1)
+		return raw(value)
+	}
+	c.failures.Store(0)
+	c.compressed.Add(1)
+	c.inputBytes.Add(uint64(len(value)))
+	c.outputBytes.Add(uint64(buf.Len() - 1))
+	return buf.Bytes()
+}
+
+// decompress returns the value that was stored as data.
+func (c *compression) decompress(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	} else if data[0] == compressionRaw {
+		return data[1:]
+	}
+
+	value, err := io.ReadAll(flate.NewReader(bytes.NewReader(data[1:])))
+	if err != nil {
+		// Values are only compressed by compress.
+		panic("ttlmap: corrupt compressed value: " + err.Error())
+	}
+	return value
+}
+
+// stats returns the decisions as CompressionStats.
+func (c *compression) stats() *CompressionStats {
+	return &CompressionStats{
+		Compressed:  c.compressed.Load(),
+		Bypassed:    c.bypassed.Load(),
+		Skipped:     c.skipped.Load(),
+		InputBytes:  c.inputBytes.Load(),
+		OutputBytes: c.outputBytes.Load(),
+	}
+}
+
+// raw returns value with the header of an uncompressed value.
+func raw(value []byte) []byte {
+	return append([]byte{compressionRaw}, value...)
+}
@@ -0,0 +1,62 @@
+package ttlmap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompression(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithCompression[string, string](0.9))
+	defer ttlmap.Close()
+
+	text := strings.Repeat("compressible ", 100)
+	ttlmap.Store("text", text)
+	ttlmap.Store("small", "value")
+	random := make([]byte, 1024)
+	_, _ = rand.Read(random)
+	ttlmap.Store("random", string(random))
+
+	stats := ttlmap.Stats().Compression
+	if value, ok := ttlmap.Load("text"); !ok || value != text {
+		t.Errorf("Expected compressed value to be loaded, but got %d bytes", len(value))
+	} else if value, ok := ttlmap.Load("small"); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if value, ok := ttlmap.Load("random"); !ok || value != string(random) {
+		t.Errorf("Expected bypassed value to be loaded, but got %d bytes", len(value))
+	} else if stats == nil {
+		t.Fatalf("Expected compression stats, but got nil")
+	} else if stats.Compressed != 1 || stats.Bypassed != 1 || stats.Skipped != 1 {
+		t.Errorf("Expected 1 compressed, bypassed and skipped value, but got %+v", *stats)
+	} else if stats.InputBytes != uint64(len(text)) || stats.Ratio() >= 0.1 {
+		t.Errorf("Expected the text to compress well, but got %+v", *stats)
+	}
+}
+
+func TestCompressionProbe(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithCompression[string, []byte](0.9))
+	defer ttlmap.Close()
+
+	random := make([]byte, 256)
+	for i := 0; i < 2*compressionProbe; i++ {
+		_, _ = rand.Read(random)
+		ttlmap.Store("random", bytes.Clone(random))
+	}
+	stats := ttlmap.Stats().Compression
+	if stats.Bypassed != compressionProbe+1 || stats.Skipped != compressionProbe-1 {
+		t.Errorf("Expected compression to be probed after %d failures, but got %+v", compressionProbe, *stats)
+	}
+
+	// Compressible values enable compression again.
+	text := []byte(strings.Repeat("compressible ", 100))
+	for i := 0; i < compressionProbe; i++ {
+		ttlmap.Store("text", text)
+	}
+	if value, _ := ttlmap.Load("text"); !bytes.Equal(value, text) {
+		t.Errorf("Expected value to be loaded, but got %d bytes", len(value))
+	} else if stats := ttlmap.Stats().Compression; stats.Compressed == 0 {
+		t.Errorf("Expected compression to be enabled again, but got %+v", *stats)
+	}
+}
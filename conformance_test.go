@@ -0,0 +1,49 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"github.com/job79/ttlmap/ttlmaptest"
+)
+
+// expirers contains a constructor for every expiry engine.
+var expirers = map[string]func() ttlmap.Expirer[string]{
+	"generations": func() ttlmap.Expirer[string] { return ttlmap.NewGenerationExpirer[string](4) },
+	"indexed":     func() ttlmap.Expirer[string] { return ttlmap.NewIndexedGenerationExpirer[string](4) },
+	"list":        func() ttlmap.Expirer[string] { return ttlmap.NewListExpirer[string]() },
+	"heap":        func() ttlmap.Expirer[string] { return ttlmap.NewHeapExpirer[string]() },
+}
+
+func TestConformance(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			ttlmaptest.Conformance(t, func(ttl, interval time.Duration) ttlmap.Interface[string, string] {
+				return ttlmap.New(ttl, interval, ttlmap.WithExpirer[string, string](newExpirer()))
+			})
+		})
+	}
+}
+
+func TestConformanceSharded(t *testing.T) {
+	ttlmaptest.Conformance(t, func(ttl, interval time.Duration) ttlmap.Interface[string, string] {
+		return ttlmap.New(ttl, interval, ttlmap.WithShardedStorage[string, string](0))
+	})
+}
+
+func TestStress(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			m := ttlmap.New(4*time.Hour, time.Hour, ttlmap.WithExpirer[string, string](newExpirer()))
+			defer m.Close()
+			ttlmaptest.Stress(t, m, ttlmaptest.StressOptions{Interval: time.Hour, TTL: 4 * time.Hour})
+		})
+	}
+}
+
+func TestStressSharded(t *testing.T) {
+	m := ttlmap.New(4*time.Hour, time.Hour, ttlmap.WithShardedStorage[string, string](0))
+	defer m.Close()
+	ttlmaptest.Stress(t, m, ttlmaptest.StressOptions{Interval: time.Hour, TTL: 4 * time.Hour})
+}
@@ -0,0 +1,105 @@
+package ttlmap
+
+import (
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnCache caches idle connections, or other resources that
+// must be closed, like net.Conn. Resources are closed when they
+// expire, are replaced or are removed from the cache.
+type ConnCache[K comparable, C io.Closer] struct {
+	conns   *TTLMap[K, C]
+	healthy func(conn C) bool
+
+	// mu orders Close after the running calls, so resources
+	// that are put concurrently are closed as well.
+	mu     sync.RWMutex
+	closed atomic.Bool
+}
+
+// NewConnCache creates a new ConnCache. Idle resources are
+// closed after ttl, see New for the meaning of interval.
+//
+// The healthy function is optional. When set, it is called
+// before a cached resource is returned by Get, and resources
+// for which it returns false are closed instead.
+func NewConnCache[K comparable, C io.Closer](ttl, interval time.Duration, healthy func(conn C) bool) *ConnCache[K, C] {
+	c := &ConnCache[K, C]{healthy: healthy}
+	c.conns = New(ttl, interval, WithOnEvict(func(key K, conn C, reason EvictionReason) {
+		switch {
+		case reason == EvictionDeleted && !c.closed.Load():
+			// Deleted resources are checked out by Get, or
+			// closed by Remove.
+		case reason == EvictionReplaced && c.cached(key, conn):
+			// The resource was put again.
+		default:
+			_ = conn.Close()
+		}
+	}))
+	return c
+}
+
+// Get removes the cached resource for key from the cache and
+// returns it. The ok result indicates whether a healthy
+// resource was found.
+func (c *ConnCache[K, C]) Get(key K) (conn C, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	conn, ok = c.conns.LoadAndDelete(key)
+	if ok && c.healthy != nil && !c.healthy(conn) {
+		_ = conn.Close()
+		return *new(C), false
+	}
+	return conn, ok
+}
+
+// Put caches conn for key, giving it the full TTL. A resource
+// that was cached for key before is closed, unless it is conn.
+// The conn is closed when it can't be cached, for example
+// after Close.
+func (c *ConnCache[K, C]) Put(key K, conn C) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed.Load() {
+		_ = conn.Close()
+	} else if err := c.conns.TryStore(key, conn); err != nil {
+		_ = conn.Close()
+	}
+}
+
+// Remove closes and removes the cached resource for key.
+func (c *ConnCache[K, C]) Remove(key K) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if conn, ok := c.conns.LoadAndDelete(key); ok {
+		_ = conn.Close()
+	}
+}
+
+// Close closes all cached resources and stops the ticker.
+// Resources that are put afterwards are closed.
+func (c *ConnCache[K, C]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed.Store(true)
+	c.conns.Close()
+}
+
+// cached reports whether conn is the resource cached for key.
+// Resources of types that are not comparable, unlike pointers
+// like *net.TCPConn, are never reported as cached.
+func (c *ConnCache[K, C]) cached(key K, conn C) bool {
+	e, ok := c.conns.storage().Load(key)
+	if !ok {
+		return false
+	}
+	current := c.conns.value(e)
+	if t := reflect.TypeOf(conn); t == nil || !t.Comparable() {
+		return false
+	}
+	return any(current) == any(conn)
+}
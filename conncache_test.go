@@ -0,0 +1,128 @@
+package ttlmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	closed bool
+}
+
+func (c *testConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// countingConn counts its calls of Close.
+type countingConn struct {
+	closes atomic.Int32
+}
+
+func (c *countingConn) Close() error {
+	c.closes.Add(1)
+	return nil
+}
+
+func TestConnCache(t *testing.T) {
+	cache := NewConnCache[string, *testConn](time.Hour, time.Minute, nil)
+	conn1, conn2 := &testConn{}, &testConn{}
+	cache.Put("key", conn1)
+	cache.Put("key", conn2)
+
+	if !conn1.closed {
+		t.Errorf("Expected replaced conn to be closed, but was not")
+	} else if conn, ok := cache.Get("key"); !ok || conn != conn2 {
+		t.Errorf("Expected to get conn2, but did not")
+	} else if _, ok = cache.Get("key"); ok {
+		t.Errorf("Expected conn to be removed by Get, but was not")
+	} else if conn2.closed {
+		t.Errorf("Expected returned conn to be open, but was closed")
+	}
+}
+
+func TestConnCacheExpire(t *testing.T) {
+	cache := NewConnCache[string, *testConn](2*time.Hour, time.Hour, nil)
+	conn := &testConn{}
+	cache.Put("key", conn)
+
+	cache.conns.nextGeneration()
+	cache.conns.nextGeneration()
+	if !conn.closed {
+		t.Errorf("Expected expired conn to be closed, but was not")
+	}
+}
+
+func TestConnCacheHealthy(t *testing.T) {
+	cache := NewConnCache[string, *testConn](time.Hour, time.Minute, func(conn *testConn) bool {
+		return false
+	})
+	conn := &testConn{}
+	cache.Put("key", conn)
+
+	if _, ok := cache.Get("key"); ok {
+		t.Errorf("Expected unhealthy conn to be missing, but was found")
+	} else if !conn.closed {
+		t.Errorf("Expected unhealthy conn to be closed, but was not")
+	}
+}
+
+func TestConnCacheClose(t *testing.T) {
+	cache := NewConnCache[string, *testConn](time.Hour, time.Minute, nil)
+	conn := &testConn{}
+	cache.Put("key", conn)
+	cache.Close()
+
+	if !conn.closed {
+		t.Errorf("Expected conn to be closed, but was not")
+	}
+}
+
+func TestConnCachePutSame(t *testing.T) {
+	cache := NewConnCache[string, *testConn](time.Hour, time.Minute, nil)
+	defer cache.Close()
+	conn := &testConn{}
+	cache.Put("key", conn)
+	cache.Put("key", conn)
+
+	if conn.closed {
+		t.Errorf("Expected conn that is put again to stay open, but it was closed")
+	} else if got, ok := cache.Get("key"); !ok || got != conn {
+		t.Errorf("Expected to get conn, but did not")
+	}
+}
+
+func TestConnCachePutAfterClose(t *testing.T) {
+	cache := NewConnCache[string, *testConn](time.Hour, time.Minute, nil)
+	cache.Close()
+	conn := &testConn{}
+	cache.Put("key", conn)
+
+	if !conn.closed {
+		t.Errorf("Expected conn put after Close to be closed, but was not")
+	}
+}
+
+func TestConnCachePutRacingClose(t *testing.T) {
+	cache := NewConnCache[int, *countingConn](time.Hour, time.Minute, nil)
+	conns := make([]*countingConn, 100)
+	var wg sync.WaitGroup
+	for i := range conns {
+		conns[i] = &countingConn{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Put(i, conns[i])
+		}()
+	}
+	cache.Close()
+	wg.Wait()
+
+	for i, conn := range conns {
+		if n := conn.closes.Load(); n != 1 {
+			t.Errorf("Expected conn %d to be closed once, but was closed %d times", i, n)
+		}
+	}
+}
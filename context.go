@@ -0,0 +1,48 @@
+package ttlmap
+
+import (
+	"context"
+	"time"
+)
+
+// NewWithContext creates a TTLMap like New, which is closed
+// when ctx is done. This ties the ticker of the map to the
+// lifetime of a server or request, so forgetting to call
+// Close does not leak it. See Close for what closing does.
+func NewWithContext[K comparable, V any](ctx context.Context, ttl, interval time.Duration, opts ...Option[K, V]) *TTLMap[K, V] {
+	ttlMap := New(ttl, interval, opts...)
+	context.AfterFunc(ctx, ttlMap.Close)
+	return ttlMap
+}
+
+// KeyFromContext derives the key for a request from ctx and
+// key with derive, like prefixing the key with the tenant or
+// user stored in ctx. When derive is nil, key is returned. It
+// is used by LoadForContext and StoreForContext, so request
+// scoped entries are keyed consistently.
+func KeyFromContext[K comparable](ctx context.Context, key K, derive func(ctx context.Context, key K) K) K {
+	if derive == nil {
+		return key
+	}
+	return derive(ctx, key)
+}
+
+// WithContextKey sets the function that derives keys from a
+// context, see LoadForContext and StoreForContext.
+func WithContextKey[K comparable, V any](derive func(ctx context.Context, key K) K) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.contextKey = derive
+	}
+}
+
+// LoadForContext returns the value stored in the map for the
+// key derived from ctx and key, see WithContextKey and Load.
+func (m *TTLMap[K, V]) LoadForContext(ctx context.Context, key K) (V, bool) {
+	return m.Load(KeyFromContext(ctx, key, m.contextKey))
+}
+
+// StoreForContext sets the value for the key derived from ctx
+// and key, see WithContextKey and Store.
+func (m *TTLMap[K, V]) StoreForContext(ctx context.Context, key K, value V) {
+	m.Store(KeyFromContext(ctx, key, m.contextKey), value)
+}
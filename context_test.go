@@ -0,0 +1,38 @@
+package ttlmap
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+
+func TestLoadForContext(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithContextKey[string, string](func(ctx context.Context, key string) string {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return tenant + "/" + key
+	}))
+	ctx1 := context.WithValue(context.Background(), tenantKey{}, "tenant1")
+	ctx2 := context.WithValue(context.Background(), tenantKey{}, "tenant2")
+	ttlmap.StoreForContext(ctx1, "key", "value")
+
+	if value, ok := ttlmap.LoadForContext(ctx1, "key"); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if _, ok = ttlmap.LoadForContext(ctx2, "key"); ok {
+		t.Errorf("Expected to not find key of another tenant, but did")
+	} else if _, ok = ttlmap.Load("tenant1/key"); !ok {
+		t.Errorf("Expected to find derived key, but did not")
+	}
+}
+
+func TestNewWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ttlmap := NewWithContext[string, string](ctx, time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+	cancel()
+
+	if !waitFor(func() bool { return ttlmap.closed.Load() && ttlmap.Len() == 0 }) {
+		t.Errorf("Expected map to be closed and cleared, but was not")
+	}
+}
@@ -0,0 +1,38 @@
+package ttlmap
+
+import "time"
+
+// Deduplicate reports whether this is the first call for key
+// within window. It returns true for the first call, and false
+// for every following call until the window has passed, which
+// is useful to drop duplicate webhooks or notifications.
+//
+// A window of zero uses the TTL of the map. Windows are
+// rounded down to a multiple of the interval, with a minimum
+// of one interval, and windows longer than the TTL of the map
+// are shortened to it. The key is stored with the zero value.
+// While the map is frozen it returns false.
+func (m *TTLMap[K, V]) Deduplicate(key K, window time.Duration) bool {
+	defer m.operation()()
+	key = m.key(key)
+	if m.Frozen() {
+		return false
+	}
+
+	ticks := m.ttlTicks.Load()
+	if window > 0 && uint64(window/m.tickInterval()) < ticks {
+		ticks = uint64(window / m.tickInterval())
+		if ticks == 0 {
+			ticks = 1
+		}
+	}
+
+	e := m.newEntry(*new(V))
+	e.expires.Store(m.tick.Load() + ticks)
+	if _, loaded := m.storage().LoadOrStore(key, e); loaded {
+		return false
+	}
+	m.schedule(e.expires.Load(), key)
+	m.added(key, e)
+	return true
+}
@@ -0,0 +1,26 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeduplicate(t *testing.T) {
+	ttlmap := New[string, struct{}](time.Hour, time.Minute)
+
+	if !ttlmap.Deduplicate("key1", 0) {
+		t.Errorf("Expected first call to not be a duplicate, but was")
+	} else if ttlmap.Deduplicate("key1", 0) {
+		t.Errorf("Expected second call to be a duplicate, but was not")
+	} else if !ttlmap.Deduplicate("key2", 2*time.Minute) {
+		t.Errorf("Expected first call to not be a duplicate, but was")
+	}
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if !ttlmap.Deduplicate("key2", 2*time.Minute) {
+		t.Errorf("Expected call after the window to not be a duplicate, but was")
+	} else if ttlmap.Deduplicate("key1", 0) {
+		t.Errorf("Expected call within the window to be a duplicate, but was not")
+	}
+}
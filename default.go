@@ -0,0 +1,67 @@
+package ttlmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultTTL is the ttl of the lazily created default map.
+	DefaultTTL = 5 * time.Minute
+
+	// DefaultInterval is the interval of the lazily created
+	// default map.
+	DefaultInterval = 5 * time.Second
+)
+
+var (
+	defaultMap   atomic.Pointer[TTLMap[any, any]]
+	defaultMapMu sync.Mutex
+)
+
+// Default returns the process-wide default map that is used by
+// the package-level functions. It is created with DefaultTTL
+// and DefaultInterval on first use, unless SetDefault is
+// called before.
+func Default() *TTLMap[any, any] {
+	if m := defaultMap.Load(); m != nil {
+		return m
+	}
+
+	defaultMapMu.Lock()
+	defer defaultMapMu.Unlock()
+	if m := defaultMap.Load(); m != nil {
+		return m
+	}
+
+	m := New[any, any](DefaultTTL, DefaultInterval)
+	defaultMap.Store(m)
+	return m
+}
+
+// SetDefault replaces the default map. The previous default
+// map is not closed.
+func SetDefault(m *TTLMap[any, any]) {
+	defaultMapMu.Lock()
+	defer defaultMapMu.Unlock()
+	defaultMap.Store(m)
+}
+
+// Load returns the value stored in the default map for a key.
+// See TTLMap.Load.
+func Load(key any) (any, bool) {
+	return Default().Load(key)
+}
+
+// Store sets the value for a key in the default map. See
+// TTLMap.Store.
+func Store(key, value any) {
+	Default().Store(key, value)
+}
+
+// Delete deletes the value for a key from the default map.
+// See TTLMap.Delete.
+func Delete(key any) {
+	Default().Delete(key)
+}
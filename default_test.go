@@ -0,0 +1,35 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefault(t *testing.T) {
+	Store("key", "value")
+	if value, ok := Load("key"); !ok {
+		t.Errorf("Expected to find key, but did not")
+	} else if value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%v'", value)
+	}
+
+	Delete("key")
+	if _, ok := Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	ttlmap := New[any, any](time.Hour, time.Minute)
+	SetDefault(ttlmap)
+	Store("key", "value")
+
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key in new default map, but did not")
+	} else if _, ok = previous.Load("key"); ok {
+		t.Errorf("Expected to not find key in previous default map, but did")
+	}
+}
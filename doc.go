@@ -0,0 +1,22 @@
+// Package ttlmap provides an efficient concurrent map with TTL
+// support.
+//
+// # Stability
+//
+// The API follows semantic versioning, with two tiers:
+//
+//   - Stable APIs don't change in a backwards incompatible way
+//     before the next major version. This is everything that is
+//     not marked as experimental.
+//   - Experimental APIs are marked with "Experimental:" in their
+//     documentation. They are complete and tested, but may change
+//     in a minor version while their design settles. These are the
+//     subsystems that connect a map to the world outside the
+//     process: backends, replication and persistence.
+//
+// The package only depends on the standard library. Subsystems
+// that need other dependencies, like servers and exporters, are
+// separate modules in subdirectories of the repository, with
+// their own go.mod, such as ttlmapprom. Importing the package
+// never pulls them in.
+package ttlmap
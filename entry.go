@@ -0,0 +1,126 @@
+package ttlmap
+
+import "sync/atomic"
+
+// Entry is a snapshot of an entry stored in a TTLMap.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+
+	// Meta is the metadata attached with StoreWithMeta, or
+	// nil.
+	Meta any
+}
+
+// entry is the value stored in the storage engine.
+type entry[V any] struct {
+	value V
+	meta  any
+	label *labelStats
+
+	// created is the tick at which the entry was stored.
+	created uint64
+
+	// stamped is the time in unix nanoseconds at which the
+	// TTL of the entry started, it is 0 unless WithStrictTTL
+	// is used.
+	stamped atomic.Int64
+
+	// used is one past the last tick at which the entry was
+	// stored or loaded, or 0 when trim cleared it. Trim evicts
+	// entries that are not used in the current tick first.
+	used atomic.Uint64
+
+	// blob is the handle of the value in the blob store, the
+	// value is zero when it is set. See WithBlobStore.
+	blob string
+
+	// seq orders entries by insertion, it is 0 unless
+	// WithOrderedExpiry is used.
+	seq uint64
+
+	// ttl is the TTL of the entry in ticks when it was stored
+	// with StoreWithTTL, or 0 for the TTL of the map. It is
+	// atomic, so SetTTL can convert it to another interval.
+	ttl atomic.Uint64
+
+	// expires is the tick at which the entry expires. It is
+	// set to 0 once the entry is claimed by nextGeneration.
+	expires atomic.Uint64
+
+	// stale is set when the entry expired, but is kept for a
+	// generation, see WithStaleWhileRevalidate.
+	stale atomic.Bool
+
+	// extensions is the number of times the entry was kept
+	// by WithExtendOnExpire, it is only used by the ticker.
+	extensions uint32
+
+	// removed is set when the entry is removed from the map,
+	// it is only used by WithStrict.
+	removed atomic.Bool
+
+	// quarantined is the time in unix nanoseconds until which
+	// loads miss the entry, see Quarantine.
+	quarantined atomic.Int64
+
+	// restored is set when the entry is stored by a restore,
+	// and restoreDeleted when it is deleted by one. The
+	// write-ahead log records neither, see restoreStore.
+	restored       bool
+	restoreDeleted bool
+}
+
+// StoreWithMeta sets the value for a key and attaches meta to
+// it. The metadata can be retrieved with GetEntry, which
+// allows carrying information like the source or version of a
+// value without wrapping the value type. It is a no-op while
+// the map is frozen.
+func (m *TTLMap[K, V]) StoreWithMeta(key K, value V, meta any) {
+	_, _ = m.store(key, &entry[V]{value: value, meta: meta})
+}
+
+// GetEntry returns the entry stored in the map for a key,
+// including its metadata. The ok result indicates whether the
+// entry was found in the map.
+func (m *TTLMap[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	key = m.key(key)
+	e, ok := m.storage().Load(key)
+	if !ok || m.hidden(e) {
+		m.miss()
+		return Entry[K, V]{}, false
+	}
+
+	m.hit(e)
+	return Entry[K, V]{Key: key, Value: m.value(e), Meta: e.meta}, true
+}
+
+// with returns a copy of the entry with another value. The
+// copy has the same deadline, so it replaces the entry as the
+// same logical entry.
+func (e *entry[V]) with(value V) *entry[V] {
+	c := &entry[V]{value: value, meta: e.meta, label: e.label, created: e.created, seq: e.seq}
+	c.ttl.Store(e.ttl.Load())
+	c.expires.Store(e.expires.Load())
+	c.stamped.Store(e.stamped.Load())
+	c.used.Store(e.used.Load())
+	return c
+}
+
+// touch sets the deadline of the entry. The moved result
+// reports whether the key must be added to the generation of
+// the new deadline, ok is false when the entry expired
+// concurrently.
+func (e *entry[V]) touch(deadline uint64) (ok, moved bool) {
+	for {
+		expires := e.expires.Load()
+		if expires == 0 {
+			return false, false
+		} else if expires == deadline {
+			return true, false
+		} else if e.expires.CompareAndSwap(expires, deadline) {
+			e.stale.Store(false)
+			return true, true
+		}
+	}
+}
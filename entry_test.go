@@ -0,0 +1,28 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreWithMeta(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.StoreWithMeta("key", "value", "source")
+
+	if e, ok := ttlmap.GetEntry("key"); !ok {
+		t.Errorf("Expected to find key, but did not")
+	} else if e.Key != "key" || e.Value != "value" {
+		t.Errorf("Expected entry to be 'key' 'value', but was '%s' '%s'", e.Key, e.Value)
+	} else if e.Meta != "source" {
+		t.Errorf("Expected meta to be 'source', but was '%v'", e.Meta)
+	}
+
+	ttlmap.Store("key", "value2")
+	if e, ok := ttlmap.GetEntry("key"); !ok {
+		t.Errorf("Expected to find key, but did not")
+	} else if e.Meta != nil {
+		t.Errorf("Expected meta to be nil after Store, but was '%v'", e.Meta)
+	} else if _, ok = ttlmap.GetEntry("missing"); ok {
+		t.Errorf("Expected to not find missing, but did")
+	}
+}
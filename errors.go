@@ -0,0 +1,91 @@
+package ttlmap
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrExists is returned by Add, and by stores to a
+	// write-once map, when the key is already present in the
+	// map.
+	ErrExists = errors.New("ttlmap: key already exists")
+
+	// ErrFrozen is returned by mutating operations while the
+	// map is frozen.
+	ErrFrozen = errors.New("ttlmap: map is frozen")
+
+	// ErrNotAdmitted is returned by stores that are rejected
+	// by the admission function, see WithAdmission.
+	ErrNotAdmitted = errors.New("ttlmap: value not admitted")
+
+	// ErrFull is returned by stores of new keys while the map
+	// is at its hard limit, see WithHardLimit.
+	ErrFull = errors.New("ttlmap: map is full")
+
+	// ErrClosed is returned by stores, and operations that
+	// need the ticker of a map, after it was closed.
+	ErrClosed = errors.New("ttlmap: map is closed")
+
+	// ErrInterval is returned by SetTTL when the interval of
+	// a child differs from the interval of its parent.
+	ErrInterval = errors.New("ttlmap: interval differs from the parent")
+
+	// ErrChild is returned by SetScheduler for child maps,
+	// which are advanced by their parent.
+	ErrChild = errors.New("ttlmap: map is a child")
+
+	// ErrNotFound is returned by operations that require the
+	// key to be present in the map.
+	ErrNotFound = errors.New("ttlmap: key not found")
+
+	// ErrThrottled is returned by operations that are
+	// rejected by a rate limit, see WithMissPenalty.
+	ErrThrottled = errors.New("ttlmap: throttled")
+
+	// ErrNoSnapshot is returned by ReadSnapshot when no valid
+	// snapshot is found.
+	ErrNoSnapshot = errors.New("ttlmap: no valid snapshot")
+
+	// ErrLoader matches every LoaderError with errors.Is.
+	ErrLoader = errors.New("ttlmap: loader failed")
+)
+
+// LoaderError is returned when the function that loads a
+// missing value fails. It wraps the error of the function, and
+// matches ErrLoader with errors.Is.
+type LoaderError struct {
+	// Key is the key that was loaded.
+	Key any
+	// Err is the error returned by the function.
+	Err error
+}
+
+// Error implements error.
+func (e *LoaderError) Error() string {
+	return fmt.Sprintf("ttlmap: loading %v: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the error of the function.
+func (e *LoaderError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrLoader.
+func (e *LoaderError) Is(target error) bool {
+	return target == ErrLoader
+}
+
+// PanicError is a panic that was recovered in the background
+// work of a map, see TTLMap.Err.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("ttlmap: panic: %v", e.Value)
+}
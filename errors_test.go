@@ -0,0 +1,22 @@
+package ttlmap
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestLoaderError(t *testing.T) {
+	var err error = &LoaderError{Key: "key", Err: io.EOF}
+
+	var loaderErr *LoaderError
+	if !errors.Is(err, ErrLoader) {
+		t.Errorf("Expected error to be ErrLoader, but was not")
+	} else if !errors.Is(err, io.EOF) {
+		t.Errorf("Expected error to wrap io.EOF, but did not")
+	} else if !errors.As(err, &loaderErr) || loaderErr.Key != "key" {
+		t.Errorf("Expected error to be a LoaderError for key, but was not")
+	} else if err.Error() != "ttlmap: loading key: EOF" {
+		t.Errorf("Expected message 'ttlmap: loading key: EOF', but was '%s'", err)
+	}
+}
@@ -0,0 +1,67 @@
+package ttlmap
+
+import "time"
+
+// EventLog keeps a sliding window of events per key. Events
+// older than the TTL are pruned automatically, which allows
+// tracking recent activity per key without unbounded growth.
+type EventLog[K comparable, E any] struct {
+	events *TTLMap[K, []loggedEvent[E]]
+}
+
+// loggedEvent is an event with the tick it was appended at.
+type loggedEvent[E any] struct {
+	tick  uint64
+	event E
+}
+
+// NewEventLog creates a new EventLog. Events are kept for
+// ttl, see New for the meaning of interval.
+func NewEventLog[K comparable, E any](ttl, interval time.Duration) *EventLog[K, E] {
+	return &EventLog[K, E]{New[K, []loggedEvent[E]](ttl, interval)}
+}
+
+// AppendEvent appends event to the events of key. Keys are
+// removed when no event was appended for the TTL.
+func (l *EventLog[K, E]) AppendEvent(key K, event E) {
+	_, ok := l.events.update(key, func(old []loggedEvent[E], _ bool) []loggedEvent[E] {
+		events := l.prune(old)
+		val := make([]loggedEvent[E], len(events)+1)
+		copy(val, events)
+		val[len(events)] = loggedEvent[E]{tick: l.events.tick.Load(), event: event}
+		return val
+	})
+	if ok {
+		l.events.LoadAndTouch(key)
+	}
+}
+
+// Events returns the events of key that are not older than
+// the TTL, oldest first.
+func (l *EventLog[K, E]) Events(key K) []E {
+	logged, _ := l.events.Load(key)
+	logged = l.prune(logged)
+
+	events := make([]E, len(logged))
+	for i, e := range logged {
+		events[i] = e.event
+	}
+	return events
+}
+
+// Close stops the event log.
+func (l *EventLog[K, E]) Close() {
+	l.events.Close()
+}
+
+// prune returns the events that are not older than the TTL.
+// The events are not modified.
+func (l *EventLog[K, E]) prune(events []loggedEvent[E]) []loggedEvent[E] {
+	tick := l.events.tick.Load()
+	for i, e := range events {
+		if e.tick+l.events.ttlTicks.Load() > tick {
+			return events[i:]
+		}
+	}
+	return nil
+}
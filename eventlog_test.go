@@ -0,0 +1,30 @@
+package ttlmap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventLog(t *testing.T) {
+	log := NewEventLog[string, string](2*time.Hour, time.Hour)
+	log.AppendEvent("key", "event1")
+	log.events.nextGeneration()
+	log.AppendEvent("key", "event2")
+
+	if events := log.Events("key"); !reflect.DeepEqual(events, []string{"event1", "event2"}) {
+		t.Errorf("Expected events to be [event1 event2], but were %v", events)
+	}
+
+	log.events.nextGeneration()
+	if events := log.Events("key"); !reflect.DeepEqual(events, []string{"event2"}) {
+		t.Errorf("Expected events to be [event2], but were %v", events)
+	}
+
+	log.events.nextGeneration()
+	if events := log.Events("key"); len(events) != 0 {
+		t.Errorf("Expected no events, but got %v", events)
+	} else if _, ok := log.events.Load("key"); ok {
+		t.Errorf("Expected key to be removed, but was not")
+	}
+}
@@ -0,0 +1,117 @@
+package ttlmap
+
+import "time"
+
+// EvictionReason is the reason a value left the map, see
+// WithOnEvict.
+type EvictionReason int
+
+const (
+	// EvictionExpired is used for entries that expired.
+	EvictionExpired EvictionReason = iota
+	// EvictionDeleted is used for entries that were deleted.
+	EvictionDeleted
+	// EvictionReplaced is used for values that were replaced
+	// by a store, CompareAndSwap, an update of a typed map or
+	// ReplaceAll.
+	EvictionReplaced
+	// EvictionCapacity is used for entries that were evicted
+	// because the map exceeded its limit, see WithSoftLimit.
+	EvictionCapacity
+)
+
+// String returns the name of the reason.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionExpired:
+		return "expired"
+	case EvictionDeleted:
+		return "deleted"
+	case EvictionReplaced:
+		return "replaced"
+	case EvictionCapacity:
+		return "capacity"
+	default:
+		return "unknown"
+	}
+}
+
+// WithOnEvict sets a function that is called for every value
+// that leaves the map, with the reason it left. This allows
+// releasing external resources, like closing connections, when
+// entries disappear.
+//
+// The function is called synchronously by the goroutine that
+// removed the value, which is the ticker for expired entries.
+// It should not block, see WithAsyncEvictions for expensive
+// functions. The order in which the entries of a
+// generation expire is unspecified, see WithOrderedExpiry.
+func WithOnEvict[K comparable, V any](onEvict func(key K, value V, reason EvictionReason)) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.onEvict = onEvict
+	}
+}
+
+// Eviction describes an entry that expired, see
+// RecentEvictions.
+type Eviction[K comparable] struct {
+	Key K
+	// Lifetime is the time the entry spent in the map, with
+	// the precision of the interval.
+	Lifetime time.Duration
+	// Size is the size of the value reported by the size
+	// function of WithRecentEvictions, or 0.
+	Size int64
+}
+
+// evictionLog records the last expired entries.
+type evictionLog[K comparable, V any] struct {
+	evictions *ring[Eviction[K]]
+	size      func(key K, value V) int64
+}
+
+// WithRecentEvictions records the last n entries that expired,
+// which shows operators what the map is churning through. The
+// size function is optional, when set it is called with every
+// expired key and its stored value, which is encoded when
+// WithTransform is used.
+func WithRecentEvictions[K comparable, V any](n int, size func(key K, value V) int64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.evictions = &evictionLog[K, V]{evictions: newRing[Eviction[K]](n), size: size}
+	}
+}
+
+// RecentEvictions returns up to the n most recently expired
+// entries, most recent first. It is empty when the map is not
+// created with WithRecentEvictions.
+func (m *TTLMap[K, V]) RecentEvictions(n int) []Eviction[K] {
+	if m.evictions == nil {
+		return nil
+	}
+
+	evictions := m.evictions.evictions.items()
+	if n < len(evictions) {
+		evictions = evictions[len(evictions)-n:]
+	}
+	for i, j := 0, len(evictions)-1; i < j; i, j = i+1, j-1 {
+		evictions[i], evictions[j] = evictions[j], evictions[i]
+	}
+	return evictions
+}
+
+// evicted records an expired entry when recent evictions are
+// recorded.
+func (m *TTLMap[K, V]) evicted(key K, e *entry[V]) {
+	if m.evictions == nil {
+		return
+	}
+
+	eviction := Eviction[K]{
+		Key:      key,
+		Lifetime: time.Duration(m.tick.Load()-e.created) * m.tickInterval(),
+	}
+	if m.evictions.size != nil {
+		eviction.Size = m.evictions.size(key, e.value)
+	}
+	m.evictions.evictions.add(eviction)
+}
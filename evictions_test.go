@@ -0,0 +1,57 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecentEvictions(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithRecentEvictions(2, func(_ string, value string) int64 {
+		return int64(len(value))
+	}))
+	ttlmap.Store("key1", "v")
+	ttlmap.Store("key2", "value")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key3", "value3")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+
+	evictions := ttlmap.RecentEvictions(5)
+	if len(evictions) != 2 {
+		t.Errorf("Expected 2 evictions, but got %d", len(evictions))
+	} else if evictions[0].Key != "key3" || evictions[0].Size != 6 {
+		t.Errorf("Expected key3 of size 6, but got %v", evictions[0])
+	} else if evictions[0].Lifetime != 2*time.Hour {
+		t.Errorf("Expected lifetime to be 2h, but was %s", evictions[0].Lifetime)
+	} else if evictions = ttlmap.RecentEvictions(1); len(evictions) != 1 || evictions[0].Key != "key3" {
+		t.Errorf("Expected only key3, but got %v", evictions)
+	}
+}
+
+func TestWithOnEvict(t *testing.T) {
+	reasons := make(map[string]EvictionReason)
+	ttlmap := New(time.Hour, time.Hour, WithOnEvict(func(key string, _ string, reason EvictionReason) {
+		reasons[key] = reason
+	}))
+	ttlmap.Store("expired", "value")
+	ttlmap.Store("deleted", "value")
+	ttlmap.Store("replaced", "value")
+	ttlmap.Delete("deleted")
+	ttlmap.Store("replaced", "other")
+
+	if reasons["deleted"] != EvictionDeleted {
+		t.Errorf("Expected reason to be deleted, but was %s", reasons["deleted"])
+	} else if reasons["replaced"] != EvictionReplaced {
+		t.Errorf("Expected reason to be replaced, but was %s", reasons["replaced"])
+	} else if _, ok := reasons["expired"]; ok {
+		t.Errorf("Expected key to not be evicted yet, but was")
+	}
+
+	delete(reasons, "replaced")
+	ttlmap.nextGeneration()
+	if reasons["expired"] != EvictionExpired {
+		t.Errorf("Expected reason to be expired, but was %s", reasons["expired"])
+	} else if reasons["replaced"] != EvictionExpired {
+		t.Errorf("Expected reason to be expired, but was %s", reasons["replaced"])
+	}
+}
@@ -0,0 +1,17 @@
+// Package examples contains small programs that use ttlmap,
+// one per directory:
+//
+//   - sessions is a session store with idle expiration.
+//   - ratelimit is a fixed window rate limiter.
+//   - readthrough is a read-through cache over an HTTP API.
+//   - warmrestart restores a cache from a snapshot after a
+//     restart.
+//
+// They are run with go run from this directory:
+//
+//	go run ./sessions
+//
+// Every program has a test that runs it, so the examples keep
+// working as the API evolves. They are a separate module,
+// importing ttlmap never pulls them in.
+package examples
@@ -0,0 +1,7 @@
+module github.com/job79/ttlmap/examples
+
+go 1.24
+
+require github.com/job79/ttlmap v0.0.0-20261015085731-387ee54aba43
+
+replace github.com/job79/ttlmap => ../
@@ -0,0 +1,53 @@
+// Command ratelimit is a fixed window rate limiter: every
+// client may make 3 requests per minute. The counter of a
+// client is created with the TTL of the map, which is the
+// window, and Compute keeps its deadline when it counts.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Limiter limits the requests of clients.
+type Limiter struct {
+	m     *ttlmap.TTLMap[string, int]
+	limit int
+}
+
+// Allow counts a request of client, and reports whether it is
+// within the limit.
+func (l *Limiter) Allow(client string) bool {
+	n, _ := l.m.Compute(client, func(old int, exists bool) (int, bool) {
+		return old + 1, false
+	})
+	return n <= l.limit
+}
+
+// run simulates a client that makes 5 requests, waits for the
+// window to pass, and makes another one.
+func run(w io.Writer) error {
+	// A manual map is advanced by the program, so it runs
+	// instantly. A real limiter uses ttlmap.New.
+	m := ttlmap.NewManual[string, int](time.Minute, time.Second)
+	defer m.Close()
+	l := &Limiter{m: m, limit: 3}
+
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(w, "request %d allowed: %t\n", i, l.Allow("client"))
+	}
+	m.Advance(60)
+	fmt.Fprintf(w, "request 6 allowed: %t\n", l.Allow("client"))
+	return nil
+}
+
+func main() {
+	if err := run(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
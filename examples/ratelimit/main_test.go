@@ -0,0 +1,17 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	want := "request 1 allowed: true\nrequest 2 allowed: true\nrequest 3 allowed: true\n" +
+		"request 4 allowed: false\nrequest 5 allowed: false\nrequest 6 allowed: true\n"
+	if err := run(&buf); err != nil {
+		t.Fatalf("Expected run to succeed, but got %v", err)
+	} else if buf.String() != want {
+		t.Errorf("Expected output '%s', but got '%s'", want, buf.String())
+	}
+}
@@ -0,0 +1,94 @@
+// Command readthrough is a read-through cache over an HTTP
+// API. Loads that miss fetch the value from the API, and cache
+// it for the max-age of its response. Keys that the API
+// doesn't know are penalized after a few misses, so clients
+// that ask for them don't reach the API.
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// API is a read-only ttlmap.Backend over an HTTP API, which
+// serves the value of a key at its path.
+type API struct {
+	URL string
+}
+
+// Get fetches the value of key.
+func (a API) Get(key string) (string, time.Duration, bool, error) {
+	resp, err := http.Get(a.URL + "/" + key)
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", 0, false, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return "", 0, false, fmt.Errorf("readthrough: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, false, err
+	}
+	var ttl time.Duration
+	if maxAge, ok := strings.CutPrefix(resp.Header.Get("Cache-Control"), "max-age="); ok {
+		seconds, _ := strconv.Atoi(maxAge)
+		ttl = time.Duration(seconds) * time.Second
+	}
+	return string(body), ttl, true, nil
+}
+
+// Set does nothing, the API is read-only.
+func (API) Set(string, string, time.Duration) error { return nil }
+
+// Delete does nothing, the API is read-only.
+func (API) Delete(string) error { return nil }
+
+// run loads keys from an API that knows one, and reports how
+// often the API was called.
+func run(w io.Writer) error {
+	var calls atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/greeting" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, "hello")
+	}))
+	defer api.Close()
+
+	m := ttlmap.New(time.Hour, time.Second,
+		ttlmap.WithBackend[string, string](API{URL: api.URL}, ttlmap.BackendOptions{}),
+		ttlmap.WithMissPenalty[string, string](time.Minute, 3, time.Minute))
+	defer m.Close()
+
+	for range 10 {
+		m.Load("greeting")
+		m.Load("unknown")
+	}
+	greeting, _ := m.Load("greeting")
+	fmt.Fprintf(w, "greeting: %s\n", greeting)
+	fmt.Fprintf(w, "API calls: %d\n", calls.Load())
+	return nil
+}
+
+func main() {
+	if err := run(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	// The greeting is fetched once, the unknown key until it
+	// is penalized.
+	var buf bytes.Buffer
+	if err := run(&buf); err != nil {
+		t.Fatalf("Expected run to succeed, but got %v", err)
+	} else if want := "greeting: hello\nAPI calls: 4\n"; buf.String() != want {
+		t.Errorf("Expected output '%s', but got '%s'", want, buf.String())
+	}
+}
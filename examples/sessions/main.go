@@ -0,0 +1,70 @@
+// Command sessions is a session store with idle expiration:
+// sessions expire after 30 minutes without requests, and every
+// request extends the session.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Session is the state of a logged in user.
+type Session struct {
+	User string
+}
+
+// Store stores sessions by id.
+type Store struct {
+	m *ttlmap.TTLMap[string, Session]
+}
+
+// Create creates a session for user and returns its id.
+func (s *Store) Create(user string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+	s.m.Store(id, Session{User: user})
+	return id
+}
+
+// Get returns the session of id, and extends it.
+func (s *Store) Get(id string) (Session, bool) {
+	return s.m.LoadAndTouch(id)
+}
+
+// run simulates two users, one of which stays active.
+func run(w io.Writer) error {
+	// A manual map is advanced by the program, so it runs
+	// instantly. A real store uses ttlmap.New.
+	m := ttlmap.NewManual[string, Session](30*time.Minute, time.Minute)
+	defer m.Close()
+	s := &Store{m: m}
+
+	alice, bob := s.Create("alice"), s.Create("bob")
+	for range 3 {
+		m.Advance(20)
+		s.Get(alice)
+	}
+
+	for _, id := range []string{alice, bob} {
+		if session, ok := s.Get(id); ok {
+			fmt.Fprintf(w, "%s is logged in\n", session.User)
+		} else {
+			fmt.Fprintln(w, "session expired")
+		}
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
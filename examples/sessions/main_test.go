@@ -0,0 +1,15 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run(&buf); err != nil {
+		t.Fatalf("Expected run to succeed, but got %v", err)
+	} else if want := "alice is logged in\nsession expired\n"; buf.String() != want {
+		t.Errorf("Expected output '%s', but got '%s'", want, buf.String())
+	}
+}
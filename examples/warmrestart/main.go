@@ -0,0 +1,60 @@
+// Command warmrestart restores a cache after a restart, so it
+// doesn't start cold. The cache is persisted to a file every
+// minute and when it is closed, with a write-ahead log of the
+// stores in between, and restored from them when it is
+// created. Entries keep the time at which they expire.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// open opens the cache persisted at path.
+func open(path string) *ttlmap.TTLMap[string, string] {
+	return ttlmap.New(time.Hour, time.Second,
+		ttlmap.WithPersistence[string, string](path, time.Minute),
+		ttlmap.WithWriteAheadLog[string, string]())
+}
+
+// run fills a cache, restarts it, and prints what survived.
+func run(w io.Writer, dir string) error {
+	path := filepath.Join(dir, "cache")
+
+	m := open(path)
+	m.Store("user:1", "alice")
+	m.StoreWithTTL("user:2", "bob", 10*time.Minute)
+	m.Close()
+	if err := m.PersistenceError(); err != nil {
+		return err
+	}
+
+	m = open(path)
+	defer m.Close()
+	if err := m.PersistenceError(); err != nil {
+		return err
+	}
+	for _, key := range []string{"user:1", "user:2"} {
+		value, _ := m.Load(key)
+		expiresAt, _ := m.ExpiresAt(key)
+		fmt.Fprintf(w, "%s: %s, expires in %d minutes\n", key, value, (time.Until(expiresAt)+30*time.Second)/time.Minute)
+	}
+	return nil
+}
+
+func main() {
+	dir, err := os.MkdirTemp("", "warmrestart")
+	if err == nil {
+		defer os.RemoveAll(dir)
+		err = run(os.Stdout, dir)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	want := "user:1: alice, expires in 60 minutes\nuser:2: bob, expires in 10 minutes\n"
+	if err := run(&buf, t.TempDir()); err != nil {
+		t.Fatalf("Expected run to succeed, but got %v", err)
+	} else if buf.String() != want {
+		t.Errorf("Expected output '%s', but got '%s'", want, buf.String())
+	}
+}
@@ -0,0 +1,104 @@
+package ttlmap
+
+import "sync/atomic"
+
+// ExpiredEntry is an entry that expired, see Expired.
+type ExpiredEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// expiredChannel delivers expired entries to a consumer.
+type expiredChannel[K comparable, V any] struct {
+	c       chan ExpiredEntry[K, V]
+	block   bool
+	dropped atomic.Uint64
+}
+
+// WithExpiredChannel delivers expired entries on the channel
+// returned by Expired, which allows processing expirations
+// asynchronously, like writing them behind to a database.
+//
+// The channel buffers size entries. When the buffer is full
+// and block is false, expired entries are dropped, which keeps
+// a slow consumer from stalling expiration. Dropped entries
+// are counted in Stats. When block is true, expiration waits
+// for the consumer.
+func WithExpiredChannel[K comparable, V any](size int, block bool) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.expiredC = &expiredChannel[K, V]{c: make(chan ExpiredEntry[K, V], size), block: block}
+	}
+}
+
+// Expired returns the channel on which expired entries are
+// delivered. It returns nil when the map is not created with
+// WithExpiredChannel. The channel is never closed.
+func (m *TTLMap[K, V]) Expired() <-chan ExpiredEntry[K, V] {
+	if m.expiredC == nil {
+		return nil
+	}
+	return m.expiredC.c
+}
+
+// WithOnExpireBatch calls fn once per generation with all
+// entries that expired in it. This is more efficient than a
+// callback per entry for consumers that write expirations to
+// a database or queue.
+//
+// The fn is called by the goroutine that advances the map, and
+// delays expiration while it runs. The batch is reused after
+// fn returns, so fn must copy the entries it retains.
+func WithOnExpireBatch[K comparable, V any](fn func(batch []ExpiredEntry[K, V])) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.onExpireBatch = fn
+	}
+}
+
+// disposing reports whether expired entries are collected for
+// dispose.
+func (m *TTLMap[K, V]) disposing() bool {
+	return m.expiredC != nil || m.onExpireBatch != nil
+}
+
+// dispose delivers a batch of expired entries to the consumers
+// of expirations. The batch is only valid during the call, the
+// sweep reuses its backing array.
+func (m *TTLMap[K, V]) dispose(batch []ExpiredEntry[K, V]) {
+	if len(batch) == 0 {
+		return
+	}
+	if m.onExpireBatch != nil && m.workers != nil {
+		// The batch is reused by the next sweep.
+		batch := append([]ExpiredEntry[K, V](nil), batch...)
+		m.workers.run(func() {
+			m.safely(func() { m.onExpireBatch(batch) })
+		})
+	} else if m.onExpireBatch != nil {
+		m.safely(func() { m.onExpireBatch(batch) })
+	}
+	if m.expiredC != nil {
+		for _, expired := range batch {
+			m.sendExpired(expired)
+		}
+	}
+}
+
+// sendExpired delivers an expired entry on the expired
+// channel, if any.
+func (m *TTLMap[K, V]) sendExpired(expired ExpiredEntry[K, V]) {
+	c := m.expiredC
+	if c == nil {
+		return
+	}
+
+	if c.block {
+		c.c <- expired
+		return
+	}
+
+	select {
+	case c.c <- expired:
+	default:
+		c.dropped.Add(1)
+	}
+}
@@ -0,0 +1,62 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpired(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithExpiredChannel[string, string](1, false))
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+
+	select {
+	case expired := <-ttlmap.Expired():
+		if expired.Key+expired.Value != "key1value1" && expired.Key+expired.Value != "key2value2" {
+			t.Errorf("Expected an expired entry, but got %v", expired)
+		}
+	default:
+		t.Errorf("Expected an expired entry, but got none")
+	}
+
+	if dropped := ttlmap.Stats().DroppedExpirations; dropped != 1 {
+		t.Errorf("Expected 1 dropped expiration, but got %d", dropped)
+	}
+}
+
+func TestDisposalReuse(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithExpiredChannel[string, string](16, false))
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+	capacity := cap(ttlmap.disposal)
+
+	ttlmap.Store("key3", "value3")
+	ttlmap.nextGeneration()
+
+	if len(ttlmap.disposal) != 0 {
+		t.Errorf("Expected disposal to be reset, but has %d entries", len(ttlmap.disposal))
+	} else if cap(ttlmap.disposal) != capacity {
+		t.Errorf("Expected disposal to be reused, but capacity changed from %d to %d", capacity, cap(ttlmap.disposal))
+	} else if len(ttlmap.Expired()) != 3 {
+		t.Errorf("Expected 3 expired entries, but got %d", len(ttlmap.Expired()))
+	}
+}
+
+func TestOnExpireBatch(t *testing.T) {
+	var batches [][]ExpiredEntry[string, string]
+	ttlmap := New(time.Hour, time.Hour, WithOnExpireBatch(func(batch []ExpiredEntry[string, string]) {
+		batches = append(batches, append([]ExpiredEntry[string, string](nil), batch...))
+	}))
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+
+	if len(batches) != 1 {
+		t.Errorf("Expected 1 batch, but got %d", len(batches))
+	} else if len(batches[0]) != 2 {
+		t.Errorf("Expected 2 entries in the batch, but got %d", len(batches[0]))
+	}
+}
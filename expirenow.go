@@ -0,0 +1,55 @@
+package ttlmap
+
+// ExpireNow expires the entry for key immediately, as if its
+// deadline passed. It is reported with EvictionExpired, like
+// an entry that is swept, so external signals like a logout or
+// a revocation take effect before the next tick. It reports
+// whether the key was found in the map, and returns false
+// while the map is frozen.
+func (m *TTLMap[K, V]) ExpireNow(key K) bool {
+	defer m.operation()()
+	key = m.key(key)
+	if m.Frozen() {
+		return false
+	}
+
+	for {
+		e, ok := m.storage().Load(key)
+		if !ok {
+			return false
+		}
+		expires := e.expires.Load()
+		if expires == 0 {
+			// The entry is removed concurrently.
+			return false
+		}
+		if e = m.expire(key, expires, EvictionExpired); e == nil {
+			// The entry was touched or stored again.
+			continue
+		}
+
+		m.unschedule(key)
+		if m.disposing() {
+			m.dispose([]ExpiredEntry[K, V]{{Key: key, Value: m.value(e)}})
+		}
+		m.release(e)
+		return true
+	}
+}
+
+// FlushExpired expires the entries that are due now, without
+// waiting for the ticker. The ticks that passed since the last
+// tick are advanced, like the ticker does when it runs late.
+// It advances the root of a child map, and is a no-op for maps
+// created with NewManual, whose time is only advanced by
+// Advance.
+func (m *TTLMap[K, V]) FlushExpired() {
+	root := m
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root.manual {
+		return
+	}
+	root.AdvanceTo(root.clock.Now())
+}
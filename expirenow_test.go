@@ -0,0 +1,55 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpireNow(t *testing.T) {
+	var reasons []EvictionReason
+	var batch []ExpiredEntry[string, string]
+	ttlmap := NewManual(time.Hour, time.Minute,
+		WithOnEvict(func(key, value string, reason EvictionReason) { reasons = append(reasons, reason) }),
+		WithOnExpireBatch(func(expired []ExpiredEntry[string, string]) { batch = append(batch, expired...) }))
+	defer ttlmap.Close()
+
+	ttlmap.Store("key", "value")
+	if !ttlmap.ExpireNow("key") {
+		t.Errorf("Expected key to be expired, but it was not found")
+	} else if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected expired key to be removed, but it was loaded")
+	} else if len(reasons) != 1 || reasons[0] != EvictionExpired {
+		t.Errorf("Expected the key to be reported as expired, but got %v", reasons)
+	} else if len(batch) != 1 || batch[0].Value != "value" {
+		t.Errorf("Expected the key to be disposed, but got %v", batch)
+	} else if ttlmap.ExpireNow("missing") {
+		t.Errorf("Expected missing key not to be expired, but it was")
+	}
+
+	ttlmap.Store("frozen", "value")
+	ttlmap.Freeze(false)
+	if ttlmap.ExpireNow("frozen") {
+		t.Errorf("Expected frozen map not to expire keys, but it did")
+	}
+}
+
+func TestFlushExpired(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := New(2*time.Second, time.Second, WithClock[string, string](clock))
+	defer ttlmap.Close()
+	child := ttlmap.Child(time.Second)
+
+	ttlmap.Store("key", "value")
+	child.Store("key", "value")
+	clock.now = clock.now.Add(time.Second)
+	if child.FlushExpired(); child.Len() != 0 {
+		t.Errorf("Expected the child to be advanced through its root, but got %d entries", child.Len())
+	} else if ttlmap.Len() != 1 {
+		t.Errorf("Expected the root not to expire early, but got %d entries", ttlmap.Len())
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if ttlmap.FlushExpired(); ttlmap.Len() != 0 {
+		t.Errorf("Expected due entries to expire, but got %d entries", ttlmap.Len())
+	}
+}
@@ -0,0 +1,535 @@
+package ttlmap
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Expirer keeps track of the deadlines of keys, it is the
+// expiry engine of a TTLMap. Deadlines are expressed in ticks
+// of the map, the map advances one tick every interval.
+//
+// An Expirer does not need to be safe for concurrent use, the
+// TTLMap guards it with a mutex. An Expirer must not be
+// shared between maps.
+//
+// Expirers may report keys whose entry was touched, replaced
+// or deleted since it was scheduled. The TTLMap checks the
+// deadline of the entry before expiring it.
+type Expirer[K comparable] interface {
+	// Schedule registers that keys expire at the deadline
+	// tick. Keys that are registered already are moved to
+	// the new deadline.
+	Schedule(deadline uint64, keys ...K)
+
+	// Remove unregisters key.
+	Remove(key K)
+
+	// Advance appends all keys that are due at tick to keys,
+	// and returns the extended slice. It is called for every
+	// tick, in order.
+	Advance(tick uint64, keys []K) []K
+
+	// Reset unregisters all keys.
+	Reset()
+}
+
+// GenerationExpirer is the default Expirer. It stores keys
+// in a ring of generations, every generation contains the
+// keys that expire at the same tick. The ring is a fixed
+// number of slots, allocated once, with a head that moves one
+// slot every tick. Advanced slots keep their arrays for the
+// keys of later ticks, see WithCapacityHint.
+//
+// Keys are never removed from a generation before it is
+// advanced, touched keys are added to another generation
+// instead. This makes scheduling cheap, at the cost of keeping
+// stale keys around until their old generation is advanced.
+//
+// An indexed GenerationExpirer keeps track of the position of
+// every key instead, and moves keys that are scheduled again.
+// This bounds the memory of overwrite heavy workloads, at the
+// cost of an index entry per key.
+//
+// Keys with a deadline beyond the ring are kept in rounds of
+// len(ring) ticks, like a hierarchical timing wheel. A round
+// is cascaded into the ring when the ring reaches it.
+type GenerationExpirer[K comparable] struct {
+	generations [][]K
+	rounds      map[uint64][]roundKey[K]
+
+	// head is the slot of the generation after the last
+	// advanced tick.
+	head uint64
+
+	// index maps keys to their position, it is nil unless
+	// the expirer is indexed.
+	index map[K]generationPos
+
+	// hint is the capacity of new generations, and free the
+	// pool of backing arrays, see WithCapacityHint.
+	hint int
+	free [][]K
+
+	// grown and shrunk count the backing arrays that grew and
+	// were dropped, see EventGrown and EventShrunk.
+	grown, shrunk int
+
+	// tick is the last advanced tick.
+	tick uint64
+}
+
+// generationPos is the position of a key in an indexed
+// GenerationExpirer. The slot is a generation, or a round when
+// round is true.
+type generationPos struct {
+	round bool
+	slot  uint64
+	i     int
+}
+
+// roundKey is a key in a round of a GenerationExpirer.
+type roundKey[K comparable] struct {
+	key      K
+	deadline uint64
+}
+
+// NewGenerationExpirer creates a GenerationExpirer with a ring
+// for deadlines up to n ticks ahead. The n should be the
+// number of ticks in the TTL of the map, keys with a later
+// deadline are cascaded into the ring.
+func NewGenerationExpirer[K comparable](n int) *GenerationExpirer[K] {
+	return &GenerationExpirer[K]{
+		generations: make([][]K, n),
+		rounds:      make(map[uint64][]roundKey[K]),
+	}
+}
+
+// NewIndexedGenerationExpirer creates an indexed
+// GenerationExpirer, see NewGenerationExpirer.
+func NewIndexedGenerationExpirer[K comparable](n int) *GenerationExpirer[K] {
+	g := NewGenerationExpirer[K](n)
+	g.index = make(map[K]generationPos)
+	return g
+}
+
+// Schedule implements Expirer.
+func (g *GenerationExpirer[K]) Schedule(deadline uint64, keys ...K) {
+	n := uint64(len(g.generations))
+	if deadline > g.tick+n {
+		round := deadline / n
+		for _, key := range keys {
+			if g.index != nil {
+				g.unlink(key)
+				g.index[key] = generationPos{round: true, slot: round, i: len(g.rounds[round])}
+			}
+			g.rounds[round] = append(g.rounds[round], roundKey[K]{key: key, deadline: deadline})
+		}
+		return
+	}
+
+	gen := g.slot(deadline)
+	before := g.generation(gen)
+	if g.index != nil {
+		g.generations[gen] = before
+		for _, key := range keys {
+			g.unlink(key)
+			g.index[key] = generationPos{slot: gen, i: len(g.generations[gen])}
+			g.generations[gen] = append(g.generations[gen], key)
+		}
+	} else {
+		g.generations[gen] = append(before, keys...)
+	}
+	if cap(before) > 0 && cap(g.generations[gen]) != cap(before) {
+		g.grown++
+	}
+}
+
+// unlink removes key from its position in an indexed
+// GenerationExpirer. The last key of the slot takes its place.
+func (g *GenerationExpirer[K]) unlink(key K) {
+	pos, ok := g.index[key]
+	if !ok {
+		return
+	}
+	delete(g.index, key)
+
+	if pos.round {
+		round := g.rounds[pos.slot]
+		last := len(round) - 1
+		if pos.i != last {
+			round[pos.i] = round[last]
+			g.index[round[pos.i].key] = pos
+		}
+		round[last] = roundKey[K]{}
+		if last == 0 {
+			delete(g.rounds, pos.slot)
+		} else {
+			g.rounds[pos.slot] = round[:last]
+		}
+		return
+	}
+
+	gen := g.generations[pos.slot]
+	last := len(gen) - 1
+	if pos.i != last {
+		gen[pos.i] = gen[last]
+		g.index[gen[pos.i]] = pos
+	}
+	var zero K
+	gen[last] = zero
+	g.generations[pos.slot] = gen[:last]
+}
+
+// Remove implements Expirer. Keys are only removed from an
+// indexed GenerationExpirer, otherwise they are skipped when
+// their generation is advanced.
+func (g *GenerationExpirer[K]) Remove(key K) {
+	if g.index != nil {
+		g.unlink(key)
+	}
+}
+
+// Advance implements Expirer.
+func (g *GenerationExpirer[K]) Advance(tick uint64, keys []K) []K {
+	n := uint64(len(g.generations))
+	if round, ok := g.rounds[tick/n]; ok && tick%n == 0 {
+		// The ring reached the round, all its deadlines
+		// are within the ring now.
+		delete(g.rounds, tick/n)
+		for _, k := range round {
+			gen := g.slot(k.deadline)
+			g.generations[gen] = g.generation(gen)
+			if g.index != nil {
+				g.index[k.key] = generationPos{slot: gen, i: len(g.generations[gen])}
+			}
+			g.generations[gen] = append(g.generations[gen], k.key)
+		}
+	}
+
+	gen := g.head
+	keys = append(keys, g.generations[gen]...)
+	if g.index != nil {
+		for _, key := range g.generations[gen] {
+			delete(g.index, key)
+		}
+	}
+
+	g.recycle(gen)
+	g.head = g.wrap(gen + 1)
+	g.tick = tick
+	return keys
+}
+
+// slot returns the slot of the generation of deadline, which
+// must be within the ring. Deadlines that passed are due at
+// the next tick.
+func (g *GenerationExpirer[K]) slot(deadline uint64) uint64 {
+	if deadline <= g.tick {
+		return g.head
+	}
+	return g.wrap(g.head + deadline - g.tick - 1)
+}
+
+// wrap wraps a slot past the end of the ring to its start.
+func (g *GenerationExpirer[K]) wrap(slot uint64) uint64 {
+	if n := uint64(len(g.generations)); slot >= n {
+		return slot - n
+	}
+	return slot
+}
+
+// sizes returns the number of keys in every generation,
+// starting with the generation after the last advanced tick.
+func (g *GenerationExpirer[K]) sizes() []int {
+	sizes := make([]int, len(g.generations))
+	for i := range sizes {
+		sizes[i] = len(g.generations[g.wrap(g.head+uint64(i))])
+	}
+	return sizes
+}
+
+// Reset implements Expirer.
+func (g *GenerationExpirer[K]) Reset() {
+	g.generations = make([][]K, len(g.generations))
+	g.rounds = make(map[uint64][]roundKey[K])
+	g.head = 0
+	g.free = nil
+	if g.index != nil {
+		g.index = make(map[K]generationPos)
+	}
+}
+
+// Resolution configures a ring of a MultiResolutionExpirer.
+type Resolution struct {
+	// Ticks is the length of a generation of the ring, in
+	// ticks of the map.
+	Ticks int
+	// Generations is the number of generations in the ring.
+	Generations int
+}
+
+// MultiResolutionExpirer stores keys in multiple rings of
+// generations with different resolutions. Keys are routed to
+// the finest ring whose generations reach their deadline, so a
+// map can hold entries with second and hour scale TTLs without
+// a ring of thousands of generations. Keys beyond the coarsest
+// ring are kept in its rounds, see GenerationExpirer.
+//
+// A ring with a resolution of multiple ticks rounds deadlines
+// up to its resolution, so keys in it expire up to a
+// generation late.
+type MultiResolutionExpirer[K comparable] struct {
+	rings []resolutionRing[K]
+
+	// tick is the last advanced tick.
+	tick uint64
+}
+
+// resolutionRing is a ring of a MultiResolutionExpirer, its
+// generations are advanced every ticks ticks.
+type resolutionRing[K comparable] struct {
+	ticks uint64
+	span  uint64
+	*GenerationExpirer[K]
+}
+
+// NewMultiResolutionExpirer creates a MultiResolutionExpirer
+// with a ring for every resolution. For example, a map with a
+// one second interval could use a ring of 60 one second
+// generations and a ring of 24 one hour generations:
+//
+//	ttlmap.NewMultiResolutionExpirer[string](
+//		ttlmap.Resolution{Ticks: 1, Generations: 60},
+//		ttlmap.Resolution{Ticks: 3600, Generations: 24},
+//	)
+func NewMultiResolutionExpirer[K comparable](resolutions ...Resolution) *MultiResolutionExpirer[K] {
+	sort.Slice(resolutions, func(i, j int) bool {
+		return resolutions[i].Ticks < resolutions[j].Ticks
+	})
+
+	m := &MultiResolutionExpirer[K]{}
+	for _, r := range resolutions {
+		m.rings = append(m.rings, resolutionRing[K]{
+			ticks:             uint64(r.Ticks),
+			span:              uint64(r.Ticks * r.Generations),
+			GenerationExpirer: NewGenerationExpirer[K](r.Generations),
+		})
+	}
+	return m
+}
+
+// Schedule implements Expirer.
+func (m *MultiResolutionExpirer[K]) Schedule(deadline uint64, keys ...K) {
+	ring := m.rings[len(m.rings)-1]
+	for _, r := range m.rings {
+		if deadline <= m.tick+r.span {
+			ring = r
+			break
+		}
+	}
+
+	// Round the deadline up to the resolution of the ring.
+	ring.Schedule((deadline+ring.ticks-1)/ring.ticks, keys...)
+}
+
+// Remove implements Expirer. Like the GenerationExpirer, keys
+// are skipped when their generation is advanced.
+func (m *MultiResolutionExpirer[K]) Remove(key K) {}
+
+// Advance implements Expirer.
+func (m *MultiResolutionExpirer[K]) Advance(tick uint64, keys []K) []K {
+	m.tick = tick
+	for _, r := range m.rings {
+		if tick%r.ticks == 0 {
+			keys = r.Advance(tick/r.ticks, keys)
+		}
+	}
+	return keys
+}
+
+// Reset implements Expirer.
+func (m *MultiResolutionExpirer[K]) Reset() {
+	for _, r := range m.rings {
+		r.Reset()
+	}
+}
+
+// ListExpirer stores every key in a node that is linked into
+// a doubly linked list of the keys that expire at the same
+// tick. Moving and removing keys is O(1) and leaves no stale
+// keys behind, at the cost of a node per key.
+type ListExpirer[K comparable] struct {
+	nodes   map[K]*listNode[K]
+	buckets map[uint64]*listNode[K]
+	// tick is the last advanced tick.
+	tick uint64
+}
+
+// listNode is a key in a bucket of a ListExpirer.
+type listNode[K comparable] struct {
+	key        K
+	deadline   uint64
+	prev, next *listNode[K]
+}
+
+// NewListExpirer creates an empty ListExpirer.
+func NewListExpirer[K comparable]() *ListExpirer[K] {
+	return &ListExpirer[K]{
+		nodes:   make(map[K]*listNode[K]),
+		buckets: make(map[uint64]*listNode[K]),
+	}
+}
+
+// Schedule implements Expirer.
+func (l *ListExpirer[K]) Schedule(deadline uint64, keys ...K) {
+	if deadline <= l.tick {
+		// The deadline passed, expire the keys at the next
+		// tick.
+		deadline = l.tick + 1
+	}
+	for _, key := range keys {
+		node, ok := l.nodes[key]
+		if !ok {
+			node = &listNode[K]{key: key}
+			l.nodes[key] = node
+		} else if node.deadline == deadline {
+			continue
+		} else {
+			l.unlink(node)
+		}
+
+		node.deadline = deadline
+		node.next = l.buckets[deadline]
+		if node.next != nil {
+			node.next.prev = node
+		}
+		l.buckets[deadline] = node
+	}
+}
+
+// Remove implements Expirer.
+func (l *ListExpirer[K]) Remove(key K) {
+	if node, ok := l.nodes[key]; ok {
+		l.unlink(node)
+		delete(l.nodes, key)
+	}
+}
+
+// Advance implements Expirer.
+func (l *ListExpirer[K]) Advance(tick uint64, keys []K) []K {
+	for node := l.buckets[tick]; node != nil; node = node.next {
+		keys = append(keys, node.key)
+		delete(l.nodes, node.key)
+	}
+	delete(l.buckets, tick)
+	l.tick = tick
+	return keys
+}
+
+// Reset implements Expirer.
+func (l *ListExpirer[K]) Reset() {
+	l.nodes = make(map[K]*listNode[K])
+	l.buckets = make(map[uint64]*listNode[K])
+}
+
+// unlink removes node from its bucket.
+func (l *ListExpirer[K]) unlink(node *listNode[K]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else if node.next != nil {
+		l.buckets[node.deadline] = node.next
+	} else {
+		delete(l.buckets, node.deadline)
+	}
+
+	if node.next != nil {
+		node.next.prev = node.prev
+	}
+	node.prev, node.next = nil, nil
+}
+
+// HeapExpirer stores keys in a min-heap ordered by deadline.
+// Every key is tracked individually, so it suits small maps
+// where keys are touched or deleted often. Scheduling and
+// removing keys is O(log n).
+type HeapExpirer[K comparable] struct {
+	items heapItems[K]
+	index map[K]*heapItem[K]
+}
+
+// heapItem is a key in a HeapExpirer.
+type heapItem[K comparable] struct {
+	key      K
+	deadline uint64
+	index    int
+}
+
+// NewHeapExpirer creates an empty HeapExpirer.
+func NewHeapExpirer[K comparable]() *HeapExpirer[K] {
+	return &HeapExpirer[K]{index: make(map[K]*heapItem[K])}
+}
+
+// Schedule implements Expirer.
+func (h *HeapExpirer[K]) Schedule(deadline uint64, keys ...K) {
+	for _, key := range keys {
+		if item, ok := h.index[key]; ok {
+			item.deadline = deadline
+			heap.Fix(&h.items, item.index)
+		} else {
+			item = &heapItem[K]{key: key, deadline: deadline}
+			h.index[key] = item
+			heap.Push(&h.items, item)
+		}
+	}
+}
+
+// Remove implements Expirer.
+func (h *HeapExpirer[K]) Remove(key K) {
+	if item, ok := h.index[key]; ok {
+		heap.Remove(&h.items, item.index)
+		delete(h.index, key)
+	}
+}
+
+// Advance implements Expirer.
+func (h *HeapExpirer[K]) Advance(tick uint64, keys []K) []K {
+	for len(h.items) > 0 && h.items[0].deadline <= tick {
+		item := heap.Pop(&h.items).(*heapItem[K])
+		delete(h.index, item.key)
+		keys = append(keys, item.key)
+	}
+	return keys
+}
+
+// Reset implements Expirer.
+func (h *HeapExpirer[K]) Reset() {
+	h.items = nil
+	h.index = make(map[K]*heapItem[K])
+}
+
+// heapItems implements heap.Interface.
+type heapItems[K comparable] []*heapItem[K]
+
+func (h heapItems[K]) Len() int           { return len(h) }
+func (h heapItems[K]) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+
+func (h heapItems[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *heapItems[K]) Push(x any) {
+	item := x.(*heapItem[K])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *heapItems[K]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,361 @@
+package ttlmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestListExpirer(t *testing.T) {
+	l := NewListExpirer[string]()
+	l.Schedule(1, "key1", "key2", "key3")
+	l.Schedule(2, "key2")
+	l.Remove("key3")
+
+	if keys := l.Advance(1, nil); len(keys) != 1 || keys[0] != "key1" {
+		t.Errorf("Expected keys to be [key1], but were %v", keys)
+	} else if keys = l.Advance(2, nil); len(keys) != 1 || keys[0] != "key2" {
+		t.Errorf("Expected keys to be [key2], but were %v", keys)
+	} else if len(l.nodes) != 0 || len(l.buckets) != 0 {
+		t.Errorf("Expected expirer to be empty, but had %d nodes and %d buckets", len(l.nodes), len(l.buckets))
+	}
+}
+
+func TestHeapExpirer(t *testing.T) {
+	h := NewHeapExpirer[string]()
+	h.Schedule(3, "key1")
+	h.Schedule(1, "key2", "key3")
+	h.Schedule(2, "key3")
+	h.Remove("key1")
+
+	if keys := h.Advance(1, nil); len(keys) != 1 || keys[0] != "key2" {
+		t.Errorf("Expected keys to be [key2], but were %v", keys)
+	} else if keys = h.Advance(3, nil); len(keys) != 1 || keys[0] != "key3" {
+		t.Errorf("Expected keys to be [key3], but were %v", keys)
+	} else if len(h.items) != 0 || len(h.index) != 0 {
+		t.Errorf("Expected expirer to be empty, but had %d items", len(h.items))
+	}
+}
+
+func TestIndexedGenerationExpirer(t *testing.T) {
+	g := NewIndexedGenerationExpirer[string](2)
+	g.Schedule(1, "key1", "key2", "key3")
+	g.Schedule(2, "key1")
+	g.Schedule(5, "key2")
+	g.Schedule(2, "key4")
+	g.Remove("key4")
+
+	if keys := g.Advance(1, nil); len(keys) != 1 || keys[0] != "key3" {
+		t.Errorf("Expected keys to be [key3], but were %v", keys)
+	} else if keys = g.Advance(2, nil); len(keys) != 1 || keys[0] != "key1" {
+		t.Errorf("Expected keys to be [key1], but were %v", keys)
+	} else if keys = append(g.Advance(3, nil), g.Advance(4, nil)...); len(keys) != 0 {
+		t.Errorf("Expected no keys, but got %v", keys)
+	} else if keys = g.Advance(5, nil); len(keys) != 1 || keys[0] != "key2" {
+		t.Errorf("Expected keys to be [key2], but were %v", keys)
+	} else if len(g.index) != 0 {
+		t.Errorf("Expected index to be empty, but had %d keys", len(g.index))
+	}
+}
+
+func TestWithGenerationIndexShortTTL(t *testing.T) {
+	ttlmap := NewManual(100*time.Millisecond, time.Second, WithGenerationIndex[string, string]())
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+
+	if n := len(ttlmap.expirer.(*GenerationExpirer[string]).generations); n != 1 {
+		t.Errorf("Expected 1 generation, but got %d", n)
+	} else if ttlmap.Advance(1); ttlmap.Len() != 0 {
+		t.Errorf("Expected key to expire after a tick, but had %d entries", ttlmap.Len())
+	}
+}
+
+func TestWithGenerationIndexTTLRounding(t *testing.T) {
+	ttlmap := NewManual(90*time.Second, time.Minute, WithGenerationIndex[string, string](), WithTTLRounding[string, string](TTLRoundUp))
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+
+	if n := len(ttlmap.expirer.(*GenerationExpirer[string]).generations); n != 2 {
+		t.Errorf("Expected 2 generations, but got %d", n)
+	} else if ttlmap.Advance(1); ttlmap.Len() != 1 {
+		t.Errorf("Expected key to outlive one tick, but had %d entries", ttlmap.Len())
+	} else if ttlmap.Advance(1); ttlmap.Len() != 0 {
+		t.Errorf("Expected key to expire after two ticks, but had %d entries", ttlmap.Len())
+	}
+}
+
+func TestMultiResolutionExpirer(t *testing.T) {
+	m := NewMultiResolutionExpirer[string](Resolution{Ticks: 1, Generations: 4}, Resolution{Ticks: 4, Generations: 4})
+	m.Schedule(2, "short")
+	m.Schedule(10, "long")
+
+	var keys []string
+	for tick := uint64(1); tick <= 12; tick++ {
+		for _, key := range m.Advance(tick, nil) {
+			keys = append(keys, key+"@"+strconv.Itoa(int(tick)))
+		}
+	}
+
+	if len(keys) != 2 || keys[0] != "short@2" || keys[1] != "long@12" {
+		t.Errorf("Expected keys to be [short@2 long@12], but were %v", keys)
+	} else if len(m.rings[0].generations) != 4 || len(m.rings[1].generations) != 4 {
+		t.Errorf("Expected rings of 4 generations, but got %d and %d", len(m.rings[0].generations), len(m.rings[1].generations))
+	}
+}
+
+func TestListExpiry(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithExpirer[string, string](NewListExpirer[string]()))
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+	ttlmap.LoadAndTouch("key2")
+	ttlmap.Delete("key1")
+	ttlmap.Store("key1", "value1")
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key1"); !ok {
+		t.Errorf("Expected to find key1, but did not")
+	} else if _, ok = ttlmap.Load("key2"); !ok {
+		t.Errorf("Expected to find key2, but did not")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected to not find key1, but did")
+	} else if _, ok = ttlmap.Load("key2"); ok {
+		t.Errorf("Expected to not find key2, but did")
+	}
+}
+
+// benchmarkExpirers runs f against a map for every expirer.
+func benchmarkExpirers(b *testing.B, f func(b *testing.B, m *TTLMap[string, string])) {
+	b.Run("generations", func(b *testing.B) {
+		f(b, New[string, string](time.Hour, time.Minute))
+	})
+	b.Run("indexed", func(b *testing.B) {
+		f(b, New(time.Hour, time.Minute, WithGenerationIndex[string, string]()))
+	})
+	b.Run("list", func(b *testing.B) {
+		f(b, New(time.Hour, time.Minute, WithExpirer[string, string](NewListExpirer[string]())))
+	})
+	b.Run("heap", func(b *testing.B) {
+		f(b, New(time.Hour, time.Minute, WithExpirer[string, string](NewHeapExpirer[string]())))
+	})
+}
+
+// BenchmarkChurnStore stores new keys, every key is expired
+// without being touched.
+func BenchmarkChurnStore(b *testing.B) {
+	benchmarkExpirers(b, func(b *testing.B, m *TTLMap[string, string]) {
+		for i := 0; i < b.N; i++ {
+			m.Store(strconv.Itoa(i), "value")
+			if i%1000 == 0 {
+				m.nextGeneration()
+			}
+		}
+	})
+}
+
+// BenchmarkChurnOverwrite overwrites a small set of keys.
+func BenchmarkChurnOverwrite(b *testing.B) {
+	benchmarkExpirers(b, func(b *testing.B, m *TTLMap[string, string]) {
+		for i := 0; i < b.N; i++ {
+			m.Store(strconv.Itoa(i%1000), "value")
+			if i%1000 == 0 {
+				m.nextGeneration()
+			}
+		}
+	})
+}
+
+// BenchmarkChurnTouch touches a small set of keys.
+func BenchmarkChurnTouch(b *testing.B) {
+	benchmarkExpirers(b, func(b *testing.B, m *TTLMap[string, string]) {
+		for i := 0; i < 1000; i++ {
+			m.Store(strconv.Itoa(i), "value")
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.LoadAndTouch(strconv.Itoa(i % 1000))
+			if i%1000 == 0 {
+				m.nextGeneration()
+			}
+		}
+	})
+}
+
+// BenchmarkChurnDelete stores and deletes keys.
+func BenchmarkChurnDelete(b *testing.B) {
+	benchmarkExpirers(b, func(b *testing.B, m *TTLMap[string, string]) {
+		for i := 0; i < b.N; i++ {
+			key := strconv.Itoa(i)
+			m.Store(key, "value")
+			m.Delete(key)
+			if i%1000 == 0 {
+				m.nextGeneration()
+			}
+		}
+	})
+}
+
+// tickKeys and tickGenerations are the size of the steady
+// state of the tick benchmarks.
+const tickKeys, tickGenerations = 1 << 20, 64
+
+// BenchmarkTick measures the latency and the allocations of
+// advancing a ring of 1M keys. Every tick schedules the keys
+// it expired again, like a map in a steady state.
+func BenchmarkTick(b *testing.B) {
+	for name, newExpirer := range map[string]func() *GenerationExpirer[int]{
+		"generations": func() *GenerationExpirer[int] { return NewGenerationExpirer[int](tickGenerations) },
+		"indexed":     func() *GenerationExpirer[int] { return NewIndexedGenerationExpirer[int](tickGenerations) },
+	} {
+		b.Run(name, func(b *testing.B) {
+			g := newExpirer()
+			for i := range tickKeys {
+				g.Schedule(uint64(i%tickGenerations)+1, i)
+			}
+
+			var keys []int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for tick := uint64(1); tick <= uint64(b.N); tick++ {
+				keys = g.Advance(tick, keys[:0])
+				g.Schedule(tick+tickGenerations, keys...)
+			}
+		})
+	}
+}
+
+// BenchmarkTickMap measures the latency and the allocations of
+// a tick of a map with 1M entries. The expired entries are
+// stored again outside of the timer.
+func BenchmarkTickMap(b *testing.B) {
+	m := NewManual[int, int](tickGenerations*time.Second, time.Second)
+	defer m.Close()
+	for i := range tickKeys {
+		m.Store(i, i)
+		if i%(tickKeys/tickGenerations) == tickKeys/tickGenerations-1 {
+			m.nextGeneration()
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.nextGeneration()
+
+		b.StopTimer()
+		start := i % tickGenerations * (tickKeys / tickGenerations)
+		for key := start; key < start+tickKeys/tickGenerations; key++ {
+			m.Store(key, key)
+		}
+		b.StartTimer()
+	}
+}
+
+// expirers contains a constructor for every expiry engine.
+var expirers = map[string]func() Expirer[int]{
+	"generations": func() Expirer[int] { return NewGenerationExpirer[int](4) },
+	"indexed":     func() Expirer[int] { return NewIndexedGenerationExpirer[int](4) },
+	"list":        func() Expirer[int] { return NewListExpirer[int]() },
+	"heap":        func() Expirer[int] { return NewHeapExpirer[int]() },
+}
+
+// newConformanceMap creates a map with a TTL of 4 ticks using
+// the given expiry engine.
+func newConformanceMap(newExpirer func() Expirer[int]) *TTLMap[int, int] {
+	return New(4*time.Hour, time.Hour, WithExpirer[int, int](newExpirer()))
+}
+
+// TestExpiryConformance verifies that every expiry engine
+// removes entries exactly when their deadline tick is
+// advanced, by comparing the map against a model of the
+// deadlines.
+func TestExpiryConformance(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			ttlmap := newConformanceMap(newExpirer)
+			deadlines := make(map[int]uint64)
+			rand := uint64(1)
+
+			for tick := uint64(0); tick < 64; tick++ {
+				for i := 0; i < 32; i++ {
+					rand = rand*6364136223846793005 + 1442695040888963407
+					key := int(rand>>33) % 16
+
+					switch (rand >> 20) % 4 {
+					case 0, 1:
+						ttlmap.Store(key, key)
+						deadlines[key] = tick + 4
+					case 2:
+						if _, ok := ttlmap.LoadAndTouch(key); ok {
+							deadlines[key] = tick + 4
+						}
+					case 3:
+						ttlmap.Delete(key)
+						delete(deadlines, key)
+					}
+				}
+
+				ttlmap.nextGeneration()
+				for key, deadline := range deadlines {
+					if deadline <= tick+1 {
+						delete(deadlines, key)
+					}
+				}
+				for key := 0; key < 16; key++ {
+					_, expected := deadlines[key]
+					if _, ok := ttlmap.Load(key); ok != expected {
+						t.Fatalf("Expected presence of key %d after tick %d to be %t, but was %t", key, tick+1, expected, ok)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestExpiryPassedDeadline verifies that every expiry engine
+// expires keys scheduled at a deadline that already passed at
+// the next tick.
+func TestExpiryPassedDeadline(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			e := newExpirer()
+			e.Advance(1, nil)
+			e.Advance(2, nil)
+			e.Schedule(1, 1)
+			e.Schedule(2, 2)
+
+			if keys := e.Advance(3, nil); len(keys) != 2 {
+				t.Errorf("Expected keys with passed deadlines to expire, but got %v", keys)
+			}
+		})
+	}
+}
+
+// TestExpiryUpperBound verifies that entries expire at most
+// ttl after they are stored, even when ticks are missed.
+func TestExpiryUpperBound(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			ttlmap := newConformanceMap(newExpirer)
+			start := ttlmap.nextTick.Add(-time.Hour)
+			ttlmap.Store(1, 1)
+
+			ttlmap.AdvanceTo(start.Add(3*time.Hour + 59*time.Minute))
+			if _, ok := ttlmap.Load(1); !ok {
+				t.Errorf("Expected to find key before ttl passed, but did not")
+			}
+
+			ttlmap.Store(2, 2)
+			ttlmap.AdvanceTo(start.Add(8 * time.Hour))
+			if _, ok := ttlmap.Load(1); ok {
+				t.Errorf("Expected to not find key 1 after ttl passed, but did")
+			} else if _, ok = ttlmap.Load(2); ok {
+				t.Errorf("Expected to not find key 2 after ttl passed, but did")
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package ttlmap
+
+import "expvar"
+
+// PublishExpvar publishes the Stats of the map under name with
+// the expvar package, which serves them on /debug/vars. Like
+// expvar.Publish, it panics when name is already published.
+func (m *TTLMap[K, V]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return m.Stats()
+	}))
+}
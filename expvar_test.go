@@ -0,0 +1,23 @@
+package ttlmap
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key", "value")
+	ttlmap.PublishExpvar("ttlmap_test")
+
+	var stats Stats
+	if v := expvar.Get("ttlmap_test"); v == nil {
+		t.Errorf("Expected stats to be published, but were not")
+	} else if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Errorf("Expected stats to be JSON, but got '%v'", err)
+	} else if stats.Entries != 1 {
+		t.Errorf("Expected 1 entry, but got %d", stats.Entries)
+	}
+}
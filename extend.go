@@ -0,0 +1,53 @@
+package ttlmap
+
+import "time"
+
+// WithExtendOnExpire sets a function that can keep an entry
+// that is about to expire. It is called by the ticker with
+// every due entry, when it returns a positive duration the
+// entry is kept for that duration instead, rounded down to a
+// multiple of the interval with a minimum of one interval.
+// This supports entries that are still in use, like a lease
+// that is held, without a separate pinning mechanism.
+//
+// Every entry is extended at most max times, afterwards it
+// expires regardless of extend. Storing the key again resets
+// the count, touching it does not. Like WithOnEvict, extend
+// should not block.
+func WithExtendOnExpire[K comparable, V any](extend func(key K, value V) time.Duration, max int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.extend = extend
+		m.maxExtensions = uint32(max)
+	}
+}
+
+// extendExpiry extends the entry of key when it is due at tick
+// and extend keeps it. It reports whether the entry was
+// extended.
+func (m *TTLMap[K, V]) extendExpiry(key K, tick uint64) bool {
+	e, ok := m.storage().Load(key)
+	if !ok {
+		return false
+	}
+
+	expires := e.expires.Load()
+	if expires == 0 || expires > tick || e.extensions >= m.maxExtensions {
+		return false
+	}
+	d := m.extend(key, m.value(e))
+	if d <= 0 {
+		return false
+	}
+
+	ticks := uint64(d / m.tickInterval())
+	if ticks == 0 {
+		ticks = 1
+	}
+	if !e.expires.CompareAndSwap(expires, tick+ticks) {
+		// The entry was touched or claimed concurrently.
+		return false
+	}
+	e.extensions++
+	m.schedule(tick+ticks, key)
+	return true
+}
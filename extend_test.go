@@ -0,0 +1,40 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithExtendOnExpire(t *testing.T) {
+	calls := 0
+	ttlmap := New(time.Hour, time.Hour, WithExtendOnExpire(func(key, value string) time.Duration {
+		calls++
+		return time.Hour
+	}, 2))
+	ttlmap.Store("key", "value")
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected key to be extended twice, but it expired")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire after 2 extensions, but it is present")
+	} else if calls != 2 {
+		t.Errorf("Expected extend to be called 2 times, but was called %d times", calls)
+	}
+}
+
+func TestWithExtendOnExpireDeclined(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithExtendOnExpire(func(key, value string) time.Duration {
+		return 0
+	}, 2))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire, but it is present")
+	}
+}
@@ -0,0 +1,34 @@
+package ttlmap
+
+// Freeze states of a TTLMap.
+const (
+	notFrozen int32 = iota
+	frozenWrites
+	frozenPaused
+)
+
+// Freeze makes the map read-only. Mutating operations are
+// ignored, or return ErrFrozen when they can report errors.
+// Loads and Range keep working, which allows serving from the
+// map while a replacement is prepared.
+//
+// When pauseExpiration is true, generations are not advanced
+// while the map is frozen. Entries keep their remaining TTL
+// and continue expiring after Unfreeze.
+func (m *TTLMap[K, V]) Freeze(pauseExpiration bool) {
+	if pauseExpiration {
+		m.frozen.Store(frozenPaused)
+	} else {
+		m.frozen.Store(frozenWrites)
+	}
+}
+
+// Unfreeze makes the map writable again.
+func (m *TTLMap[K, V]) Unfreeze() {
+	m.frozen.Store(notFrozen)
+}
+
+// Frozen reports whether the map is frozen.
+func (m *TTLMap[K, V]) Frozen() bool {
+	return m.frozen.Load() != notFrozen
+}
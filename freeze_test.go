@@ -0,0 +1,59 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Freeze(false)
+
+	if !ttlmap.Frozen() {
+		t.Errorf("Expected map to be frozen, but was not")
+	} else if err := ttlmap.TryStore("key2", "value2"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got '%v'", err)
+	} else if err = ttlmap.TryDelete("key1"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got '%v'", err)
+	} else if err = ttlmap.Add("key2", "value2"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got '%v'", err)
+	}
+
+	ttlmap.Store("key2", "value2")
+	ttlmap.Delete("key1")
+	ttlmap.LoadOrStore("key3", "value3")
+	ttlmap.LoadAndDelete("key1")
+	if _, ok := ttlmap.Load("key1"); !ok {
+		t.Errorf("Expected to find key1, but did not")
+	} else if _, ok := ttlmap.Load("key2"); ok {
+		t.Errorf("Expected to not find key2, but did")
+	} else if _, ok := ttlmap.Load("key3"); ok {
+		t.Errorf("Expected to not find key3, but did")
+	}
+
+	ttlmap.Unfreeze()
+	ttlmap.Store("key2", "value2")
+	if _, ok := ttlmap.Load("key2"); !ok {
+		t.Errorf("Expected to find key2, but did not")
+	}
+}
+
+func TestFreezePauseExpiration(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	start := ttlmap.nextTick.Add(-time.Hour)
+	ttlmap.Store("key", "value")
+
+	ttlmap.Freeze(true)
+	ttlmap.AdvanceTo(start.Add(3 * time.Hour))
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key while paused, but did not")
+	}
+
+	ttlmap.Unfreeze()
+	ttlmap.AdvanceTo(start.Add(5 * time.Hour))
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
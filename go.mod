@@ -1,3 +1,3 @@
 module github.com/job79/ttlmap
 
-go 1.19
+go 1.24
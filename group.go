@@ -0,0 +1,33 @@
+package ttlmap
+
+import "time"
+
+// Group returns the map of the namespace name, a child map
+// with the TTL of m, see Child. Features that cache
+// unrelated data can share the ticker of m this way, while
+// their keys, Clear and Stats stay separate. Calling Group
+// again with the same name returns the same map until it is
+// closed, opts only apply when the group is created.
+//
+// Groups of a named map are named after it, like
+// "sessions/users", see WithName.
+func (m *TTLMap[K, V]) Group(name string, opts ...Option[K, V]) *TTLMap[K, V] {
+	m.groupMu.Lock()
+	defer m.groupMu.Unlock()
+
+	m.advanceMu.Lock()
+	for _, child := range m.children {
+		if child.group == name {
+			m.advanceMu.Unlock()
+			return child
+		}
+	}
+	m.advanceMu.Unlock()
+
+	if m.name != "" {
+		opts = append([]Option[K, V]{WithName[K, V](m.name + "/" + name)}, opts...)
+	}
+	group := m.Child(time.Duration(m.ttlTicks.Load())*m.tickInterval(), opts...)
+	group.group = name
+	return group
+}
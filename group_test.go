@@ -0,0 +1,41 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour, WithName[string, string]("cache"))
+	defer ttlmap.Close()
+	users := ttlmap.Group("users")
+	orders := ttlmap.Group("orders")
+	users.Store("key", "user")
+	orders.Store("key", "order")
+	users.Load("key")
+
+	if ttlmap.Group("users") != users {
+		t.Errorf("Expected Group to return the same map, but it created a new one")
+	} else if users.Name() != "cache/users" {
+		t.Errorf("Expected group to be named 'cache/users', but was '%s'", users.Name())
+	} else if value, _ := orders.Load("key"); value != "order" {
+		t.Errorf("Expected value to be 'order', but was '%s'", value)
+	} else if users.Stats().Hits != 1 || orders.Stats().Hits != 1 {
+		t.Errorf("Expected 1 hit per group, but got %d and %d", users.Stats().Hits, orders.Stats().Hits)
+	}
+
+	users.Clear()
+	if _, ok := orders.Load("key"); !ok {
+		t.Errorf("Expected Clear to keep other groups, but it did not")
+	}
+
+	ttlmap.AdvanceTo(time.Now().Add(2 * time.Hour))
+	if _, ok := orders.Load("key"); ok {
+		t.Errorf("Expected group to expire with the ticks of the map, but it did not")
+	}
+
+	users.Close()
+	if ttlmap.Group("users") == users {
+		t.Errorf("Expected a closed group to be recreated, but it was not")
+	}
+}
@@ -0,0 +1,106 @@
+package ttlmap
+
+import "sync"
+
+// Handle is a value pinned by Acquire. It must be released
+// with Release when it is no longer used.
+type Handle[V any] struct {
+	value   V
+	release func()
+	once    sync.Once
+}
+
+// Value returns the pinned value. It must not be used after
+// Release.
+func (h *Handle[V]) Value() V {
+	return h.value
+}
+
+// Release unpins the value. Calling it more than once has no
+// effect.
+func (h *Handle[V]) Release() {
+	h.once.Do(func() {
+		if h.release != nil {
+			h.release()
+		}
+	})
+}
+
+// pin counts the handles of a blob, see Acquire.
+type pin struct {
+	handles int
+	// dropped is set when the entry of the blob left the map
+	// while it was pinned, its last handle releases it.
+	dropped bool
+}
+
+// Acquire returns a handle to the value stored in the map for
+// a key, like Load. With WithBlobStore, the blob of the value
+// is not released until the handle is released, even when the
+// entry expires, is deleted or is replaced meanwhile. This
+// allows a value that references the memory of the blob store,
+// like a slice of an arena, to be written to a connection
+// without copying it and without racing its expiry.
+//
+// Without a blob store, values are kept alive by the garbage
+// collector and the handle only holds the value. Acquire does
+// not fall through to a backend.
+func (m *TTLMap[K, V]) Acquire(key K) (*Handle[V], bool) {
+	m.checkOpen("Acquire")
+	key = m.key(key)
+	m.record(TraceLoad, key)
+
+	// The entry is loaded under pinMu, so it is pinned before
+	// release can see it.
+	m.pinMu.Lock()
+	e, ok := m.storage().Load(key)
+	if !ok || m.hidden(e) {
+		m.pinMu.Unlock()
+		m.miss()
+		return nil, false
+	}
+	if e.blob != "" {
+		p := m.pins[e.blob]
+		if p == nil {
+			if m.pins == nil {
+				m.pins = make(map[string]*pin)
+			}
+			p = &pin{}
+			m.pins[e.blob] = p
+		}
+		p.handles++
+	}
+	m.pinMu.Unlock()
+
+	h := &Handle[V]{}
+	if e.blob != "" {
+		blob := e.blob
+		h.release = func() { m.unpin(blob) }
+	}
+	if m.policies[OpLoad] != PolicyPreserve && !m.refresh(OpLoad, key, e) {
+		h.Release()
+		m.miss()
+		return nil, false
+	}
+	m.hit(e)
+	h.value = m.value(e)
+	return h, true
+}
+
+// unpin releases a handle of blob, and the blob when it was
+// the last handle of a dropped entry.
+func (m *TTLMap[K, V]) unpin(blob string) {
+	m.pinMu.Lock()
+	p := m.pins[blob]
+	p.handles--
+	if p.handles > 0 {
+		m.pinMu.Unlock()
+		return
+	}
+	delete(m.pins, blob)
+	m.pinMu.Unlock()
+
+	if p.dropped {
+		m.blobs.Release(blob)
+	}
+}
@@ -0,0 +1,56 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	blobs := &memoryBlobs{blobs: make(map[string]string)}
+	ttlmap := NewManual(time.Hour, time.Hour, WithBlobStore[string, string](blobs, 4, func(value string) int64 {
+		return int64(len(value))
+	}))
+	defer ttlmap.Close()
+	ttlmap.Store("large", "large value")
+
+	h, ok := ttlmap.Acquire("large")
+	if !ok || h.Value() != "large value" {
+		t.Fatalf("Expected handle to 'large value', but got %v", ok)
+	}
+	other, _ := ttlmap.Acquire("large")
+
+	ttlmap.Delete("large")
+	if len(blobs.blobs) != 1 {
+		t.Errorf("Expected pinned blob not to be released, but got %d blobs", len(blobs.blobs))
+	}
+	h.Release()
+	h.Release()
+	if len(blobs.blobs) != 1 {
+		t.Errorf("Expected blob to stay pinned by the other handle, but got %d blobs", len(blobs.blobs))
+	}
+	other.Release()
+	if len(blobs.blobs) != 0 {
+		t.Errorf("Expected last handle to release the blob, but got %d blobs", len(blobs.blobs))
+	}
+
+	ttlmap.Store("large", "large value")
+	h, _ = ttlmap.Acquire("large")
+	h.Release()
+	if len(blobs.blobs) != 1 {
+		t.Errorf("Expected released handle not to release a stored blob, but got %d blobs", len(blobs.blobs))
+	}
+	ttlmap.Advance(1)
+	if len(blobs.blobs) != 0 {
+		t.Errorf("Expected unpinned blob to be released on expiry, but got %d blobs", len(blobs.blobs))
+	}
+
+	if _, ok := ttlmap.Acquire("missing"); ok {
+		t.Errorf("Expected missing key not to be acquired, but it was")
+	}
+	ttlmap.Store("small", "tiny")
+	if h, ok := ttlmap.Acquire("small"); !ok || h.Value() != "tiny" {
+		t.Errorf("Expected handle to a value outside the blob store, but got %v", ok)
+	} else {
+		h.Release()
+	}
+}
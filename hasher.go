@@ -0,0 +1,125 @@
+package ttlmap
+
+import (
+	"slices"
+	"time"
+)
+
+// HashedMap is a map for keys that are not comparable, like
+// byte slices or structs with slice fields, see
+// NewWithHasher.
+type HashedMap[K any, V any] struct {
+	m     *TTLMap[uint64, []HashedEntry[K, V]]
+	hash  func(key K) uint64
+	equal func(a, b K) bool
+}
+
+// HashedEntry is an entry in a bucket of a HashedMap.
+type HashedEntry[K any, V any] struct {
+	Key   K
+	Value V
+}
+
+// NewWithHasher creates a HashedMap, which stores its entries
+// in buckets by the hash of their key. The keys in a bucket
+// are told apart with equal. This caches by fingerprints like
+// request bodies without encoding them as strings.
+//
+// The buckets are the entries of a TTLMap, configured by opts.
+// Keys whose hashes collide share a bucket, and with it their
+// deadline, so a good hash keeps the TTL of keys exact. The
+// hash and equal functions must be consistent, equal keys must
+// have equal hashes.
+func NewWithHasher[K any, V any](ttl, interval time.Duration, hash func(key K) uint64, equal func(a, b K) bool, opts ...Option[uint64, []HashedEntry[K, V]]) *HashedMap[K, V] {
+	return &HashedMap[K, V]{
+		m:     New(ttl, interval, opts...),
+		hash:  hash,
+		equal: equal,
+	}
+}
+
+// Map returns the map of buckets, for its statistics and
+// options.
+func (h *HashedMap[K, V]) Map() *TTLMap[uint64, []HashedEntry[K, V]] {
+	return h.m
+}
+
+// index returns the index of key in bucket, or -1.
+func (h *HashedMap[K, V]) index(bucket []HashedEntry[K, V], key K) int {
+	return slices.IndexFunc(bucket, func(e HashedEntry[K, V]) bool {
+		return h.equal(e.Key, key)
+	})
+}
+
+// Load returns the value stored for key. The ok result
+// indicates whether the key was found.
+func (h *HashedMap[K, V]) Load(key K) (value V, ok bool) {
+	bucket, _ := h.m.Load(h.hash(key))
+	if i := h.index(bucket, key); i >= 0 {
+		return bucket[i].Value, true
+	}
+	return value, false
+}
+
+// Store sets the value for key, and resets the TTL of its
+// bucket.
+func (h *HashedMap[K, V]) Store(key K, value V) {
+	hash := h.hash(key)
+	h.m.update(hash, func(bucket []HashedEntry[K, V], _ bool) []HashedEntry[K, V] {
+		// Buckets are shared with loads, they are copied
+		// instead of modified in place.
+		bucket = slices.Clone(bucket)
+		if i := h.index(bucket, key); i >= 0 {
+			bucket[i].Value = value
+			return bucket
+		}
+		return append(bucket, HashedEntry[K, V]{key, value})
+	})
+	h.m.Touch(hash)
+}
+
+// LoadOrStore returns the existing value for key if present.
+// Otherwise, it stores and returns value. The loaded result
+// is true if the value was loaded, false if stored.
+func (h *HashedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	h.m.update(h.hash(key), func(bucket []HashedEntry[K, V], _ bool) []HashedEntry[K, V] {
+		if i := h.index(bucket, key); i >= 0 {
+			actual, loaded = bucket[i].Value, true
+			return bucket
+		}
+		actual, loaded = value, false
+		return append(slices.Clip(bucket), HashedEntry[K, V]{key, value})
+	})
+	return actual, loaded
+}
+
+// Delete deletes the value for key.
+func (h *HashedMap[K, V]) Delete(key K) {
+	h.m.Compute(h.hash(key), func(bucket []HashedEntry[K, V], _ bool) ([]HashedEntry[K, V], bool) {
+		i := h.index(bucket, key)
+		if i < 0 {
+			return bucket, len(bucket) == 0
+		}
+		bucket = slices.Delete(slices.Clone(bucket), i, i+1)
+		return bucket, len(bucket) == 0
+	})
+}
+
+// Range calls f sequentially for each key and value in the
+// map. If f returns false, Range stops the iteration.
+func (h *HashedMap[K, V]) Range(f func(key K, value V) bool) {
+	h.m.Range(func(_ uint64, bucket []HashedEntry[K, V]) bool {
+		for _, e := range bucket {
+			if !f(e.Key, e.Value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Close stops the ticker of the map and clears it, see
+// TTLMap.Close.
+func (h *HashedMap[K, V]) Close() {
+	h.m.Close()
+}
@@ -0,0 +1,58 @@
+package ttlmap
+
+import (
+	"bytes"
+	"hash/maphash"
+	"testing"
+	"time"
+)
+
+func TestNewWithHasher(t *testing.T) {
+	seed := maphash.MakeSeed()
+	ttlmap := NewWithHasher[[]byte, string](time.Hour, time.Hour, func(key []byte) uint64 {
+		return maphash.Bytes(seed, key)
+	}, bytes.Equal)
+	defer ttlmap.Close()
+	ttlmap.Store([]byte("key"), "value")
+
+	if value, ok := ttlmap.Load([]byte("key")); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if actual, loaded := ttlmap.LoadOrStore([]byte("key"), "other"); !loaded || actual != "value" {
+		t.Errorf("Expected LoadOrStore to load 'value', but got '%s'", actual)
+	}
+
+	ttlmap.Delete([]byte("key"))
+	if _, ok := ttlmap.Load([]byte("key")); ok {
+		t.Errorf("Expected key to be deleted, but was not")
+	} else if n := ttlmap.Map().Len(); n != 0 {
+		t.Errorf("Expected empty bucket to be deleted, but map has %d buckets", n)
+	}
+}
+
+func TestNewWithHasherCollisions(t *testing.T) {
+	ttlmap := NewWithHasher[[]byte, string](time.Hour, time.Hour, func(key []byte) uint64 {
+		return uint64(len(key))
+	}, bytes.Equal)
+	defer ttlmap.Close()
+	ttlmap.Store([]byte("key1"), "value1")
+	ttlmap.Store([]byte("key2"), "value2")
+	ttlmap.Store([]byte("key1"), "value3")
+	ttlmap.LoadOrStore([]byte("key3"), "value4")
+	ttlmap.Delete([]byte("key2"))
+
+	values := make(map[string]string)
+	ttlmap.Range(func(key []byte, value string) bool {
+		values[string(key)] = value
+		return true
+	})
+	if len(values) != 2 || values["key1"] != "value3" || values["key3"] != "value4" {
+		t.Errorf("Expected key1=value3 and key3=value4, but got %v", values)
+	} else if n := ttlmap.Map().Len(); n != 1 {
+		t.Errorf("Expected keys to share 1 bucket, but got %d", n)
+	}
+
+	ttlmap.Map().AdvanceTo(time.Now().Add(time.Hour))
+	if _, ok := ttlmap.Load([]byte("key1")); ok {
+		t.Errorf("Expected key1 to expire, but it did not")
+	}
+}
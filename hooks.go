@@ -0,0 +1,80 @@
+package ttlmap
+
+import "time"
+
+// Hooks are functions that the map calls to instrument its
+// work, for example with tracing or metrics, see WithHooks.
+// Every function is optional. Panics of hooks are recovered,
+// see Err.
+type Hooks[K comparable] struct {
+	// Load is called before LoadOrCompute calls a loader for
+	// key, and the returned function is called with the error
+	// of the loader after it returned.
+	Load func(key K) (done func(err error))
+	// Removed is called for every entry that leaves the map,
+	// with the reason it left.
+	Removed func(reason EvictionReason)
+	// Advanced is called after the map advanced a tick, with
+	// the number of entries that expired and the time the tick
+	// took.
+	Advanced func(expired int, duration time.Duration)
+	// Closed is called when the map is closed.
+	Closed func()
+}
+
+// WithHooks adds hooks to the map. Unlike other options,
+// WithHooks can be used multiple times, the hooks of every
+// option are called. This allows combining integrations, like
+// the tracing and metrics of the ttlmapotel package.
+func WithHooks[K comparable, V any](hooks Hooks[K]) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.hooks = append(m.hooks, hooks)
+	}
+}
+
+// loadHooks calls the Load hooks for key, and returns a
+// function that finishes them.
+func (m *TTLMap[K, V]) loadHooks(key K) func(err error) {
+	var done []func(err error)
+	for _, h := range m.hooks {
+		if h.Load != nil {
+			m.safely(func() {
+				if f := h.Load(key); f != nil {
+					done = append(done, f)
+				}
+			})
+		}
+	}
+	return func(err error) {
+		for _, f := range done {
+			m.safely(func() { f(err) })
+		}
+	}
+}
+
+// removedHooks calls the Removed hooks.
+func (m *TTLMap[K, V]) removedHooks(reason EvictionReason) {
+	for _, h := range m.hooks {
+		if h.Removed != nil {
+			m.safely(func() { h.Removed(reason) })
+		}
+	}
+}
+
+// advancedHooks calls the Advanced hooks.
+func (m *TTLMap[K, V]) advancedHooks(expired int, duration time.Duration) {
+	for _, h := range m.hooks {
+		if h.Advanced != nil {
+			m.safely(func() { h.Advanced(expired, duration) })
+		}
+	}
+}
+
+// closedHooks calls the Closed hooks.
+func (m *TTLMap[K, V]) closedHooks() {
+	for _, h := range m.hooks {
+		if h.Closed != nil {
+			m.safely(func() { h.Closed() })
+		}
+	}
+}
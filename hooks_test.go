@@ -0,0 +1,46 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithHooks(t *testing.T) {
+	var loads, failed, advanced, closed int
+	removed := make(map[EvictionReason]int)
+	hooks := Hooks[string]{
+		Load: func(key string) func(err error) {
+			loads++
+			return func(err error) {
+				if err != nil {
+					failed++
+				}
+			}
+		},
+		Removed:  func(reason EvictionReason) { removed[reason]++ },
+		Advanced: func(int, time.Duration) { advanced++ },
+		Closed:   func() { closed++ },
+	}
+	ttlmap := New(time.Hour, time.Hour, WithHooks[string, string](hooks), WithHooks[string, string](Hooks[string]{
+		Closed: func() { closed++ },
+	}))
+
+	_, _ = ttlmap.LoadOrCompute("key1", func(string) (string, error) { return "value", nil })
+	_, _ = ttlmap.LoadOrCompute("key2", func(string) (string, error) { return "", errors.New("failed") })
+	_, _ = ttlmap.LoadOrCompute("key1", func(string) (string, error) { return "value", nil })
+	ttlmap.Store("key3", "value")
+	ttlmap.Delete("key3")
+	ttlmap.nextGeneration()
+	ttlmap.Close()
+
+	if loads != 2 || failed != 1 {
+		t.Errorf("Expected 2 loads and 1 failure, but got %d and %d", loads, failed)
+	} else if removed[EvictionExpired] != 1 || removed[EvictionDeleted] != 1 {
+		t.Errorf("Expected an expired and a deleted entry, but got %v", removed)
+	} else if advanced != 1 {
+		t.Errorf("Expected 1 advanced tick, but got %d", advanced)
+	} else if closed != 2 {
+		t.Errorf("Expected the closed hooks of both options to be called, but got %d", closed)
+	}
+}
@@ -0,0 +1,146 @@
+package ttlmap
+
+import "time"
+
+// deadlineIndex maps deadlines to the keys that expire at
+// them. It is guarded by the mu of the map.
+type deadlineIndex[K comparable] struct {
+	deadlines map[K]uint64
+	buckets   map[uint64]map[K]struct{}
+}
+
+// WithDeadlineIndex maintains an index from deadlines to keys,
+// which allows enumerating the keys that expire in a window
+// with ExpiringBetween. The index costs memory for every key,
+// and a lock for every removed entry.
+func WithDeadlineIndex[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.index = &deadlineIndex[K]{}
+		m.index.reset()
+	}
+}
+
+// ExpiringBetween returns the keys that expire at or after
+// from and before to, with the precision of the interval. It
+// returns nil when the map is not created with
+// WithDeadlineIndex.
+//
+// Keys can be touched or deleted concurrently, so the result
+// might be outdated when it is returned.
+func (m *TTLMap[K, V]) ExpiringBetween(from, to time.Time) []K {
+	if m.index == nil {
+		return nil
+	}
+
+	tick, nextTick := m.ticks()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []K
+	for deadline, bucket := range m.index.buckets {
+		if deadline <= tick {
+			continue
+		}
+
+		at := m.timeOf(deadline, tick, nextTick)
+		if !at.Before(from) && at.Before(to) {
+			for key := range bucket {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// StoreUntil sets the value for a key, which expires at
+// expiresAt. It is StoreWithTTL with the time until
+// expiresAt as TTL.
+func (m *TTLMap[K, V]) StoreUntil(key K, value V, expiresAt time.Time) {
+	m.StoreWithTTL(key, value, expiresAt.Sub(m.clock.Now()))
+}
+
+// add indexes that keys expire at deadline.
+func (x *deadlineIndex[K]) add(deadline uint64, keys ...K) {
+	for _, key := range keys {
+		x.remove(key)
+		x.deadlines[key] = deadline
+		bucket, ok := x.buckets[deadline]
+		if !ok {
+			bucket = make(map[K]struct{})
+			x.buckets[deadline] = bucket
+		}
+		bucket[key] = struct{}{}
+	}
+}
+
+// remove removes key from the index.
+func (x *deadlineIndex[K]) remove(key K) {
+	deadline, ok := x.deadlines[key]
+	if !ok {
+		return
+	}
+
+	delete(x.deadlines, key)
+	delete(x.buckets[deadline], key)
+	if len(x.buckets[deadline]) == 0 {
+		delete(x.buckets, deadline)
+	}
+}
+
+// reset removes all keys from the index.
+func (x *deadlineIndex[K]) reset() {
+	x.deadlines = make(map[K]uint64)
+	x.buckets = make(map[uint64]map[K]struct{})
+}
+
+// ExpiresAt returns the time at which the entry for key
+// expires, with the precision of the interval. The ok result
+// reports whether the key was found in the map. Entries that
+// are due but not expired yet expire at the next tick.
+func (m *TTLMap[K, V]) ExpiresAt(key K) (time.Time, bool) {
+	key = m.key(key)
+	e, ok := m.storage().Load(key)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	expires := e.expires.Load()
+	if expires == 0 {
+		return time.Time{}, false
+	}
+	tick, nextTick := m.ticks()
+	return m.timeOf(expires, tick, nextTick), true
+}
+
+// RangeWithExpiry is like Range, but also passes the time at
+// which every entry expires, see ExpiresAt.
+func (m *TTLMap[K, V]) RangeWithExpiry(f func(key K, value V, expiresAt time.Time) bool) {
+	tick, nextTick := m.ticks()
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		expires := e.expires.Load()
+		if expires == 0 || m.hidden(e) {
+			return true
+		}
+		return f(key, m.value(e), m.timeOf(expires, tick, nextTick))
+	})
+}
+
+// ticks returns the current tick and the time at which the
+// next tick is due. Child maps use the ticks of their root.
+// The time is derived from the epoch, see expiryTime, so it
+// doesn't take advanceMu and can be called by the callbacks of
+// a sweep.
+func (m *TTLMap[K, V]) ticks() (tick uint64, nextTick time.Time) {
+	tick = m.tick.Load()
+	return tick, time.Unix(0, m.expiryTime(tick+1))
+}
+
+// timeOf returns the time at which the generation of deadline
+// is advanced. The generation of the next tick is advanced at
+// nextTick, later generations every interval after it.
+func (m *TTLMap[K, V]) timeOf(deadline, tick uint64, nextTick time.Time) time.Time {
+	if deadline <= tick {
+		return nextTick
+	}
+	return nextTick.Add(time.Duration(deadline-tick-1) * m.tickInterval())
+}
@@ -0,0 +1,103 @@
+package ttlmap
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestExpiringBetween(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithDeadlineIndex[string, string]())
+	start := ttlmap.nextTick
+	ttlmap.Store("key1", "value1")
+	ttlmap.AdvanceTo(start)
+	ttlmap.Store("key2", "value2")
+
+	keys := ttlmap.ExpiringBetween(start.Add(3*time.Hour), start.Add(5*time.Hour))
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Errorf("Expected [key1 key2], but got %v", keys)
+	} else if keys = ttlmap.ExpiringBetween(start.Add(3*time.Hour), start.Add(4*time.Hour)); len(keys) != 1 || keys[0] != "key1" {
+		t.Errorf("Expected [key1], but got %v", keys)
+	}
+
+	ttlmap.Delete("key1")
+	ttlmap.AdvanceTo(start.Add(4 * time.Hour))
+	if keys = ttlmap.ExpiringBetween(start, start.Add(10*time.Hour)); len(keys) != 0 {
+		t.Errorf("Expected no keys, but got %v", keys)
+	} else if len(ttlmap.index.deadlines) != 0 {
+		t.Errorf("Expected index to be empty, but has %d keys", len(ttlmap.index.deadlines))
+	}
+}
+
+func TestStoreUntil(t *testing.T) {
+	ttlmap := New[string, string](4*time.Hour, time.Hour)
+	ttlmap.StoreUntil("key", "value", time.Now().Add(2*time.Hour+time.Minute))
+	ttlmap.nextGeneration()
+
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key, but did not")
+	}
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire, but did not")
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+	expected := ttlmap.nextTick.Add(time.Hour)
+
+	if at, ok := ttlmap.ExpiresAt("key"); !ok || !at.Equal(expected) {
+		t.Errorf("Expected key to expire at %s, but got %s", expected, at)
+	} else if _, ok = ttlmap.ExpiresAt("missing"); ok {
+		t.Errorf("Expected missing key to have no expiry, but had")
+	}
+
+	found := 0
+	ttlmap.RangeWithExpiry(func(key string, value string, expiresAt time.Time) bool {
+		found++
+		if !expiresAt.Equal(expected) {
+			t.Errorf("Expected %s to expire at %s, but got %s", key, expected, expiresAt)
+		}
+		return true
+	})
+	if found != 1 {
+		t.Errorf("Expected 1 entry, but got %d", found)
+	}
+}
+
+func TestExpiresAtFromOnEvict(t *testing.T) {
+	var ttlmap *TTLMap[string, string]
+	var found, visited, between int
+	ttlmap = NewManual(2*time.Hour, time.Hour, WithDeadlineIndex[string, string](), WithOnEvict(func(string, string, EvictionReason) {
+		if _, ok := ttlmap.ExpiresAt("present"); ok {
+			found++
+		}
+		ttlmap.RangeWithExpiry(func(string, string, time.Time) bool {
+			visited++
+			return true
+		})
+		between += len(ttlmap.ExpiringBetween(time.Time{}, time.Now().Add(24*time.Hour)))
+	}))
+	ttlmap.Store("expired", "value")
+	ttlmap.Advance(1)
+	ttlmap.Store("present", "value")
+
+	done := make(chan struct{})
+	go func() {
+		ttlmap.Advance(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the callback to return, but it deadlocked")
+	}
+	ttlmap.Close()
+
+	if found != 1 || visited != 1 || between != 1 {
+		t.Errorf("Expected the callback to see the present key, but got %d, %d and %d", found, visited, between)
+	}
+}
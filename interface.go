@@ -0,0 +1,46 @@
+package ttlmap
+
+import "time"
+
+// Interface is the method set of TTLMap that is used to access
+// and manage its entries. Applications can depend on Interface
+// instead of *TTLMap, to mock the map in unit tests or to swap
+// in another implementation.
+//
+// Methods that report on the configuration of a TTLMap, like
+// Trace and LabelStats, are not part of Interface.
+type Interface[K comparable, V any] interface {
+	Load(key K) (V, bool)
+	LoadWithMinTTL(key K, min time.Duration) (V, bool)
+	LoadAndTouch(key K) (V, bool)
+	Touch(key K) bool
+	Extend(key K, d time.Duration) bool
+	Promote(key K, ttl time.Duration) bool
+	TouchMany(keys []K) int
+	GetEntry(key K) (Entry[K, V], bool)
+	Store(key K, value V)
+	StoreWithMeta(key K, value V, meta any)
+	StoreWithTTL(key K, value V, ttl time.Duration)
+	TryStore(key K, value V) error
+	Add(key K, value V) error
+	LoadOrStore(key K, value V) (actual V, loaded bool)
+	LoadOrCompute(key K, loader func(key K) (V, error)) (V, error)
+	Swap(key K, value V) (previous V, loaded bool)
+	CompareAndSwap(key K, old, new V) (swapped bool)
+	CompareAndDelete(key K, old V) (deleted bool)
+	Compute(key K, fn func(old V, exists bool) (new V, delete bool)) (value V, ok bool)
+	Delete(key K)
+	TryDelete(key K) error
+	DeleteFunc(pred func(key K, value V) bool) int
+	LoadAndDelete(key K) (V, bool)
+	Clear()
+	ReplaceAll(entries map[K]V)
+	Range(f func(key K, value V) bool)
+	Freeze(pauseExpiration bool)
+	Unfreeze()
+	Frozen() bool
+	AdvanceTo(now time.Time)
+	Close()
+}
+
+var _ Interface[string, string] = (*TTLMap[string, string])(nil)
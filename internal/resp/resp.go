@@ -0,0 +1,228 @@
+// Package resp implements the subset of the Redis
+// serialization protocol used by ttlmap.
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Kinds of values.
+const (
+	SimpleString = '+'
+	Error        = '-'
+	Integer      = ':'
+	BulkString   = '$'
+	Array        = '*'
+)
+
+// Limits are the limits of the input that Read accepts. Input
+// beyond them is a ProtocolError.
+type Limits struct {
+	// BulkLength is the maximum length of a bulk string.
+	BulkLength int
+	// ArrayLength is the maximum number of values in an
+	// array.
+	ArrayLength int
+	// Depth is the maximum nesting of arrays, a value that is
+	// not in an array has depth 1.
+	Depth int
+	// LineLength is the maximum length of a line, like the
+	// header of a bulk string or a simple string.
+	LineLength int
+}
+
+// DefaultLimits are the limits of Read. They fit the commands
+// of a cache, input of a larger size is rejected before it is
+// read.
+var DefaultLimits = Limits{
+	BulkLength:  1 << 20,
+	ArrayLength: 1 << 10,
+	Depth:       4,
+	LineLength:  64 << 10,
+}
+
+// RedisLimits are the limits of Redis itself, for clients that
+// read the replies of a Redis server they trust.
+var RedisLimits = Limits{
+	BulkLength:  512 << 20,
+	ArrayLength: 1<<31 - 1,
+	Depth:       8,
+	LineLength:  64 << 10,
+}
+
+// ProtocolError is returned by Read for input that exceeds
+// its limits. The rest of the input can't
+// be parsed, so a server replies with the error and closes the
+// connection.
+type ProtocolError struct {
+	Reason string
+}
+
+// Error implements error.
+func (e *ProtocolError) Error() string {
+	return "resp: protocol error: " + e.Reason
+}
+
+// Value is a RESP value. Null bulk strings and arrays have
+// Null set.
+type Value struct {
+	Kind  byte
+	Str   string
+	Int   int64
+	Array []Value
+	Null  bool
+}
+
+// Err returns the error of an error value, or nil.
+func (v Value) Err() error {
+	if v.Kind != Error {
+		return nil
+	}
+	return errors.New(v.Str)
+}
+
+// Bulk returns a bulk string value.
+func Bulk(s string) Value {
+	return Value{Kind: BulkString, Str: s}
+}
+
+// Command returns an array of bulk strings, which is how
+// clients send commands.
+func Command(args ...string) Value {
+	v := Value{Kind: Array, Array: make([]Value, len(args))}
+	for i, arg := range args {
+		v.Array[i] = Bulk(arg)
+	}
+	return v
+}
+
+// Write writes v to w.
+func Write(w *bufio.Writer, v Value) error {
+	w.WriteByte(v.Kind)
+	switch v.Kind {
+	case SimpleString, Error:
+		w.WriteString(v.Str)
+	case Integer:
+		w.WriteString(strconv.FormatInt(v.Int, 10))
+	case BulkString:
+		if v.Null {
+			w.WriteString("-1")
+			break
+		}
+		w.WriteString(strconv.Itoa(len(v.Str)))
+		w.WriteString("\r\n")
+		w.WriteString(v.Str)
+	case Array:
+		if v.Null {
+			w.WriteString("-1")
+			break
+		}
+		w.WriteString(strconv.Itoa(len(v.Array)))
+		w.WriteString("\r\n")
+		for _, item := range v.Array {
+			if err := Write(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("resp: unknown kind %q", v.Kind)
+	}
+	_, err := w.WriteString("\r\n")
+	return err
+}
+
+// Read reads a value from r, within DefaultLimits.
+func Read(r *bufio.Reader) (Value, error) {
+	return DefaultLimits.Read(r)
+}
+
+// Read reads a value from r, within the limits l. Memory is
+// allocated as the input arrives, not by the lengths that it
+// announces.
+func (l Limits) Read(r *bufio.Reader) (Value, error) {
+	return l.read(r, 1)
+}
+
+// read reads a value at depth from r.
+func (l Limits) read(r *bufio.Reader, depth int) (Value, error) {
+	line, err := l.readLine(r)
+	if err != nil {
+		return Value{}, err
+	} else if len(line) == 0 {
+		return Value{}, errors.New("resp: empty line")
+	}
+
+	v := Value{Kind: line[0]}
+	switch v.Kind {
+	case SimpleString, Error:
+		v.Str = line[1:]
+	case Integer:
+		v.Int, err = strconv.ParseInt(line[1:], 10, 64)
+	case BulkString:
+		var n int
+		if n, err = strconv.Atoi(line[1:]); err != nil || n < 0 {
+			v.Null = n < 0
+			break
+		} else if n > l.BulkLength {
+			err = &ProtocolError{Reason: "invalid bulk length"}
+			break
+		}
+		var b strings.Builder
+		if _, err = io.CopyN(&b, r, int64(n)); err == nil {
+			v.Str = b.String()
+			_, err = r.Discard(2)
+		}
+	case Array:
+		var n int
+		if n, err = strconv.Atoi(line[1:]); err != nil || n < 0 {
+			v.Null = n < 0
+			break
+		} else if n > l.ArrayLength {
+			err = &ProtocolError{Reason: "invalid multibulk length"}
+			break
+		} else if n > 0 && depth >= l.Depth {
+			err = &ProtocolError{Reason: "too deep nesting"}
+			break
+		}
+		v.Array = make([]Value, 0, min(n, 16))
+		for range n {
+			var item Value
+			if item, err = l.read(r, depth+1); err != nil {
+				break
+			}
+			v.Array = append(v.Array, item)
+		}
+	default:
+		err = fmt.Errorf("resp: unknown kind %q", v.Kind)
+	}
+	return v, err
+}
+
+// readLine reads a line terminated by CRLF, without the
+// terminator.
+func (l Limits) readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > l.LineLength+2 {
+			return "", &ProtocolError{Reason: "too big inline request"}
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		break
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("resp: line not terminated by CRLF")
+	}
+	return string(line[:len(line)-2]), nil
+}
@@ -0,0 +1,130 @@
+package ttlmap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/gob"
+	"sync"
+)
+
+// Invalidator connects the maps of replicas, see
+// WithInvalidator.
+type Invalidator[K comparable] interface {
+	// Publish tells the peers that the value of key changed.
+	Publish(key K)
+	// Subscribe calls invalidate with the keys published by
+	// peers, until stop is called. Keys published by the
+	// Invalidator itself are not delivered.
+	Subscribe(invalidate func(key K)) (stop func())
+}
+
+// WithInvalidator keeps the map coherent with the maps of
+// replicas. Stores and deletes publish the key with inv, and
+// keys published by peers are deleted locally, so the next
+// load misses and fetches the new value. Entries dropped for
+// a peer are reported with EvictionDeleted, they are not
+// published again.
+//
+// Stores, LoadOrStore and deletes are published, like
+// WithShadow. Deletes are published even when the key is not
+// in the map, since peers may hold it. Delivery is as reliable
+// as the transport of inv, so a TTL still bounds the staleness
+// of lost invalidations.
+//
+// Experimental: the Invalidator interface and the format of
+// the messages of BusInvalidator may change in a minor
+// version.
+func WithInvalidator[K comparable, V any](inv Invalidator[K]) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.invalidator = inv
+	}
+}
+
+// invalidate deletes key after a peer changed it, without
+// publishing it again.
+func (m *TTLMap[K, V]) invalidate(key K) {
+	defer m.operation()()
+	if m.Frozen() || m.closed.Load() {
+		return
+	}
+
+	e, ok := m.storage().LoadAndDelete(key)
+	if !ok {
+		return
+	}
+	m.record(TraceDelete, key)
+	m.unschedule(key)
+	m.removed(key, e, EvictionDeleted)
+}
+
+// Bus is a publish/subscribe transport, like a Redis channel
+// or a NATS subject, see NewBusInvalidator.
+type Bus interface {
+	// Publish sends msg to all subscribers, including the
+	// publisher.
+	Publish(msg []byte) error
+	// Subscribe calls handler with every message, until stop
+	// is called.
+	Subscribe(handler func(msg []byte)) (stop func(), err error)
+}
+
+// BusInvalidator is an Invalidator over a Bus. Keys are
+// encoded with encoding/gob, every message carries the id of
+// its publisher, so a replica skips its own messages.
+type BusInvalidator[K comparable] struct {
+	bus Bus
+	id  [16]byte
+
+	// OnError is called with the errors of the bus and of
+	// decoding messages, which are otherwise discarded.
+	OnError func(err error)
+}
+
+// NewBusInvalidator creates a BusInvalidator on bus. Every
+// replica must create its own.
+func NewBusInvalidator[K comparable](bus Bus) *BusInvalidator[K] {
+	i := &BusInvalidator[K]{bus: bus}
+	_, _ = rand.Read(i.id[:])
+	return i
+}
+
+// Publish implements Invalidator.
+func (i *BusInvalidator[K]) Publish(key K) {
+	var buf bytes.Buffer
+	buf.Write(i.id[:])
+	if err := gob.NewEncoder(&buf).Encode(&key); err != nil {
+		i.error(err)
+		return
+	}
+	if err := i.bus.Publish(buf.Bytes()); err != nil {
+		i.error(err)
+	}
+}
+
+// Subscribe implements Invalidator.
+func (i *BusInvalidator[K]) Subscribe(invalidate func(key K)) func() {
+	stop, err := i.bus.Subscribe(func(msg []byte) {
+		if len(msg) < len(i.id) || bytes.Equal(msg[:len(i.id)], i.id[:]) {
+			return
+		}
+
+		var key K
+		if err := gob.NewDecoder(bytes.NewReader(msg[len(i.id):])).Decode(&key); err != nil {
+			i.error(err)
+			return
+		}
+		invalidate(key)
+	})
+	if err != nil {
+		i.error(err)
+		return func() {}
+	}
+	return sync.OnceFunc(stop)
+}
+
+// error reports err to OnError.
+func (i *BusInvalidator[K]) error(err error) {
+	if i.OnError != nil {
+		i.OnError(err)
+	}
+}
@@ -0,0 +1,90 @@
+package ttlmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBus is a Bus in memory, which delivers messages
+// synchronously.
+type memoryBus struct {
+	mu       sync.Mutex
+	handlers map[int]func(msg []byte)
+	next     int
+}
+
+func (b *memoryBus) Publish(msg []byte) error {
+	b.mu.Lock()
+	handlers := make([]func(msg []byte), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(handler func(msg []byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers == nil {
+		b.handlers = make(map[int]func(msg []byte))
+	}
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+func TestInvalidator(t *testing.T) {
+	bus := &memoryBus{}
+	a := NewManual(time.Hour, time.Minute, WithInvalidator[string, string](NewBusInvalidator[string](bus)))
+	defer a.Close()
+	b := NewManual(time.Hour, time.Minute, WithInvalidator[string, string](NewBusInvalidator[string](bus)))
+
+	a.Store("key", "a")
+	b.Store("key", "b")
+	if _, ok := a.Load("key"); ok {
+		t.Errorf("Expected store of a peer to drop the local copy, but it was loaded")
+	} else if value, ok := b.Load("key"); !ok || value != "b" {
+		t.Errorf("Expected store not to invalidate its own map, but got '%s'", value)
+	}
+
+	a.Store("other", "a")
+	a.Delete("key")
+	if _, ok := b.Load("key"); ok {
+		t.Errorf("Expected delete of a peer to drop the local copy, but it was loaded")
+	} else if _, ok := a.Load("other"); !ok {
+		t.Errorf("Expected invalidation not to be published again, but other was dropped")
+	}
+
+	b.Close()
+	b.Store("key", "b")
+	a.Store("key", "a")
+	if value, ok := a.Load("key"); !ok || value != "a" {
+		t.Errorf("Expected closed map to be unsubscribed, but got '%s'", value)
+	}
+}
+
+func TestBusInvalidatorError(t *testing.T) {
+	var errs []error
+	inv := NewBusInvalidator[string](&memoryBus{})
+	inv.OnError = func(err error) { errs = append(errs, err) }
+
+	stop := inv.Subscribe(func(key string) {
+		t.Errorf("Expected corrupt message to be skipped, but got '%s'", key)
+	})
+	defer stop()
+	inv.bus.Publish(append(make([]byte, 16), 0xff))
+	if len(errs) != 1 {
+		t.Errorf("Expected corrupt message to be reported, but got %v", errs)
+	}
+}
@@ -0,0 +1,37 @@
+package ttlmap
+
+import "iter"
+
+// All returns an iterator over the keys and values in the map,
+// for use in range loops:
+//
+//	for key, value := range m.All() {
+//		fmt.Println(key, value)
+//	}
+//
+// It has the consistency of Range.
+func (m *TTLMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Range(yield)
+	}
+}
+
+// KeysSeq returns an iterator over the keys in the map. It is
+// the iterator variant of Keys.
+func (m *TTLMap[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.Range(func(key K, _ V) bool {
+			return yield(key)
+		})
+	}
+}
+
+// ValuesSeq returns an iterator over the values in the map. It
+// is the iterator variant of Values.
+func (m *TTLMap[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.Range(func(_ K, value V) bool {
+			return yield(value)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIterators(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+
+	all := make(map[string]string)
+	for key, value := range ttlmap.All() {
+		all[key] = value
+	}
+	keys := 0
+	for range ttlmap.KeysSeq() {
+		keys++
+	}
+	values := 0
+	for range ttlmap.ValuesSeq() {
+		values++
+		break
+	}
+
+	if len(all) != 2 || all["key1"] != "value1" {
+		t.Errorf("Expected All to yield both entries, but got %v", all)
+	} else if keys != 2 {
+		t.Errorf("Expected 2 keys, but got %d", keys)
+	} else if values != 1 {
+		t.Errorf("Expected iteration to stop after break, but got %d values", values)
+	}
+}
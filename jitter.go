@@ -0,0 +1,35 @@
+package ttlmap
+
+import "math/rand/v2"
+
+// WithJitter spreads the deadlines of entries that are stored
+// or touched together over neighboring generations, so a burst
+// of entries doesn't expire, and get fetched again from the
+// origin, in the same tick. Every deadline is moved up to
+// fraction of its TTL earlier, at random. Entries never live
+// longer than their TTL, the average lifetime shrinks by half
+// of the fraction.
+//
+// The fraction must be in [0, 1). Deadlines are whole ticks,
+// so jitter needs a TTL of several intervals to have effect.
+func WithJitter[K comparable, V any](fraction float64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.jitterFraction = fraction
+	}
+}
+
+// jitter returns a random number of ticks to move a deadline
+// that is ttl ticks away earlier, see WithJitter.
+func (m *TTLMap[K, V]) jitter(ttl uint64) uint64 {
+	if m.jitterFraction == 0 || ttl <= 1 {
+		return 0
+	}
+
+	// Keep at least one tick, so entries don't expire in the
+	// tick they are stored in.
+	n := min(uint64(m.jitterFraction*float64(ttl)), ttl-1)
+	if n == 0 {
+		return 0
+	}
+	return rand.Uint64N(n + 1)
+}
@@ -0,0 +1,59 @@
+package ttlmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	ttlmap := NewManual(100*time.Minute, time.Minute, WithJitter[string, string](0.5))
+	deadlines := map[uint64]bool{}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		ttlmap.Store(key, "value")
+		e, _ := ttlmap.storage().Load(key)
+		deadlines[e.expires.Load()] = true
+	}
+
+	for deadline := range deadlines {
+		if deadline < 50 || deadline > 100 {
+			t.Errorf("Expected deadline to be between 50 and 100, but was %d", deadline)
+		}
+	}
+	if len(deadlines) < 10 {
+		t.Errorf("Expected deadlines to be spread, but got %d distinct deadlines", len(deadlines))
+	}
+
+	ttlmap.Advance(49)
+	if n := ttlmap.Len(); n != 1000 {
+		t.Errorf("Expected no entry to expire before half of the TTL, but %d are left", n)
+	}
+	ttlmap.Advance(51)
+	if n := ttlmap.Len(); n != 0 {
+		t.Errorf("Expected all entries to expire within the TTL, but %d are left", n)
+	}
+}
+
+func TestJitterReplaceAll(t *testing.T) {
+	ttlmap := NewManual(10*time.Minute, time.Minute, WithJitter[string, string](0.9), WithStrict[string, string]())
+	entries := map[string]string{}
+	for i := 0; i < 100; i++ {
+		entries[strconv.Itoa(i)] = "value"
+	}
+	ttlmap.ReplaceAll(entries)
+
+	ttlmap.Advance(10)
+	if n := ttlmap.Len(); n != 0 {
+		t.Errorf("Expected all replaced entries to expire, but %d are left", n)
+	}
+}
+
+func TestJitterShortTTL(t *testing.T) {
+	ttlmap := NewManual(time.Minute, time.Minute, WithJitter[string, string](0.9))
+	ttlmap.Store("key", "value")
+
+	if e, _ := ttlmap.storage().Load("key"); e.expires.Load() != 1 {
+		t.Errorf("Expected a TTL of one tick to keep its deadline, but was %d", e.expires.Load())
+	}
+}
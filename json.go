@@ -0,0 +1,68 @@
+package ttlmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jsonEntry is an entry in the JSON encoding of a map. TTL is
+// the remaining TTL in the format of time.Duration.String.
+type jsonEntry[K comparable, V any] struct {
+	Key       K         `json:"key"`
+	Value     V         `json:"value"`
+	TTL       string    `json:"ttl"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MarshalJSON encodes the entries of the map as a JSON array
+// of objects with the key, value, remaining TTL and expiry
+// time of every entry, for debug endpoints and dumps of the
+// cache state:
+//
+//	[{"key":"a","value":1,"ttl":"59s","expires_at":"2024-01-01T00:00:59Z"}]
+//
+// See Keys for its consistency, and ExpiresAt for the
+// precision of the times.
+func (m *TTLMap[K, V]) MarshalJSON() ([]byte, error) {
+	now := m.clock.Now()
+	entries := make([]jsonEntry[K, V], 0, m.Len())
+	m.RangeWithExpiry(func(key K, value V, expiresAt time.Time) bool {
+		entries = append(entries, jsonEntry[K, V]{
+			Key:       key,
+			Value:     value,
+			TTL:       max(expiresAt.Sub(now), 0).String(),
+			ExpiresAt: expiresAt,
+		})
+		return true
+	})
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON stores the entries encoded by MarshalJSON in
+// the map, which must be created with New or NewManual. The
+// entries expire at their expiry time, like StoreUntil, entries
+// without one expire after their TTL. Entries that expired are
+// skipped.
+func (m *TTLMap[K, V]) UnmarshalJSON(data []byte) error {
+	var entries []jsonEntry[K, V]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	now := m.clock.Now()
+	for _, e := range entries {
+		expiresAt := e.ExpiresAt
+		if expiresAt.IsZero() {
+			ttl, err := time.ParseDuration(e.TTL)
+			if err != nil {
+				return fmt.Errorf("ttlmap: invalid ttl of entry: %w", err)
+			}
+			expiresAt = now.Add(ttl)
+		}
+		if expiresAt.After(now) {
+			m.restoreStore(e.Key, e.Value, expiresAt)
+		}
+	}
+	return nil
+}
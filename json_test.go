@@ -0,0 +1,49 @@
+package ttlmap
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	clock := &stoppedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ttlmap := NewManual(time.Minute, time.Second, WithClock[string, int](clock))
+	defer ttlmap.Close()
+	ttlmap.Store("key", 1)
+
+	data, err := json.Marshal(ttlmap)
+	if err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	expected := `[{"key":"key","value":1,"ttl":"1m0s","expires_at":"2024-01-01T00:01:00Z"}]`
+	if string(data) != expected {
+		t.Errorf("Expected %s, but got %s", expected, data)
+	}
+
+	clock.now = clock.now.Add(30 * time.Second)
+	restored := NewManual(time.Minute, time.Second, WithClock[string, int](clock))
+	defer restored.Close()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	} else if value, ok := restored.Load("key"); !ok || value != 1 {
+		t.Errorf("Expected value to be 1, but was %d", value)
+	} else if at, _ := restored.ExpiresAt("key"); !at.After(clock.now) || at.After(clock.now.Add(30*time.Second)) {
+		t.Errorf("Expected key to expire within 30 seconds, but expires at %v", at)
+	}
+}
+
+func TestUnmarshalJSONTTL(t *testing.T) {
+	ttlmap := NewManual[string, string](time.Hour, time.Second)
+	defer ttlmap.Close()
+
+	if err := json.Unmarshal([]byte(`[{"key":"a","value":"b","ttl":"10s"},{"key":"c","value":"d","ttl":"0s"}]`), ttlmap); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	} else if value, ok := ttlmap.Load("a"); !ok || value != "b" {
+		t.Errorf("Expected value to be 'b', but was '%s'", value)
+	} else if _, ok := ttlmap.Load("c"); ok {
+		t.Errorf("Expected expired entry to be skipped, but it was stored")
+	} else if err := json.Unmarshal([]byte(`[{"key":"a","value":"b","ttl":"soon"}]`), ttlmap); err == nil {
+		t.Errorf("Expected an error for an invalid ttl, but got none")
+	}
+}
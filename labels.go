@@ -0,0 +1,93 @@
+package ttlmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LabelStats contains the statistics of the entries stored
+// with a label, see StoreLabeled.
+type LabelStats struct {
+	// Entries is the number of entries in the map.
+	Entries int64
+	// Stores is the number of stored entries.
+	Stores uint64
+	// Hits is the number of times an entry was loaded.
+	Hits uint64
+	// Removed is the number of entries that expired, were
+	// deleted or were replaced.
+	Removed uint64
+	// AverageLifetime is the average time removed entries
+	// spent in the map, with the precision of the interval.
+	AverageLifetime time.Duration
+}
+
+// HitRate returns the average number of hits per stored
+// entry.
+func (s LabelStats) HitRate() float64 {
+	if s.Stores == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Stores)
+}
+
+// labelStats collects the statistics of a label.
+type labelStats struct {
+	entries       atomic.Int64
+	stores        atomic.Uint64
+	hits          atomic.Uint64
+	removals      atomic.Uint64
+	lifetimeTicks atomic.Uint64
+}
+
+// stored is called when an entry with the label is stored.
+func (s *labelStats) stored() {
+	s.entries.Add(1)
+	s.stores.Add(1)
+}
+
+// removed is called when an entry with the label that lived
+// for the given number of ticks is removed.
+func (s *labelStats) removed(ticks uint64) {
+	s.entries.Add(-1)
+	s.removals.Add(1)
+	s.lifetimeTicks.Add(ticks)
+}
+
+// StoreLabeled sets the value for a key and tags the entry
+// with label. Statistics are aggregated per label, which
+// shows which callers benefit from the map. It is a no-op
+// while the map is frozen.
+func (m *TTLMap[K, V]) StoreLabeled(key K, value V, label string) {
+	if m.Frozen() {
+		return
+	}
+
+	stats, ok := m.labels.Load(label)
+	if !ok {
+		stats, _ = m.labels.LoadOrStore(label, &labelStats{})
+	}
+	_, _ = m.store(key, &entry[V]{value: value, label: stats.(*labelStats)})
+}
+
+// LabelStats returns the statistics of every label used with
+// StoreLabeled.
+func (m *TTLMap[K, V]) LabelStats() map[string]LabelStats {
+	labels := make(map[string]LabelStats)
+	m.labels.Range(func(label, val any) bool {
+		s := val.(*labelStats)
+		stats := LabelStats{
+			Entries: s.entries.Load(),
+			Stores:  s.stores.Load(),
+			Hits:    s.hits.Load(),
+			Removed: s.removals.Load(),
+		}
+		if stats.Removed > 0 {
+			stats.AverageLifetime = time.Duration(s.lifetimeTicks.Load()) * m.tickInterval() / time.Duration(stats.Removed)
+		}
+
+		labels[label.(string)] = stats
+		return true
+	})
+	return labels
+}
@@ -0,0 +1,44 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreLabeled(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.StoreLabeled("key1", "value1", "search")
+	ttlmap.StoreLabeled("key2", "value2", "search")
+	ttlmap.Store("key3", "value3")
+
+	ttlmap.Load("key1")
+	ttlmap.Load("key1")
+	ttlmap.Load("key3")
+	ttlmap.nextGeneration()
+	ttlmap.Delete("key2")
+	ttlmap.nextGeneration()
+
+	stats := ttlmap.LabelStats()
+	if len(stats) != 1 {
+		t.Errorf("Expected 1 label, but got %d", len(stats))
+	} else if s := stats["search"]; s.Entries != 0 || s.Stores != 2 || s.Hits != 2 || s.Removed != 2 {
+		t.Errorf("Expected 0 entries, 2 stores, 2 hits and 2 removed, but got %+v", s)
+	} else if s.AverageLifetime != 90*time.Minute {
+		t.Errorf("Expected average lifetime to be 1h30m, but was %s", s.AverageLifetime)
+	} else if s.HitRate() != 1 {
+		t.Errorf("Expected hit rate to be 1, but was %f", s.HitRate())
+	}
+}
+
+func TestStoreLabeledReplace(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.StoreLabeled("key", "value", "a")
+	ttlmap.StoreLabeled("key", "value", "b")
+
+	stats := ttlmap.LabelStats()
+	if s := stats["a"]; s.Entries != 0 || s.Removed != 1 {
+		t.Errorf("Expected 0 entries and 1 removed for a, but got %+v", s)
+	} else if s = stats["b"]; s.Entries != 1 || s.Removed != 0 {
+		t.Errorf("Expected 1 entry and 0 removed for b, but got %+v", s)
+	}
+}
@@ -0,0 +1,149 @@
+package ttlmap
+
+import (
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets is the number of buckets of a histogram. The
+// first bucket counts durations up to 64ns, every next bucket
+// doubles the bound, the last bucket counts the durations
+// beyond 2^30ns, about a second.
+const (
+	latencyBuckets = 26
+	latencyShift   = 6
+)
+
+// LatencyHistogram is a histogram of the durations of an
+// operation, see WithLatencyHistograms.
+type LatencyHistogram struct {
+	// Bounds are the inclusive upper bounds of the buckets,
+	// the last bucket has no upper bound.
+	Bounds []time.Duration
+	// Counts are the number of observed durations in every
+	// bucket. They are not cumulative.
+	Counts []uint64
+	// Count is the number of observed durations, and Sum their
+	// total.
+	Count uint64
+	Sum   time.Duration
+}
+
+// Quantile returns an estimate of the q quantile of the
+// observed durations, the upper bound of the bucket that
+// contains it. The quantile in the last bucket is reported as
+// the bound of the bucket before it.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	var seen uint64
+	for i, n := range h.Counts {
+		seen += n
+		if seen >= rank && i < len(h.Bounds) {
+			return h.Bounds[i]
+		}
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// LatencyStats contains the latency histograms of a map, see
+// WithLatencyHistograms.
+type LatencyStats struct {
+	// Load is the latency of Load, and Store the latency of
+	// every operation that stores an entry.
+	Load  LatencyHistogram
+	Store LatencyHistogram
+	// Sweep is the latency of expiring the entries of a tick.
+	Sweep LatencyHistogram
+}
+
+// WithLatencyHistograms records histograms of the latency of
+// loads, stores and sweeps, which are reported by Stats. Only
+// a random fraction rate of the loads and stores is timed,
+// which keeps the overhead of busy maps low, every sweep is
+// timed. The counts of loads and stores are of the sampled
+// operations, the quantiles remain statistically valid. The
+// rate must be in (0, 1].
+//
+// The histograms have exponential buckets from 64ns to about
+// a second, so regressions show up in dashboards as a shift
+// of the distribution.
+func WithLatencyHistograms[K comparable, V any](rate float64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.latency = &latency{threshold: uint32(rate * math.MaxUint32)}
+	}
+}
+
+// latency records the latency histograms of a map.
+type latency struct {
+	// threshold is the sample rate scaled to uint32.
+	threshold uint32
+
+	load, store, sweep histogram
+}
+
+// histogram is a histogram of durations, that is updated
+// atomically.
+type histogram struct {
+	counts [latencyBuckets]atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+}
+
+// start returns the start time of a sampled operation, or
+// the zero time when the operation is not sampled.
+func (l *latency) start() time.Time {
+	if l == nil || rand.Uint32() > l.threshold {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observe records the duration since start, it ignores
+// operations that were not sampled.
+func (h *histogram) observe(start time.Time) {
+	if start.IsZero() {
+		return
+	}
+
+	d := time.Since(start)
+	i := 0
+	if d > 0 {
+		i = max(bits.Len64(uint64(d-1))-latencyShift, 0)
+	}
+	h.counts[min(i, latencyBuckets-1)].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+// snapshot returns the histogram as a LatencyHistogram.
+func (h *histogram) snapshot() LatencyHistogram {
+	s := LatencyHistogram{
+		Bounds: make([]time.Duration, latencyBuckets-1),
+		Counts: make([]uint64, latencyBuckets),
+		Count:  h.count.Load(),
+		Sum:    time.Duration(h.sum.Load()),
+	}
+	for i := range s.Bounds {
+		s.Bounds[i] = time.Duration(1) << (i + latencyShift)
+	}
+	for i := range s.Counts {
+		s.Counts[i] = h.counts[i].Load()
+	}
+	return s
+}
+
+// stats returns the histograms as LatencyStats.
+func (l *latency) stats() *LatencyStats {
+	return &LatencyStats{
+		Load:  l.load.snapshot(),
+		Store: l.store.snapshot(),
+		Sweep: l.sweep.snapshot(),
+	}
+}
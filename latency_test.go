@@ -0,0 +1,58 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistograms(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithLatencyHistograms[string, string](1))
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+	ttlmap.Load("key")
+	ttlmap.Load("missing")
+	ttlmap.nextGeneration()
+
+	stats := ttlmap.Stats()
+	if stats.Latency == nil {
+		t.Fatalf("Expected latency stats, but got nil")
+	} else if stats.Latency.Load.Count != 2 {
+		t.Errorf("Expected 2 timed loads, but got %d", stats.Latency.Load.Count)
+	} else if stats.Latency.Store.Count != 1 {
+		t.Errorf("Expected 1 timed store, but got %d", stats.Latency.Store.Count)
+	} else if stats.Latency.Sweep.Count != 1 {
+		t.Errorf("Expected 1 timed sweep, but got %d", stats.Latency.Sweep.Count)
+	} else if stats.Latency.Load.Sum <= 0 {
+		t.Errorf("Expected a positive sum, but got %v", stats.Latency.Load.Sum)
+	}
+
+	if stats := New[string, string](time.Hour, time.Minute).Stats(); stats.Latency != nil {
+		t.Errorf("Expected no latency stats by default, but got %+v", stats.Latency)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	start := time.Now()
+	h.observe(time.Time{})
+	h.observe(start.Add(time.Hour))
+	h.observe(start.Add(-time.Hour))
+
+	s := h.snapshot()
+	if s.Count != 2 {
+		t.Errorf("Expected 2 observations, but got %d", s.Count)
+	} else if s.Counts[0] != 1 {
+		t.Errorf("Expected a negative duration in the first bucket, but got %v", s.Counts)
+	} else if s.Counts[latencyBuckets-1] != 1 {
+		t.Errorf("Expected an hour in the last bucket, but got %v", s.Counts)
+	} else if s.Bounds[0] != 64*time.Nanosecond || s.Bounds[1] != 128*time.Nanosecond {
+		t.Errorf("Expected bounds to start at 64ns and double, but got %v", s.Bounds[:2])
+	}
+
+	s = LatencyHistogram{Bounds: []time.Duration{1, 2, 4}, Counts: []uint64{1, 2, 1, 0}, Count: 4}
+	if q := s.Quantile(0.5); q != 2 {
+		t.Errorf("Expected median to be 2, but was %v", q)
+	} else if q := s.Quantile(1); q != 4 {
+		t.Errorf("Expected maximum to be 4, but was %v", q)
+	}
+}
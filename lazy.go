@@ -0,0 +1,61 @@
+package ttlmap
+
+import "time"
+
+// WithLazyExpiry makes Load and Range check the deadline of
+// every entry they return, and treat entries whose deadline
+// passed as missing, even if they were not swept yet.
+//
+// Entries are swept up to an interval early, but never late,
+// as long as the map is advanced in time. When sweeps are
+// delayed, by a busy ticker, a Scheduler, an adaptive interval
+// or a manual map that is not advanced, entries outlive their
+// deadline until the next sweep. With lazy expiry they are
+// hidden at their deadline instead, and removed by the sweep.
+//
+// The check needs no timestamp per entry, the deadline of an
+// entry and the time of the ticks pin the time at which it
+// expires. Stale entries of WithStaleWhileRevalidate are still
+// returned, entries of a paused map don't expire.
+func WithLazyExpiry[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.lazyExpiry = true
+	}
+}
+
+// setNextTick sets the time of the next tick, and the epoch
+// that follows from it. The caller must hold advanceMu, or own
+// the map.
+func (m *TTLMap[K, V]) setNextTick(t time.Time) {
+	m.nextTick = t
+	m.epoch.Store(t.UnixNano() - int64(m.tick.Load()+1)*int64(m.tickInterval()))
+}
+
+// lapsed reports whether the deadline of e passed, while the
+// entry was not swept yet. It is always false without
+// WithLazyExpiry.
+func (m *TTLMap[K, V]) lapsed(e *entry[V]) bool {
+	if !m.lazyExpiry || e.stale.Load() {
+		return false
+	}
+	expires := e.expires.Load()
+	if expires == 0 {
+		return false
+	}
+	return m.clock.Now().UnixNano() >= m.expiryTime(expires)
+}
+
+// expiryTime returns the time in unix nanoseconds at which the
+// generation of deadline is advanced, like timeOf, but without
+// taking advanceMu.
+func (m *TTLMap[K, V]) expiryTime(deadline uint64) int64 {
+	// Child maps count their own ticks, but use the ticks of
+	// their root.
+	root := m
+	for root.parent != nil {
+		root = root.parent
+	}
+	tick := m.tick.Load()
+	ticks := max(deadline, tick+1) + root.tick.Load() - tick
+	return root.epoch.Load() + int64(ticks)*int64(m.tickInterval())
+}
@@ -0,0 +1,63 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLazyExpiry(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := NewManual(2*time.Second, time.Second, WithClock[string, string](clock), WithLazyExpiry[string, string]())
+	defer ttlmap.Close()
+
+	ttlmap.Store("key", "value")
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected key to be loaded before its deadline, but it was not")
+	}
+
+	// The map is not advanced, the entry is not swept.
+	clock.now = clock.now.Add(time.Second)
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to be hidden after its deadline, but it was loaded")
+	} else if n := len(ttlmap.Keys()); n != 0 {
+		t.Errorf("Expected Range to skip the key, but got %d keys", n)
+	} else if ttlmap.Len() != 1 {
+		t.Errorf("Expected key to stay in the map until it is swept, but had %d entries", ttlmap.Len())
+	}
+
+	ttlmap.AdvanceTo(clock.now)
+	if ttlmap.Len() != 0 {
+		t.Errorf("Expected key to be swept, but had %d entries", ttlmap.Len())
+	}
+}
+
+func TestLazyExpiryDisabled(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := NewManual(2*time.Second, time.Second, WithClock[string, string](clock))
+	defer ttlmap.Close()
+
+	ttlmap.Store("key", "value")
+	clock.now = clock.now.Add(time.Hour)
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected key to be loaded until it is swept, but it was not")
+	}
+}
+
+func TestLazyExpiryChild(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	parent := NewManual(2*time.Second, time.Second, WithClock[string, string](clock))
+	defer parent.Close()
+	parent.Tick()
+	child := parent.Child(2*time.Second, WithLazyExpiry[string, string]())
+
+	child.Store("key", "value")
+	clock.now = clock.now.Add(2500 * time.Millisecond)
+	if _, ok := child.Load("key"); !ok {
+		t.Errorf("Expected key to be loaded before its deadline, but it was not")
+	}
+	clock.now = clock.now.Add(time.Second)
+	if _, ok := child.Load("key"); ok {
+		t.Errorf("Expected key to be hidden after its deadline, but it was loaded")
+	}
+}
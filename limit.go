@@ -0,0 +1,248 @@
+package ttlmap
+
+import "sort"
+
+// WithSoftLimit sets an approximate limit on the number of
+// entries in the map. Exceeding the limit does not block
+// writers, instead the map is trimmed in the background like
+// WithMaxEntries, until it is back below the limit. This
+// suits workloads that prefer a temporary overshoot to write
+// latency.
+//
+// Evicted entries are reported with EvictionCapacity, see
+// WithOnEvict.
+func WithSoftLimit[K comparable, V any](n int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.softLimit = int64(n)
+	}
+}
+
+// WithMaxEntries sets an approximate limit on the number of
+// entries in the map. When a store exceeds the limit, entries
+// are evicted eagerly by the storing goroutine, until the map
+// is back below the limit. Entries that were not used since
+// the last tick are evicted first, in the order of their
+// deadlines, so hot entries survive the limit. This bounds the
+// memory of a traffic spike within one TTL, at the cost of
+// latency for the store that exceeds the limit.
+//
+// Evicted entries are reported with EvictionCapacity, see
+// WithOnEvict. Stores that run concurrently with trimming
+// might exceed the limit slightly.
+func WithMaxEntries[K comparable, V any](n int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.maxEntries = int64(n)
+	}
+}
+
+// WithMaxBytes sets an approximate limit on the footprint of
+// the map in bytes. The sizer is called with every key and its
+// stored value, which is encoded when WithTransform is used,
+// and must return the same size for the same value. When a
+// store exceeds the limit, entries are evicted eagerly by the
+// storing goroutine, like WithMaxEntries. This suits caches
+// of variable size values, like response bodies.
+//
+// Evicted entries are reported with EvictionCapacity, see
+// WithOnEvict.
+func WithMaxBytes[K comparable, V any](limit int64, sizer func(key K, value V) int64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.maxBytes = limit
+		m.sizer = sizer
+	}
+}
+
+// sizeOf returns the size of e measured by the sizer, or 0
+// when WithMaxBytes is not used.
+func (m *TTLMap[K, V]) sizeOf(key K, e *entry[V]) int64 {
+	if m.sizer == nil {
+		return 0
+	}
+	return m.sizer(key, e.value)
+}
+
+// resized is called after entries are added to the map. It
+// trims the map when it exceeds its max entries or bytes, and
+// starts trimming in the background when it exceeds its soft
+// limit.
+func (m *TTLMap[K, V]) resized() {
+	if m.overMax() && m.trimming.CompareAndSwap(false, true) {
+		m.trim(m.overMax)
+		m.trimming.Store(false)
+	}
+	if m.softLimit > 0 && m.count.Load() > m.softLimit && m.trimming.CompareAndSwap(false, true) {
+		go m.labeled(func() {
+			defer m.trimming.Store(false)
+			m.trim(func() bool {
+				return m.count.Load() > m.softLimit
+			})
+		})
+	}
+}
+
+// overMax reports whether the map exceeds its max entries or
+// max bytes.
+func (m *TTLMap[K, V]) overMax() bool {
+	return (m.maxEntries > 0 && m.count.Load() > m.maxEntries) ||
+		(m.maxBytes > 0 && m.bytes.Load() > m.maxBytes)
+}
+
+// trimBatch is the number of keys that trim copies from a
+// generation at once.
+const trimBatch = 64
+
+// trimHand is the position of trim in the generations of a
+// GenerationExpirer. The gen is relative to the head of the
+// ring at tick, and i the index of the next key in it. The
+// keys are reused to copy the keys of generations.
+type trimHand[K comparable] struct {
+	tick uint64
+	gen  int
+	i    int
+	keys []K
+}
+
+// trim evicts entries while the map is over its limit, with
+// the CLOCK algorithm: a hand walks the generations from the
+// head, entries that were stored or loaded since the hand last
+// passed them get a second chance, and others are evicted. The
+// hand keeps its position between calls, so every store over
+// the limit only moves it as far as needed. Nothing is evicted
+// while expiration is paused.
+func (m *TTLMap[K, V]) trim(over func() bool) {
+	if !over() || m.frozen.Load() == frozenPaused {
+		return
+	}
+	if !m.trimRing(over) {
+		m.trimScan(over)
+	}
+}
+
+// trimRing moves the hand of trim through the generations of a
+// GenerationExpirer for at most two laps. It reports whether
+// the map is under its limit, and false when the expirer has
+// no ring.
+func (m *TTLMap[K, V]) trimRing(over func() bool) bool {
+	for laps := 0; laps < 2; {
+		m.mu.Lock()
+		g, ok := m.expirer.(*GenerationExpirer[K])
+		if !ok {
+			m.mu.Unlock()
+			return false
+		} else if m.hand.tick != g.tick {
+			m.hand = trimHand[K]{tick: g.tick, keys: m.hand.keys}
+		}
+		if m.hand.gen >= len(g.generations) {
+			m.hand.gen, m.hand.i = 0, 0
+			laps++
+			m.mu.Unlock()
+			continue
+		}
+		deadline := g.tick + 1 + uint64(m.hand.gen)
+		gen := g.generations[g.wrap(g.head+uint64(m.hand.gen))]
+		if m.hand.i >= len(gen) {
+			m.hand.gen, m.hand.i = m.hand.gen+1, 0
+			m.mu.Unlock()
+			continue
+		}
+		m.hand.keys = append(m.hand.keys[:0], gen[m.hand.i:min(m.hand.i+trimBatch, len(gen))]...)
+		m.mu.Unlock()
+
+		tick := m.tick.Load()
+		for _, key := range m.hand.keys {
+			if !over() {
+				return true
+			}
+			m.hand.i++
+			e, ok := m.storage().Load(key)
+			if !ok || e.expires.Load() != deadline {
+				// The key is stale, it moved to another
+				// generation.
+				continue
+			} else if e.used.Swap(0) > tick {
+				continue
+			}
+			m.evict(key, deadline)
+		}
+	}
+	return !over()
+}
+
+// trimScan evicts entries by scanning the map. Entries that
+// were not used in the current tick are evicted first, in the
+// order of their deadlines. It is used for expirers without a
+// ring of generations, and for entries beyond the ring.
+func (m *TTLMap[K, V]) trimScan(over func() bool) {
+	type candidate struct {
+		key      K
+		deadline uint64
+	}
+	var cold, hot []candidate
+	tick := m.tick.Load()
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		expires := e.expires.Load()
+		if expires == 0 {
+			return true
+		} else if e.created >= tick || e.used.Load() > tick {
+			hot = append(hot, candidate{key, expires})
+		} else {
+			cold = append(cold, candidate{key, expires})
+		}
+		return true
+	})
+
+	for _, candidates := range [][]candidate{cold, hot} {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].deadline < candidates[j].deadline
+		})
+		for _, c := range candidates {
+			if !over() {
+				return
+			}
+			m.evict(c.key, c.deadline)
+		}
+	}
+}
+
+// evict removes the entry for key before its deadline, if it
+// expires at or before deadline. It reports whether the entry
+// was removed.
+func (m *TTLMap[K, V]) evict(key K, deadline uint64) bool {
+	defer m.operation()()
+	if m.expire(key, deadline, EvictionCapacity) == nil {
+		return false
+	}
+	m.unschedule(key)
+	return true
+}
+
+// WithHardLimit sets a limit on the number of entries in the
+// map. Instead of evicting entries, stores of new keys are
+// rejected while the map holds n or more entries. Store and
+// StoreWithMeta ignore the rejection, TryStore and Add return
+// ErrFull. This suits stores like pending request tables,
+// where silently evicting an entry causes bugs.
+//
+// When overflow is not nil, it is called with every rejected
+// key and value. Stores that run concurrently might exceed the
+// limit slightly.
+func WithHardLimit[K comparable, V any](n int, overflow func(key K, value V)) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.hardLimit = int64(n)
+		m.overflow = overflow
+	}
+}
+
+// full reports whether storing a new key is rejected because
+// the map is at its hard limit. The overflow function is
+// called when it is.
+func (m *TTLMap[K, V]) full(key K, value V) bool {
+	if m.hardLimit <= 0 || m.count.Load() < m.hardLimit {
+		return false
+	}
+
+	if m.overflow != nil {
+		m.overflow(key, value)
+	}
+	return true
+}
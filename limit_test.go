@@ -0,0 +1,164 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(cond func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return cond()
+}
+
+func TestWithSoftLimit(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithSoftLimit[string, string](2))
+	ttlmap.Store("key1", "value1")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key3", "value3")
+
+	if !waitFor(func() bool { return ttlmap.count.Load() == 2 }) {
+		t.Errorf("Expected map to be trimmed to 2 entries, but has %d", ttlmap.count.Load())
+	} else if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected oldest key to be evicted, but was not")
+	} else if _, ok := ttlmap.Load("key3"); !ok {
+		t.Errorf("Expected to find newest key, but did not")
+	}
+}
+
+func TestWithMaxEntries(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithMaxEntries[string, string](2))
+	ttlmap.Store("key1", "value1")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key3", "value3")
+
+	if n := ttlmap.count.Load(); n != 2 {
+		t.Errorf("Expected map to be trimmed to 2 entries, but has %d", n)
+	} else if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected oldest key to be evicted, but was not")
+	} else if _, ok := ttlmap.Load("key3"); !ok {
+		t.Errorf("Expected to find newest key, but did not")
+	}
+}
+
+func TestWithMaxEntriesRecency(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithMaxEntries[string, string](3))
+	ttlmap.Store("hot", "value")
+	ttlmap.Store("cold1", "value")
+	ttlmap.Store("cold2", "value")
+	ttlmap.nextGeneration()
+	ttlmap.Load("hot")
+	ttlmap.Store("key", "value")
+	_, cold1 := ttlmap.Load("cold1")
+	_, cold2 := ttlmap.Load("cold2")
+
+	if n := ttlmap.count.Load(); n != 3 {
+		t.Errorf("Expected map to be trimmed to 3 entries, but has %d", n)
+	} else if _, ok := ttlmap.Load("hot"); !ok {
+		t.Errorf("Expected used key to survive, but it was evicted")
+	} else if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find newest key, but did not")
+	} else if cold1 == cold2 {
+		t.Errorf("Expected exactly one cold key to be evicted, but was not")
+	}
+}
+
+func TestWithMaxEntriesSameTick(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithMaxEntries[int, int](3))
+	for i := range 4 {
+		ttlmap.Store(i, i)
+	}
+
+	if n := ttlmap.count.Load(); n != 3 {
+		t.Errorf("Expected map to be trimmed to 3 entries, but has %d", n)
+	} else if _, ok := ttlmap.Load(0); ok {
+		t.Errorf("Expected oldest key to be evicted, but was not")
+	}
+}
+
+func TestWithMaxEntriesStored(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithMaxEntries[string, string](2))
+	ttlmap.Store("old", "value")
+	ttlmap.nextGeneration()
+	ttlmap.StoreWithTTL("short", "value", time.Hour)
+	ttlmap.Store("key", "value")
+
+	if _, ok := ttlmap.Load("short"); !ok {
+		t.Errorf("Expected key stored in the current tick to survive, but it was evicted")
+	} else if _, ok := ttlmap.Load("old"); ok {
+		t.Errorf("Expected unused key to be evicted, but was not")
+	}
+}
+
+func TestWithMaxBytes(t *testing.T) {
+	ttlmap := New(4*time.Hour, time.Hour, WithMaxBytes(10, func(_ string, value string) int64 {
+		return int64(len(value))
+	}))
+	ttlmap.Store("key1", "12345")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key2", "1234")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key3", "123")
+
+	if n := ttlmap.bytes.Load(); n != 7 {
+		t.Errorf("Expected map to be trimmed to 7 bytes, but has %d", n)
+	} else if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected oldest key to be evicted, but was not")
+	}
+
+	ttlmap.Compute("key3", func(string, bool) (string, bool) {
+		return "1", false
+	})
+	ttlmap.Delete("key2")
+	if n := ttlmap.bytes.Load(); n != 1 {
+		t.Errorf("Expected map to have 1 byte, but has %d", n)
+	}
+}
+
+func TestWithHardLimit(t *testing.T) {
+	var overflowed []string
+	ttlmap := New(time.Hour, time.Minute, WithHardLimit(1, func(key string, _ string) {
+		overflowed = append(overflowed, key)
+	}))
+	ttlmap.Store("key1", "value1")
+
+	if err := ttlmap.TryStore("key2", "value2"); !errors.Is(err, ErrFull) {
+		t.Errorf("Expected ErrFull, but got '%v'", err)
+	} else if err = ttlmap.Add("key2", "value2"); !errors.Is(err, ErrFull) {
+		t.Errorf("Expected ErrFull, but got '%v'", err)
+	} else if err = ttlmap.TryStore("key1", "other"); err != nil {
+		t.Errorf("Expected existing key to be replaced, but got '%v'", err)
+	} else if len(overflowed) != 2 {
+		t.Errorf("Expected 2 overflows, but got %d", len(overflowed))
+	}
+
+	ttlmap.Delete("key1")
+	if err := ttlmap.TryStore("key2", "value2"); err != nil {
+		t.Errorf("Expected store after delete to succeed, but got '%v'", err)
+	}
+}
+
+// BenchmarkWithMaxEntries stores new keys into a full map.
+func BenchmarkWithMaxEntries(b *testing.B) {
+	const limit = 100_000
+	ttlmap := New(time.Hour, time.Minute, WithMaxEntries[int, int](limit))
+	defer ttlmap.Close()
+	for i := range limit {
+		ttlmap.Store(i, i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ttlmap.Store(limit+i, i)
+	}
+}
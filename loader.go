@@ -0,0 +1,138 @@
+package ttlmap
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// errLoaderPanicked is the error of a loader that panicked.
+var errLoaderPanicked = errors.New("loader panicked")
+
+// LoaderStats contains statistics of the loaders called by
+// LoadOrCompute, see Stats. Together with the hit ratio they
+// tell a cache that is ineffective from an origin that is
+// slow or failing.
+type LoaderStats struct {
+	// Calls is the number of calls of loaders, and Errors the
+	// number of calls that returned an error or panicked.
+	Calls  uint64
+	Errors uint64
+	// CachedErrors is the number of misses that returned an
+	// error cached by WithNegativeCaching, without calling the
+	// loader.
+	CachedErrors uint64
+	// Latency is the latency of the calls of loaders.
+	Latency LatencyHistogram
+}
+
+// ErrorRatio returns the fraction of calls of loaders that
+// failed.
+func (s LoaderStats) ErrorRatio() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Calls)
+}
+
+// loaderStats records the calls of loaders of a map.
+type loaderStats struct {
+	calls, errors, cachedErrors atomic.Uint64
+	latency                     histogram
+}
+
+// stats returns the counters as LoaderStats.
+func (l *loaderStats) stats() LoaderStats {
+	return LoaderStats{
+		Calls:        l.calls.Load(),
+		Errors:       l.errors.Load(),
+		CachedErrors: l.cachedErrors.Load(),
+		Latency:      l.latency.snapshot(),
+	}
+}
+
+// loaderCall is a running call of a loader.
+type loaderCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// LoadOrCompute returns the value stored in the map for a key.
+// When the key is missing, the value is loaded with loader and
+// stored in the map. Concurrent misses for the same key share
+// a single call of loader, the other callers wait for its
+// result. This prevents a thundering herd of loads against a
+// backing store.
+//
+// Errors of loader are returned as a *LoaderError, and are not
+// cached unless WithNegativeCaching is used. The loaded value
+// is returned even if storing it fails, for example while the
+// map is frozen. It returns ErrThrottled for keys that are
+// penalized for missing, see WithMissPenalty.
+func (m *TTLMap[K, V]) LoadOrCompute(key K, loader func(key K) (V, error)) (V, error) {
+	key = m.key(key)
+	penalized := m.penalty != nil && m.penalized(key)
+	if value, ok := m.Load(key); ok {
+		return value, nil
+	} else if penalized {
+		return *new(V), ErrThrottled
+	} else if m.negative != nil {
+		if err := m.cachedError(key); err != nil {
+			m.loads.cachedErrors.Add(1)
+			return *new(V), err
+		}
+	}
+
+	c := &loaderCall[V]{done: make(chan struct{})}
+	if actual, loaded := m.calls.LoadOrStore(key, c); loaded {
+		c = actual.(*loaderCall[V])
+		<-c.done
+		return c.value, c.err
+	}
+
+	// The error is replaced when loader returns, waiting
+	// callers see it when loader panics.
+	c.err = &LoaderError{Key: key, Err: errLoaderPanicked}
+	defer func() {
+		m.calls.Delete(key)
+		close(c.done)
+	}()
+
+	// Check the map again, the value might be stored by a
+	// call that finished after the first load.
+	if e, ok := m.storage().Load(key); ok && e.expires.Load() != 0 && !m.hidden(e) {
+		c.value, c.err = m.value(e), nil
+		return c.value, nil
+	}
+
+	m.loads.calls.Add(1)
+	var done func(err error)
+	if m.hooks != nil {
+		done = m.loadHooks(key)
+	}
+	start, err := time.Now(), errLoaderPanicked
+	defer func() {
+		m.loads.latency.observe(start)
+		if err != nil {
+			m.loads.errors.Add(1)
+		}
+		if done != nil {
+			done(err)
+		}
+	}()
+	var value V
+	value, err = loader(key)
+	if err != nil {
+		loaderErr := &LoaderError{Key: key, Err: err}
+		if m.negative != nil {
+			m.cacheError(key, loaderErr)
+		}
+		c.err = loaderErr
+		return *new(V), c.err
+	}
+
+	_ = m.TryStore(key, value)
+	c.value, c.err = value, nil
+	return value, nil
+}
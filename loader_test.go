@@ -0,0 +1,79 @@
+package ttlmap
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadOrCompute(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := ttlmap.LoadOrCompute("key", func(key string) (string, error) {
+				calls.Add(1)
+				<-release
+				return "value", nil
+			})
+			if err != nil || value != "value" {
+				t.Errorf("Expected value to be 'value', but got '%s' and '%v'", value, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected loader to be called once, but was called %d times", n)
+	} else if value, _ := ttlmap.Load("key"); value != "value" {
+		t.Errorf("Expected value to be stored, but was '%s'", value)
+	}
+}
+
+func TestLoadOrComputeError(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	_, err := ttlmap.LoadOrCompute("key", func(string) (string, error) {
+		return "", io.EOF
+	})
+
+	if !errors.Is(err, ErrLoader) || !errors.Is(err, io.EOF) {
+		t.Errorf("Expected a LoaderError wrapping io.EOF, but got '%v'", err)
+	} else if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected error to not be cached, but was")
+	}
+}
+
+func TestLoaderStats(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithNegativeCaching[string, string](time.Minute, nil))
+	defer ttlmap.Close()
+	ttlmap.LoadOrCompute("key", func(key string) (string, error) {
+		return "value", nil
+	})
+	ttlmap.LoadOrCompute("key", func(key string) (string, error) {
+		return "", errors.New("unreachable")
+	})
+	for range 2 {
+		ttlmap.LoadOrCompute("missing", func(key string) (string, error) {
+			return "", ErrNotFound
+		})
+	}
+
+	stats := ttlmap.Stats().Loader
+	if stats.Calls != 2 || stats.Errors != 1 || stats.CachedErrors != 1 {
+		t.Errorf("Expected 2 calls, 1 error and 1 cached error, but got %+v", stats)
+	} else if stats.Latency.Count != 2 {
+		t.Errorf("Expected 2 timed calls, but got %d", stats.Latency.Count)
+	} else if stats.ErrorRatio() != 0.5 {
+		t.Errorf("Expected error ratio 0.5, but got %f", stats.ErrorRatio())
+	}
+}
@@ -0,0 +1,109 @@
+package ttlmap
+
+import (
+	"log/slog"
+	"time"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// EventAdvanced is logged when the map advanced a
+	// generation. Count is the number of entries that
+	// expired, and Duration the time the sweep took.
+	EventAdvanced EventKind = iota
+	// EventGrown is logged when generations of the
+	// GenerationExpirer outgrew their backing array since the
+	// last tick. Count is the number of times an array was
+	// grown.
+	EventGrown
+	// EventShrunk is logged when generations of the
+	// GenerationExpirer dropped a backing array that was
+	// mostly unused, see WithCapacityHint. Count is the number
+	// of dropped arrays.
+	EventShrunk
+	// EventPanicked is logged when a callback, like the
+	// function of WithOnEvict, panicked. Panic is the value it
+	// panicked with, the panic is recovered, see Err.
+	EventPanicked
+)
+
+// String returns the name of the kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventAdvanced:
+		return "advanced"
+	case EventGrown:
+		return "grown"
+	case EventShrunk:
+		return "shrunk"
+	case EventPanicked:
+		return "panicked"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a lifecycle event of a map, see WithLogger.
+type Event struct {
+	Kind EventKind
+	// Map is the name of the map, see WithName.
+	Map string
+	// Tick is the tick of the map at the event.
+	Tick     uint64
+	Count    int
+	Duration time.Duration
+	Panic    any
+}
+
+// Attrs returns the fields of the event that are set, as
+// attributes for log/slog:
+//
+//	ttlmap.WithLogger[K, V](func(e ttlmap.Event) {
+//		slog.LogAttrs(ctx, slog.LevelDebug, "ttlmap "+e.Kind.String(), e.Attrs()...)
+//	})
+func (e Event) Attrs() []slog.Attr {
+	attrs := []slog.Attr{slog.Uint64("tick", e.Tick)}
+	if e.Map != "" {
+		attrs = append(attrs, slog.String("map", e.Map))
+	}
+	switch e.Kind {
+	case EventAdvanced:
+		attrs = append(attrs, slog.Int("expired", e.Count), slog.Duration("duration", e.Duration))
+	case EventGrown, EventShrunk:
+		attrs = append(attrs, slog.Int("count", e.Count))
+	case EventPanicked:
+		attrs = append(attrs, slog.Any("panic", e.Panic))
+	}
+	return attrs
+}
+
+// WithLogger calls log with the lifecycle events of the map,
+// so operators can see what the map does in the background.
+// The events are logged synchronously by the goroutine that
+// advances the map, or that calls the callback, so log must be
+// fast, and must not advance the map.
+func WithLogger[K comparable, V any](log func(event Event)) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.logger = log
+	}
+}
+
+// log logs an event of kind, with the name and tick of the map.
+func (m *TTLMap[K, V]) log(event Event) {
+	event.Map, event.Tick = m.name, m.tick.Load()
+	m.logger(event)
+}
+
+// logAdvanced logs the events of a generation that was
+// advanced.
+func (m *TTLMap[K, V]) logAdvanced(expired int, start time.Time, grown, shrunk int) {
+	m.log(Event{Kind: EventAdvanced, Count: expired, Duration: time.Since(start)})
+	if grown > 0 {
+		m.log(Event{Kind: EventGrown, Count: grown})
+	}
+	if shrunk > 0 {
+		m.log(Event{Kind: EventShrunk, Count: shrunk})
+	}
+}
@@ -0,0 +1,61 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	var events []Event
+	ttlmap := NewManual(2*time.Second, time.Second, WithName[int, int]("cache"), WithLogger[int, int](func(e Event) {
+		events = append(events, e)
+	}))
+	defer ttlmap.Close()
+
+	ttlmap.Store(0, 0)
+	for i := 1; i < 100; i++ {
+		ttlmap.Store(i, i)
+	}
+	ttlmap.Advance(2)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, but got %v", events)
+	} else if e := events[0]; e.Kind != EventAdvanced || e.Count != 0 || e.Tick != 1 || e.Map != "cache" {
+		t.Errorf("Expected an empty generation to be advanced, but got %+v", e)
+	} else if e = events[1]; e.Kind != EventGrown || e.Count != 7 {
+		t.Errorf("Expected the generation of the stores to grow 7 times, but got %+v", e)
+	} else if e = events[2]; e.Kind != EventAdvanced || e.Count != 100 || e.Tick != 2 {
+		t.Errorf("Expected 100 entries to expire, but got %+v", e)
+	}
+
+	// A mostly unused array is dropped.
+	ttlmap.Store(0, 0)
+	events = nil
+	ttlmap.Advance(2)
+	if e := events[len(events)-1]; e.Kind != EventShrunk || e.Count != 1 {
+		t.Errorf("Expected a generation to shrink, but got %+v", events)
+	}
+}
+
+func TestWithLoggerPanic(t *testing.T) {
+	var events []Event
+	ttlmap := NewManual(time.Second, time.Second,
+		WithLogger[int, int](func(e Event) { events = append(events, e) }),
+		WithOnEvict(func(key int, value int, reason EvictionReason) { panic("evict") }))
+	defer ttlmap.Close()
+	ttlmap.Store(1, 1)
+	ttlmap.Delete(1)
+
+	if len(events) != 1 || events[0].Kind != EventPanicked || events[0].Panic != "evict" {
+		t.Errorf("Expected the panic to be logged, but got %+v", events)
+	}
+}
+
+func TestEventAttrs(t *testing.T) {
+	e := Event{Kind: EventAdvanced, Map: "cache", Tick: 3, Count: 2, Duration: time.Millisecond}
+	attrs := e.Attrs()
+	if len(attrs) != 4 || attrs[2].Key != "expired" || attrs[2].Value.Int64() != 2 {
+		t.Errorf("Expected tick, map, expired and duration, but got %v", attrs)
+	} else if EventPanicked.String() != "panicked" {
+		t.Errorf("Expected kind 'panicked', but got '%s'", EventPanicked)
+	}
+}
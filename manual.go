@@ -0,0 +1,42 @@
+package ttlmap
+
+import "time"
+
+// NewManual creates a TTLMap that is advanced by its caller,
+// see New for the meaning of ttl, interval and opts. It starts
+// no goroutine, generations are only advanced by Advance, Tick
+// and AdvanceTo. This suits short-lived maps, deterministic
+// simulations, event loops and targets without threads like
+// WASM. WithScheduler and WithAdaptiveInterval have no effect.
+//
+// The interval is the duration of a tick, it determines how
+// many ticks entries live.
+func NewManual[K comparable, V any](ttl, interval time.Duration, opts ...Option[K, V]) *TTLMap[K, V] {
+	ttlMap := newTTLMap(ttl, interval, opts...)
+	ttlMap.scheduler = nil
+	ttlMap.manual = true
+	return ttlMap
+}
+
+// Advance advances the map by n generations, expiring the
+// entries that are due. It works for every map, but calling it
+// on a map with a ticker makes expiration run early. Calling
+// Advance on a child map is a no-op, see AdvanceTo.
+func (m *TTLMap[K, V]) Advance(n int) {
+	if m.parent != nil {
+		return
+	}
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	for i := 0; i < n; i++ {
+		m.advance()
+		m.nextTick = m.nextTick.Add(m.tickInterval())
+	}
+}
+
+// Tick advances the map by one generation, it is shorthand for
+// Advance(1).
+func (m *TTLMap[K, V]) Tick() {
+	m.Advance(1)
+}
@@ -0,0 +1,27 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewManual(t *testing.T) {
+	ttlmap := NewManual[string, string](2*time.Millisecond, time.Millisecond)
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+
+	if ttlmap.ticker != nil {
+		t.Errorf("Expected no ticker to be started, but got one")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	ttlmap.Tick()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected key to be present after 1 tick, but it expired")
+	}
+
+	ttlmap.Advance(1)
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire after 2 ticks, but it is present")
+	}
+}
@@ -0,0 +1,117 @@
+package ttlmap
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatsSource is a map that reports statistics, like a
+// *TTLMap.
+type StatsSource interface {
+	Stats() Stats
+}
+
+// metric is a metric written by MetricsHandler.
+type metric struct {
+	name, kind, help string
+	value            func(stats Stats) float64
+}
+
+// metrics are the metrics written by MetricsHandler, they
+// match the metrics of the ttlmapprom package.
+var metrics = []metric{
+	{"ttlmap_entries", "gauge", "Number of entries in the map.", func(s Stats) float64 { return float64(s.Entries) }},
+	{"ttlmap_hits_total", "counter", "Number of loads that found an entry.", func(s Stats) float64 { return float64(s.Hits) }},
+	{"ttlmap_misses_total", "counter", "Number of loads that did not find an entry.", func(s Stats) float64 { return float64(s.Misses) }},
+	{"ttlmap_stores_total", "counter", "Number of entries added to the map.", func(s Stats) float64 { return float64(s.Stores) }},
+	{"ttlmap_deletes_total", "counter", "Number of entries deleted explicitly.", func(s Stats) float64 { return float64(s.Deletes) }},
+	{"ttlmap_expirations_total", "counter", "Number of entries that expired.", func(s Stats) float64 { return float64(s.Expirations) }},
+	{"ttlmap_dropped_expirations_total", "counter", "Number of expired entries dropped by a full expired channel.", func(s Stats) float64 { return float64(s.DroppedExpirations) }},
+}
+
+// MetricsHandler returns a handler that serves the Stats of
+// maps in the Prometheus text exposition format, without
+// depending on the Prometheus client library. The keys of maps
+// are used as the "map" label. Use the ttlmapprom package to
+// register maps with a Prometheus registry instead.
+func MetricsHandler(maps map[string]StatsSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := make([]string, 0, len(maps))
+		for name := range maps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		stats := make([]Stats, len(names))
+		for i, name := range names {
+			stats[i] = maps[name].Stats()
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		for _, metric := range metrics {
+			fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+			for i, name := range names {
+				fmt.Fprintf(buf, "%s{map=%s} %s\n", metric.name, quoteLabel(name), formatValue(metric.value(stats[i])))
+			}
+		}
+
+		const generationKeys = "ttlmap_generation_keys"
+		fmt.Fprintf(buf, "# HELP %s Number of keys scheduled in a generation, by generations until expiry.\n# TYPE %s gauge\n", generationKeys, generationKeys)
+		for i, name := range names {
+			for generation, keys := range stats[i].Generations {
+				fmt.Fprintf(buf, "%s{map=%s,generation=\"%d\"} %d\n", generationKeys, quoteLabel(name), generation, keys)
+			}
+		}
+
+		const duration = "ttlmap_operation_duration_seconds"
+		fmt.Fprintf(buf, "# HELP %s Latency of sampled operations of the map.\n# TYPE %s histogram\n", duration, duration)
+		for i, name := range names {
+			if stats[i].Latency == nil {
+				continue
+			}
+			for _, op := range latencyOps(stats[i].Latency) {
+				labels := fmt.Sprintf("map=%s,operation=%q", quoteLabel(name), op.name)
+				var count uint64
+				for j, n := range op.h.Counts {
+					count += n
+					le := "+Inf"
+					if j < len(op.h.Bounds) {
+						le = formatValue(op.h.Bounds[j].Seconds())
+					}
+					fmt.Fprintf(buf, "%s_bucket{%s,le=%q} %d\n", duration, labels, le, count)
+				}
+				fmt.Fprintf(buf, "%s_sum{%s} %s\n", duration, labels, formatValue(op.h.Sum.Seconds()))
+				fmt.Fprintf(buf, "%s_count{%s} %d\n", duration, labels, op.h.Count)
+			}
+		}
+		buf.Flush()
+	})
+}
+
+// latencyOp is a latency histogram of an operation.
+type latencyOp struct {
+	name string
+	h    LatencyHistogram
+}
+
+// latencyOps returns the histograms of s by operation name.
+func latencyOps(s *LatencyStats) []latencyOp {
+	return []latencyOp{{"load", s.Load}, {"store", s.Store}, {"sweep", s.Sweep}}
+}
+
+// quoteLabel quotes a label value as required by the
+// exposition format.
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+// formatValue formats a sample value.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
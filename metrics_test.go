@@ -0,0 +1,43 @@
+package ttlmap
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key", "value")
+	ttlmap.Load("key")
+
+	recorder := httptest.NewRecorder()
+	MetricsHandler(map[string]StatsSource{"test": ttlmap}).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	if !strings.Contains(body, "# TYPE ttlmap_hits_total counter\nttlmap_hits_total{map=\"test\"} 1\n") {
+		t.Errorf("Expected hits to be exposed, but got:\n%s", body)
+	} else if !strings.Contains(body, "ttlmap_entries{map=\"test\"} 1\n") {
+		t.Errorf("Expected entries to be exposed, but got:\n%s", body)
+	} else if !strings.Contains(body, "ttlmap_generation_keys{map=\"test\",generation=\"59\"} 1\n") {
+		t.Errorf("Expected generation keys to be exposed, but got:\n%s", body)
+	}
+}
+
+func TestMetricsHandlerLatency(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithLatencyHistograms[string, string](1))
+	ttlmap.Load("key")
+
+	recorder := httptest.NewRecorder()
+	MetricsHandler(map[string]StatsSource{"test": ttlmap}).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	if !strings.Contains(body, "# TYPE ttlmap_operation_duration_seconds histogram\n") {
+		t.Errorf("Expected a latency histogram, but got:\n%s", body)
+	} else if !strings.Contains(body, "ttlmap_operation_duration_seconds_bucket{map=\"test\",operation=\"load\",le=\"+Inf\"} 1\n") {
+		t.Errorf("Expected the load to be counted, but got:\n%s", body)
+	} else if !strings.Contains(body, "ttlmap_operation_duration_seconds_count{map=\"test\",operation=\"store\"} 0\n") {
+		t.Errorf("Expected no stores to be counted, but got:\n%s", body)
+	}
+}
@@ -0,0 +1,37 @@
+package ttlmap
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithName names the map in profiles. The work of the map in
+// the background, expiring, trimming and revalidating
+// entries, runs with the pprof label ttlmap=name, so CPU and
+// goroutine profiles attribute it to the map. This shows which
+// map costs the time in processes with many maps.
+//
+// Heap profiles attribute allocations to call stacks, not to
+// labels, and the allocations of all maps share their call
+// stacks. Use ResourceReport for the memory held by a map.
+func WithName[K comparable, V any](name string) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.name = name
+	}
+}
+
+// Name returns the name of the map, see WithName.
+func (m *TTLMap[K, V]) Name() string {
+	return m.name
+}
+
+// labeled calls f with the pprof labels of the map.
+func (m *TTLMap[K, V]) labeled(f func()) {
+	if m.name == "" {
+		f()
+		return
+	}
+	pprof.Do(context.Background(), pprof.Labels("ttlmap", m.name), func(context.Context) {
+		f()
+	})
+}
@@ -0,0 +1,22 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithName(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithName[string, string]("sessions"))
+	defer ttlmap.Close()
+
+	called := false
+	ttlmap.labeled(func() {
+		called = true
+	})
+
+	if ttlmap.Name() != "sessions" {
+		t.Errorf("Expected name sessions, but got '%s'", ttlmap.Name())
+	} else if !called {
+		t.Errorf("Expected the labeled function to be called, but it was not")
+	}
+}
@@ -0,0 +1,92 @@
+package ttlmap
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithNegativeCaching caches the errors of the loaders of
+// LoadOrCompute for ttl, which is usually shorter than the TTL
+// of the map. While an error is cached, LoadOrCompute returns
+// it for the key without calling the loader, so repeated
+// lookups of a missing key don't hammer the backing store.
+//
+// Only errors for which cacheable returns true are cached, with
+// a nil cacheable those that match ErrNotFound. Values that are
+// stored for the key are loaded as usual, and Clear forgets
+// the errors. The ttl is rounded down to a multiple of the
+// interval, with a minimum of one interval.
+func WithNegativeCaching[K comparable, V any](ttl time.Duration, cacheable func(err error) bool) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		if cacheable == nil {
+			cacheable = func(err error) bool {
+				return errors.Is(err, ErrNotFound)
+			}
+		}
+		m.negative = &negativeCache[K]{ttl: ttl, cacheable: cacheable}
+	}
+}
+
+// negativeCache caches the errors of loaders, see
+// WithNegativeCaching.
+type negativeCache[K comparable] struct {
+	ttl       time.Duration
+	cacheable func(err error) bool
+
+	// mu guards errs and prune. Errors are pruned once per
+	// ttl, at tick prune.
+	mu    sync.Mutex
+	errs  map[K]negative
+	prune uint64
+}
+
+// negative is a cached error.
+type negative struct {
+	err *LoaderError
+	// until is the tick until which the error is cached.
+	until uint64
+}
+
+// cachedError returns the cached error of key, or nil.
+func (m *TTLMap[K, V]) cachedError(key K) *LoaderError {
+	n := m.negative
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if cached, ok := n.errs[key]; ok && cached.until > m.tick.Load() {
+		return cached.err
+	}
+	return nil
+}
+
+// cacheError caches the error of the loader of key, when it is
+// cacheable.
+func (m *TTLMap[K, V]) cacheError(key K, err *LoaderError) {
+	n := m.negative
+	if !n.cacheable(err.Err) {
+		return
+	}
+	tick, ticks := m.tick.Load(), m.atLeastOneTick(n.ttl)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.errs == nil {
+		n.errs = make(map[K]negative)
+	} else if tick >= n.prune {
+		for k, cached := range n.errs {
+			if cached.until <= tick {
+				delete(n.errs, k)
+			}
+		}
+		n.prune = tick + ticks
+	}
+	n.errs[key] = negative{err: err, until: tick + ticks}
+}
+
+// clearErrors forgets the cached errors.
+func (m *TTLMap[K, V]) clearErrors() {
+	n := m.negative
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	clear(n.errs)
+}
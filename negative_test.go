@@ -0,0 +1,50 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithNegativeCaching(t *testing.T) {
+	ttlmap := NewManual(time.Hour, time.Second, WithNegativeCaching[string, string](10*time.Second, nil))
+	defer ttlmap.Close()
+
+	calls := 0
+	errFailed := errors.New("failed")
+	loader := func(key string) (string, error) {
+		calls++
+		if key == "failing" {
+			return "", errFailed
+		}
+		return "", ErrNotFound
+	}
+
+	ttlmap.LoadOrCompute("missing", loader)
+	if _, err := ttlmap.LoadOrCompute("missing", loader); !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrLoader) {
+		t.Errorf("Expected cached ErrNotFound, but got '%v'", err)
+	} else if calls != 1 {
+		t.Errorf("Expected cached error not to call the loader, but got %d calls", calls)
+	}
+
+	ttlmap.LoadOrCompute("failing", loader)
+	if ttlmap.LoadOrCompute("failing", loader); calls != 3 {
+		t.Errorf("Expected other errors not to be cached, but got %d calls", calls)
+	}
+
+	ttlmap.Store("missing", "value")
+	if value, err := ttlmap.LoadOrCompute("missing", loader); err != nil || value != "value" {
+		t.Errorf("Expected stored value to be loaded, but got '%s' '%v'", value, err)
+	}
+	ttlmap.Delete("missing")
+
+	ttlmap.Advance(10)
+	if ttlmap.LoadOrCompute("missing", loader); calls != 4 {
+		t.Errorf("Expected error to expire, but got %d calls", calls)
+	}
+
+	ttlmap.Clear()
+	if ttlmap.LoadOrCompute("missing", loader); calls != 5 {
+		t.Errorf("Expected Clear to forget errors, but got %d calls", calls)
+	}
+}
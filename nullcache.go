@@ -0,0 +1,180 @@
+package ttlmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// NullCache is an Interface that never stores anything, every
+// load is a miss. It is useful to disable caching in tests and
+// benchmarks, or behind a feature flag.
+type NullCache[K comparable, V any] struct {
+	frozen atomic.Bool
+}
+
+var _ Interface[string, string] = (*NullCache[string, string])(nil)
+
+// NewNullCache creates a new NullCache.
+func NewNullCache[K comparable, V any]() *NullCache[K, V] {
+	return &NullCache[K, V]{}
+}
+
+// Load always returns the zero value and false.
+func (c *NullCache[K, V]) Load(K) (V, bool) {
+	return *new(V), false
+}
+
+// LoadWithMinTTL always returns the zero value and false.
+func (c *NullCache[K, V]) LoadWithMinTTL(K, time.Duration) (V, bool) {
+	return *new(V), false
+}
+
+// LoadAndTouch always returns the zero value and false.
+func (c *NullCache[K, V]) LoadAndTouch(K) (V, bool) {
+	return *new(V), false
+}
+
+// Touch always returns false.
+func (c *NullCache[K, V]) Touch(K) bool {
+	return false
+}
+
+// Extend always returns false.
+func (c *NullCache[K, V]) Extend(K, time.Duration) bool {
+	return false
+}
+
+// Promote always returns false.
+func (c *NullCache[K, V]) Promote(K, time.Duration) bool {
+	return false
+}
+
+// TouchMany always returns 0.
+func (c *NullCache[K, V]) TouchMany([]K) int {
+	return 0
+}
+
+// GetEntry always returns false.
+func (c *NullCache[K, V]) GetEntry(K) (Entry[K, V], bool) {
+	return Entry[K, V]{}, false
+}
+
+// Store discards the value.
+func (c *NullCache[K, V]) Store(K, V) {}
+
+// StoreWithMeta discards the value.
+func (c *NullCache[K, V]) StoreWithMeta(K, V, any) {}
+
+// StoreWithTTL discards the value.
+func (c *NullCache[K, V]) StoreWithTTL(K, V, time.Duration) {}
+
+// TryStore discards the value. It returns ErrFrozen while the
+// cache is frozen.
+func (c *NullCache[K, V]) TryStore(K, V) error {
+	if c.Frozen() {
+		return ErrFrozen
+	}
+	return nil
+}
+
+// Add discards the value. It returns ErrFrozen while the
+// cache is frozen.
+func (c *NullCache[K, V]) Add(K, V) error {
+	if c.Frozen() {
+		return ErrFrozen
+	}
+	return nil
+}
+
+// LoadOrStore discards the value and returns it, with loaded
+// false.
+func (c *NullCache[K, V]) LoadOrStore(_ K, value V) (actual V, loaded bool) {
+	return value, false
+}
+
+// Swap discards the value and returns the zero value and
+// false.
+func (c *NullCache[K, V]) Swap(K, V) (previous V, loaded bool) {
+	return *new(V), false
+}
+
+// Compute calls fn as if the key does not exist, and discards
+// the result.
+func (c *NullCache[K, V]) Compute(_ K, fn func(old V, exists bool) (new V, delete bool)) (V, bool) {
+	value, del := fn(*new(V), false)
+	if del || c.Frozen() {
+		return *new(V), false
+	}
+	return value, true
+}
+
+// CompareAndDelete always returns false.
+func (c *NullCache[K, V]) CompareAndDelete(K, V) bool {
+	return false
+}
+
+// LoadOrCompute calls loader and returns its result, errors
+// are wrapped in a *LoaderError.
+func (c *NullCache[K, V]) LoadOrCompute(key K, loader func(key K) (V, error)) (V, error) {
+	value, err := loader(key)
+	if err != nil {
+		return *new(V), &LoaderError{Key: key, Err: err}
+	}
+	return value, nil
+}
+
+// CompareAndSwap always returns false.
+func (c *NullCache[K, V]) CompareAndSwap(K, V, V) bool {
+	return false
+}
+
+// Delete is a no-op.
+func (c *NullCache[K, V]) Delete(K) {}
+
+// TryDelete returns ErrFrozen while the cache is frozen.
+func (c *NullCache[K, V]) TryDelete(K) error {
+	if c.Frozen() {
+		return ErrFrozen
+	}
+	return nil
+}
+
+// DeleteFunc always returns 0.
+func (c *NullCache[K, V]) DeleteFunc(func(key K, value V) bool) int {
+	return 0
+}
+
+// LoadAndDelete always returns the zero value and false.
+func (c *NullCache[K, V]) LoadAndDelete(K) (V, bool) {
+	return *new(V), false
+}
+
+// Clear does nothing.
+func (c *NullCache[K, V]) Clear() {}
+
+// ReplaceAll discards the entries.
+func (c *NullCache[K, V]) ReplaceAll(map[K]V) {}
+
+// Range never calls f.
+func (c *NullCache[K, V]) Range(func(key K, value V) bool) {}
+
+// Freeze makes the cache read-only.
+func (c *NullCache[K, V]) Freeze(bool) {
+	c.frozen.Store(true)
+}
+
+// Unfreeze makes the cache writable again.
+func (c *NullCache[K, V]) Unfreeze() {
+	c.frozen.Store(false)
+}
+
+// Frozen reports whether the cache is frozen.
+func (c *NullCache[K, V]) Frozen() bool {
+	return c.frozen.Load()
+}
+
+// AdvanceTo is a no-op.
+func (c *NullCache[K, V]) AdvanceTo(time.Time) {}
+
+// Close is a no-op.
+func (c *NullCache[K, V]) Close() {}
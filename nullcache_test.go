@@ -0,0 +1,14 @@
+package ttlmap
+
+import "testing"
+
+func TestNullCache(t *testing.T) {
+	var cache Interface[string, string] = NewNullCache[string, string]()
+	cache.Store("key", "value")
+
+	if _, ok := cache.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	} else if value, loaded := cache.LoadOrStore("key", "value"); loaded || value != "value" {
+		t.Errorf("Expected value to be stored, but was loaded")
+	}
+}
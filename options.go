@@ -0,0 +1,135 @@
+package ttlmap
+
+// Option configures a TTLMap, see New.
+type Option[K comparable, V any] func(m *TTLMap[K, V])
+
+// WithCompareAndSwapRefresh resets the TTL of an entry when
+// CompareAndSwap succeeds, instead of preserving it. It is
+// shorthand for WithTTLPolicy(OpCompareAndSwap, PolicyReset).
+func WithCompareAndSwapRefresh[K comparable, V any]() Option[K, V] {
+	return WithTTLPolicy[K, V](OpCompareAndSwap, PolicyReset)
+}
+
+// WithSlidingTTL resets the TTL of an entry whenever it is
+// loaded by Load or LoadOrStore, so entries expire after they
+// were not accessed for the TTL. This is the usual semantic
+// for session caches and idle timeouts. It is shorthand for
+// WithTTLPolicy(OpLoad, PolicyReset) and
+// WithTTLPolicy(OpLoadOrStore, PolicyReset).
+func WithSlidingTTL[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.policies[OpLoad] = PolicyReset
+		m.policies[OpLoadOrStore] = PolicyReset
+	}
+}
+
+// WithTTLPolicy sets the TTL policy for an operation class.
+// See TTLMap for the default policy of each operation.
+func WithTTLPolicy[K comparable, V any](op Op, policy Policy) Option[K, V] {
+	if policy == PolicyDefault {
+		policy = defaultPolicies[op]
+	}
+
+	return func(m *TTLMap[K, V]) {
+		m.policies[op] = policy
+	}
+}
+
+// ShortTTL is the behavior of a map whose TTL is shorter than
+// its interval, see WithShortTTL.
+type ShortTTL int
+
+const (
+	// ShortTTLShrinkInterval lowers the interval to the TTL,
+	// so the map has a single generation as long as the TTL.
+	// Entries expire after one to two TTLs. It is the default.
+	ShortTTLShrinkInterval ShortTTL = iota
+	// ShortTTLRoundUp keeps the interval and rounds the TTL up
+	// to one interval. Entries expire at the next tick, after
+	// zero to one interval, which saves the cost of a fast
+	// ticker.
+	ShortTTLRoundUp
+)
+
+// WithShortTTL sets the behavior of the map when its TTL is
+// shorter than its interval, which suits short windows like
+// deduplicating requests for 100ms. It has no effect when the
+// TTL is at least the interval.
+func WithShortTTL[K comparable, V any](mode ShortTTL) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.shortTTL = mode
+	}
+}
+
+// WithExpirer sets the expiry engine of the map. By default
+// a GenerationExpirer is used, which makes expiring cheap for
+// large maps. A ListExpirer or HeapExpirer tracks every key
+// individually, which suits workloads that touch, overwrite
+// or delete keys often.
+func WithExpirer[K comparable, V any](e Expirer[K]) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.expirer = e
+		m.generationIndex = false
+	}
+}
+
+// WithGenerationIndex uses an indexed GenerationExpirer as
+// expiry engine, which moves keys that are stored or touched
+// again instead of adding them to another generation, and
+// removes deleted keys from their generation. This bounds the
+// memory of overwrite and delete heavy workloads, at the cost
+// of an index entry per key.
+func WithGenerationIndex[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.expirer = nil
+		m.generationIndex = true
+	}
+}
+
+// WithAdmission sets a function that decides whether a value
+// is stored in the map. It is consulted by every store, which
+// allows rejecting oversized or low-value entries centrally.
+// A rejected store deletes the existing entry for the key, so
+// no outdated value is served.
+func WithAdmission[K comparable, V any](admit func(key K, value V) bool) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.admit = admit
+	}
+}
+
+// WithWriteOnce makes stores of keys that are present in the
+// map fail until the entry expires or is deleted. Store
+// ignores the failure, TryStore returns ErrExists. The first
+// writer of a key wins for the TTL window, which is useful
+// for claim and reservation systems.
+//
+// CompareAndSwap and the typed maps still update present
+// keys.
+func WithWriteOnce[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.writeOnce = true
+	}
+}
+
+// WithKeyFunc sets a function that canonicalizes every key
+// passed to the map, like lowercasing or trimming strings, so
+// near duplicate keys share an entry. Range returns the
+// canonical keys. The function must be idempotent, as keys can
+// be canonicalized more than once.
+func WithKeyFunc[K comparable, V any](canonicalize func(key K) K) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.keyFunc = canonicalize
+	}
+}
+
+// WithTransform sets functions that transform values around
+// storage. Values are passed through encode before they are
+// stored, and through decode before they are returned. This
+// allows transparent compression, encryption or
+// canonicalization of values without changing call sites.
+func WithTransform[K comparable, V any](encode, decode func(value V) V) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.encoder = encode
+		m.decoder = decode
+	}
+}
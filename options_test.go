@@ -0,0 +1,113 @@
+package ttlmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithAdmission(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithAdmission(func(key string, value string) bool {
+		return len(value) <= 5
+	}))
+	ttlmap.Store("key", "value")
+	ttlmap.Store("key", "too long")
+
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected rejected store to delete key, but did not")
+	} else if err := ttlmap.TryStore("key", "too long"); !errors.Is(err, ErrNotAdmitted) {
+		t.Errorf("Expected ErrNotAdmitted, but got '%v'", err)
+	} else if err = ttlmap.Add("key", "too long"); !errors.Is(err, ErrNotAdmitted) {
+		t.Errorf("Expected ErrNotAdmitted, but got '%v'", err)
+	} else if _, loaded := ttlmap.LoadOrStore("key", "too long"); loaded {
+		t.Errorf("Expected to not load key, but did")
+	} else if _, ok = ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	} else if err = ttlmap.TryStore("key", "value"); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	}
+}
+
+func TestWithTransform(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithTransform[string, string](strings.ToUpper, strings.ToLower))
+	ttlmap.Store("key", "Value")
+
+	if value, ok := ttlmap.storage().Load("key"); !ok || value.value != "VALUE" {
+		t.Errorf("Expected stored value to be encoded, but was not")
+	} else if value, _ := ttlmap.Load("key"); value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if !ttlmap.CompareAndSwap("key", "value", "other") {
+		t.Errorf("Expected swap with decoded value to succeed, but it failed")
+	} else if value, _ = ttlmap.LoadAndDelete("key"); value != "other" {
+		t.Errorf("Expected value to be 'other', but was '%s'", value)
+	}
+}
+
+func TestWithWriteOnce(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithWriteOnce[string, string]())
+	ttlmap.Store("key", "first")
+
+	if err := ttlmap.TryStore("key", "second"); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, but got '%v'", err)
+	} else if value, _ := ttlmap.Load("key"); value != "first" {
+		t.Errorf("Expected value to be 'first', but was '%s'", value)
+	}
+
+	ttlmap.Delete("key")
+	if err := ttlmap.TryStore("key", "second"); err != nil {
+		t.Errorf("Expected store after delete to succeed, but got '%v'", err)
+	}
+}
+
+func TestWithSlidingTTL(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithSlidingTTL[string, string]())
+	ttlmap.Store("key", "value")
+
+	for i := 0; i < 4; i++ {
+		ttlmap.nextGeneration()
+		if _, ok := ttlmap.Load("key"); !ok {
+			t.Errorf("Expected to find loaded key at tick %d, but did not", i+1)
+		}
+	}
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected idle key to expire, but did not")
+	}
+}
+
+func TestWithKeyFunc(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute, WithKeyFunc[string, string](strings.ToLower))
+	ttlmap.Store("Key", "value")
+
+	if value, ok := ttlmap.Load("KEY"); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if _, ok = ttlmap.LoadOrStore("kEy", "other"); !ok {
+		t.Errorf("Expected value to be loaded, but was stored")
+	} else if value, _ = ttlmap.LoadAndDelete("KEY"); value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if _, ok = ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestWithShortTTL(t *testing.T) {
+	shrunk := New[string, string](100*time.Millisecond, time.Hour)
+	defer shrunk.Close()
+	rounded := New(100*time.Millisecond, time.Hour, WithShortTTL[string, string](ShortTTLRoundUp))
+	defer rounded.Close()
+
+	if shrunk.tickInterval() != 100*time.Millisecond || shrunk.ttlTicks.Load() != 1 {
+		t.Errorf("Expected interval to shrink to the ttl, but was %s", shrunk.tickInterval())
+	} else if rounded.tickInterval() != time.Hour || rounded.ttlTicks.Load() != 1 {
+		t.Errorf("Expected ttl to round up to the interval, but interval was %s", rounded.tickInterval())
+	}
+
+	rounded.Store("key", "value")
+	rounded.nextGeneration()
+	if _, ok := rounded.Load("key"); ok {
+		t.Errorf("Expected key to expire at the next tick, but did not")
+	}
+}
@@ -0,0 +1,54 @@
+package ttlmap
+
+import "sort"
+
+// WithOrderedExpiry expires the entries of a generation in the
+// order in which they were stored. By default the order is
+// unspecified. This matters when eviction callbacks produce
+// ordered side effects, like appending to an event log.
+//
+// Touching an entry keeps its position, storing a key again
+// moves it to the end. Ordering costs a sort of every
+// generation that is advanced.
+func WithOrderedExpiry[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.orderedExpiry = true
+	}
+}
+
+// sequence returns the next insertion sequence number, or 0
+// when the expiry of the map is not ordered.
+func (m *TTLMap[K, V]) sequence() uint64 {
+	if !m.orderedExpiry {
+		return 0
+	}
+	return m.seq.Add(1)
+}
+
+// order sorts keys in the insertion order of their entries.
+// Keys without an entry are sorted first, they are skipped by
+// the sweep.
+func (m *TTLMap[K, V]) order(keys []K) {
+	sorter := insertionOrder[K]{keys: keys, seqs: make([]uint64, len(keys))}
+	for i, key := range keys {
+		if e, ok := m.storage().Load(key); ok {
+			sorter.seqs[i] = e.seq
+		}
+	}
+	sort.Stable(sorter)
+}
+
+// insertionOrder implements sort.Interface, it sorts keys by
+// their sequence numbers.
+type insertionOrder[K comparable] struct {
+	keys []K
+	seqs []uint64
+}
+
+func (o insertionOrder[K]) Len() int           { return len(o.keys) }
+func (o insertionOrder[K]) Less(i, j int) bool { return o.seqs[i] < o.seqs[j] }
+
+func (o insertionOrder[K]) Swap(i, j int) {
+	o.keys[i], o.keys[j] = o.keys[j], o.keys[i]
+	o.seqs[i], o.seqs[j] = o.seqs[j], o.seqs[i]
+}
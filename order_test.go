@@ -0,0 +1,31 @@
+package ttlmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWithOrderedExpiry(t *testing.T) {
+	var order []string
+	ttlmap := New(time.Hour, time.Hour,
+		WithOrderedExpiry[string, string](),
+		WithExpirer[string, string](NewListExpirer[string]()),
+		WithOnEvict(func(key string, _ string, _ EvictionReason) {
+			order = append(order, key)
+		}))
+	for i := 0; i < 16; i++ {
+		ttlmap.Store(strconv.Itoa(i), "value")
+	}
+	ttlmap.nextGeneration()
+
+	if len(order) != 16 {
+		t.Errorf("Expected 16 expired keys, but got %d", len(order))
+		return
+	}
+	for i, key := range order {
+		if key != strconv.Itoa(i) {
+			t.Errorf("Expected key %d to expire at position %d, but found %s", i, i, key)
+		}
+	}
+}
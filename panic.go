@@ -0,0 +1,42 @@
+package ttlmap
+
+import "runtime/debug"
+
+// Err returns the last panic that was recovered in the
+// background work of the map as a *PanicError, or nil. The
+// callbacks of the map, like the functions of WithOnEvict and
+// WithOnExpireBatch, and the ticker are isolated: a panic is
+// recovered and logged as EventPanicked, see WithLogger, so
+// the map keeps expiring entries. Health checks can report a
+// map with a non-nil Err, as its callbacks missed entries.
+//
+// In strict mode panics are not recovered, so violations of
+// invariants fail tests, see WithStrict.
+func (m *TTLMap[K, V]) Err() error {
+	if err := m.panicked.Load(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// safely calls f, and recovers a panic of f.
+func (m *TTLMap[K, V]) safely(f func()) {
+	defer m.recoverPanic()
+	f()
+}
+
+// recoverPanic recovers a panic, and records and logs it. In
+// strict mode the panic continues. It must be deferred.
+func (m *TTLMap[K, V]) recoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	m.panicked.Store(&PanicError{Value: r, Stack: debug.Stack()})
+	if m.logger != nil {
+		m.log(Event{Kind: EventPanicked, Panic: r})
+	}
+	if m.strict {
+		panic(r)
+	}
+}
@@ -0,0 +1,49 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErr(t *testing.T) {
+	ttlmap := NewManual(time.Second, time.Second,
+		WithOnEvict(func(key int, value int, reason EvictionReason) {
+			if key == 1 {
+				panic("evict")
+			}
+		}))
+	defer ttlmap.Close()
+	if err := ttlmap.Err(); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	ttlmap.Store(1, 1)
+	ttlmap.Advance(1)
+	ttlmap.Store(2, 2)
+	ttlmap.Advance(1)
+
+	var panicErr *PanicError
+	if err := ttlmap.Err(); !errors.As(err, &panicErr) || panicErr.Value != "evict" {
+		t.Errorf("Expected a PanicError, but got %v", err)
+	} else if len(panicErr.Stack) == 0 {
+		t.Errorf("Expected the stack of the panic, but got none")
+	} else if ttlmap.Len() != 0 {
+		t.Errorf("Expected the map to keep expiring, but has %d entries", ttlmap.Len())
+	}
+}
+
+func TestErrTicker(t *testing.T) {
+	ttlmap := New(time.Millisecond, time.Millisecond,
+		WithOnExpireBatch(func(batch []ExpiredEntry[int, int]) { panic("batch") }))
+	defer ttlmap.Close()
+	ttlmap.Store(1, 1)
+
+	if !waitFor(func() bool { return ttlmap.Err() != nil }) {
+		t.Errorf("Expected the panic to be recovered, but it was not")
+	}
+	ttlmap.Store(2, 2)
+	if !waitFor(func() bool { return ttlmap.Len() == 0 }) {
+		t.Errorf("Expected the ticker to keep expiring, but has %d entries", ttlmap.Len())
+	}
+}
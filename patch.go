@@ -0,0 +1,94 @@
+package ttlmap
+
+import "time"
+
+// ExpiringValue is a value with the time at which it expires,
+// see SnapshotWithExpiry.
+type ExpiringValue[V any] struct {
+	Value     V
+	ExpiresAt time.Time
+}
+
+// SnapshotWithExpiry returns a copy of the contents of the
+// map, with the time at which every entry expires. See Keys
+// for its consistency, and ExpiresAt for the precision of the
+// times.
+func (m *TTLMap[K, V]) SnapshotWithExpiry() map[K]ExpiringValue[V] {
+	snapshot := make(map[K]ExpiringValue[V], m.Len())
+	m.RangeWithExpiry(func(key K, value V, expiresAt time.Time) bool {
+		snapshot[key] = ExpiringValue[V]{Value: value, ExpiresAt: expiresAt}
+		return true
+	})
+	return snapshot
+}
+
+// PatchEntry is an entry that was added or updated, see
+// Patch.
+type PatchEntry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiresAt time.Time
+}
+
+// Patch contains the changes between two snapshots, see Diff.
+// Its fields are exported, so it can be encoded with
+// encoding/gob or encoding/json and sent to replicas.
+type Patch[K comparable, V any] struct {
+	// Stores are the entries that were added, or whose value
+	// or expiry changed.
+	Stores []PatchEntry[K, V]
+	// Deletes are the keys that were removed.
+	Deletes []K
+}
+
+// Empty reports whether the patch contains no changes.
+func (p Patch[K, V]) Empty() bool {
+	return len(p.Stores) == 0 && len(p.Deletes) == 0
+}
+
+// Diff returns the patch that turns the old snapshot into the
+// new snapshot, see SnapshotWithExpiry. Entries that expired
+// are removed from a map regardless of patches, so replicas
+// can sync incrementally from periodic snapshots. Use DiffFunc
+// for values that are not comparable.
+func Diff[K comparable, V comparable](old, new map[K]ExpiringValue[V]) Patch[K, V] {
+	return DiffFunc(old, new, func(a, b V) bool {
+		return a == b
+	})
+}
+
+// DiffFunc is like Diff, but compares values with equal.
+func DiffFunc[K comparable, V any](old, new map[K]ExpiringValue[V], equal func(a, b V) bool) Patch[K, V] {
+	var patch Patch[K, V]
+	for key, value := range new {
+		if prev, ok := old[key]; ok && prev.ExpiresAt.Equal(value.ExpiresAt) && equal(prev.Value, value.Value) {
+			continue
+		}
+		patch.Stores = append(patch.Stores, PatchEntry[K, V]{Key: key, Value: value.Value, ExpiresAt: value.ExpiresAt})
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			patch.Deletes = append(patch.Deletes, key)
+		}
+	}
+	return patch
+}
+
+// ApplyPatch applies a patch created by Diff to the map. The
+// stored entries expire at the time recorded in the patch,
+// like StoreUntil, entries that expired already are deleted.
+// Stores and deletes are applied one by one, readers might
+// observe a partially applied patch.
+func (m *TTLMap[K, V]) ApplyPatch(patch Patch[K, V]) {
+	now := m.clock.Now()
+	for _, e := range patch.Stores {
+		if e.ExpiresAt.After(now) {
+			m.restoreStore(e.Key, e.Value, e.ExpiresAt)
+		} else {
+			m.restoreDelete(e.Key)
+		}
+	}
+	for _, key := range patch.Deletes {
+		m.restoreDelete(key)
+	}
+}
@@ -0,0 +1,38 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffApplyPatch(t *testing.T) {
+	source := New[string, string](2*time.Hour, time.Hour)
+	defer source.Close()
+	replica := New[string, string](2*time.Hour, time.Hour)
+	defer replica.Close()
+
+	source.Store("key1", "value1")
+	source.Store("key2", "value2")
+	first := source.SnapshotWithExpiry()
+	replica.ApplyPatch(Diff(nil, first))
+
+	source.Store("key2", "updated")
+	source.Delete("key1")
+	source.Store("key3", "value3")
+	patch := Diff(first, source.SnapshotWithExpiry())
+	replica.ApplyPatch(patch)
+
+	if len(patch.Stores) != 2 || len(patch.Deletes) != 1 {
+		t.Errorf("Expected 2 stores and 1 delete, but got %d and %d", len(patch.Stores), len(patch.Deletes))
+	} else if _, ok := replica.Load("key1"); ok {
+		t.Errorf("Expected key1 to be deleted, but it is present")
+	} else if value, _ := replica.Load("key2"); value != "updated" {
+		t.Errorf("Expected key2 to be 'updated', but was '%s'", value)
+	} else if value, _ := replica.Load("key3"); value != "value3" {
+		t.Errorf("Expected key3 to be 'value3', but was '%s'", value)
+	}
+
+	if patch := Diff(first, first); !patch.Empty() {
+		t.Errorf("Expected an empty patch, but got %v", patch)
+	}
+}
@@ -0,0 +1,91 @@
+package ttlmap
+
+import (
+	"sync"
+	"time"
+)
+
+// WithMissPenalty protects a backend from clients that spin on
+// keys that don't exist. When a key misses misses times within
+// window, it is penalized for d: Load doesn't fall through to
+// the backend of WithBackend, and LoadOrCompute returns
+// ErrThrottled instead of calling its loader. Keys that are
+// stored are loaded as usual, the penalty only applies to
+// misses.
+//
+// The durations are rounded down to a multiple of the interval,
+// with a minimum of one interval. The misses are counted per
+// key under a lock, so it adds some cost to every miss.
+func WithMissPenalty[K comparable, V any](d time.Duration, misses int, window time.Duration) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.penalty = &missPenalty[K]{d: d, window: window, misses: max(misses, 1)}
+	}
+}
+
+// missPenalty counts the misses of keys, see WithMissPenalty.
+type missPenalty[K comparable] struct {
+	d, window time.Duration
+	misses    int
+
+	// mu guards keys and prune. Records are pruned once per
+	// window, at tick prune.
+	mu    sync.Mutex
+	keys  map[K]*missRecord
+	prune uint64
+}
+
+// missRecord is the record of the misses of a key.
+type missRecord struct {
+	// misses is the number of misses since tick since.
+	misses int
+	since  uint64
+	// until is the tick until which the key is penalized.
+	until uint64
+}
+
+// penalized reports whether key is penalized at the current
+// tick.
+func (m *TTLMap[K, V]) penalized(key K) bool {
+	p := m.penalty
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.keys[key]
+	return ok && r.until > m.tick.Load()
+}
+
+// missed counts a miss of key, and penalizes it when it missed
+// too often.
+func (m *TTLMap[K, V]) missed(key K) {
+	p := m.penalty
+	tick := m.tick.Load()
+	window, d := m.atLeastOneTick(p.window), m.atLeastOneTick(p.d)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys == nil {
+		p.keys = make(map[K]*missRecord)
+	} else if tick >= p.prune {
+		for k, r := range p.keys {
+			if r.until <= tick && r.since+window <= tick {
+				delete(p.keys, k)
+			}
+		}
+		p.prune = tick + window
+	}
+
+	r := p.keys[key]
+	if r != nil && r.until > tick {
+		return
+	} else if r == nil || r.since+window <= tick {
+		r = &missRecord{since: tick}
+		p.keys[key] = r
+	}
+	if r.misses++; r.misses >= p.misses {
+		r.misses, r.since, r.until = 0, tick, tick+d
+	}
+}
+
+// atLeastOneTick returns d in ticks, with a minimum of one.
+func (m *TTLMap[K, V]) atLeastOneTick(d time.Duration) uint64 {
+	return max(uint64(d/m.tickInterval()), 1)
+}
@@ -0,0 +1,60 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithMissPenalty(t *testing.T) {
+	backend := newMemoryBackend()
+	ttlmap := NewManual(time.Hour, time.Second,
+		WithBackend[string, string](backend, BackendOptions{}),
+		WithMissPenalty[string, string](10*time.Second, 3, time.Minute))
+	defer ttlmap.Close()
+
+	for range 5 {
+		ttlmap.Load("missing")
+	}
+	if backend.gets != 3 {
+		t.Errorf("Expected penalized key not to fall through to the backend, but got %d gets", backend.gets)
+	}
+
+	ttlmap.Advance(10)
+	if ttlmap.Load("missing"); backend.gets != 4 {
+		t.Errorf("Expected penalty to end, but got %d gets", backend.gets)
+	}
+
+	ttlmap.Load("other")
+	ttlmap.Load("other")
+	ttlmap.Advance(60)
+	if ttlmap.Load("other"); ttlmap.penalized("other") {
+		t.Errorf("Expected misses outside the window not to penalize the key, but it was penalized")
+	}
+
+	for range 3 {
+		ttlmap.Load("stored")
+	}
+	ttlmap.Store("stored", "value")
+	if value, ok := ttlmap.Load("stored"); !ok || value != "value" {
+		t.Errorf("Expected stored key to be loaded while penalized, but got '%s'", value)
+	}
+}
+
+func TestWithMissPenaltyLoadOrCompute(t *testing.T) {
+	ttlmap := NewManual(time.Hour, time.Second, WithMissPenalty[string, string](time.Minute, 2, time.Minute))
+	defer ttlmap.Close()
+
+	calls := 0
+	loader := func(key string) (string, error) {
+		calls++
+		return "", ErrNotFound
+	}
+	ttlmap.LoadOrCompute("missing", loader)
+	ttlmap.LoadOrCompute("missing", loader)
+	if _, err := ttlmap.LoadOrCompute("missing", loader); !errors.Is(err, ErrThrottled) {
+		t.Errorf("Expected ErrThrottled, but got '%v'", err)
+	} else if calls != 2 {
+		t.Errorf("Expected 2 loader calls, but got %d", calls)
+	}
+}
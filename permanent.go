@@ -0,0 +1,353 @@
+package ttlmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PermanentMap is an Interface whose entries never expire. It
+// is useful to rule out expiration in tests and benchmarks,
+// or to roll out a TTL behind a feature flag.
+type PermanentMap[K comparable, V any] struct {
+	// items points to the sync.Map that stores *Entry[K, V]
+	// values. It is a pointer so ReplaceAll can swap in a new
+	// map.
+	items  atomic.Pointer[sync.Map]
+	frozen atomic.Bool
+}
+
+var _ Interface[string, string] = (*PermanentMap[string, string])(nil)
+
+// NewPermanentMap creates a new PermanentMap.
+func NewPermanentMap[K comparable, V any]() *PermanentMap[K, V] {
+	m := &PermanentMap[K, V]{}
+	m.items.Store(&sync.Map{})
+	return m
+}
+
+// Load returns the value stored in the map for a key. The ok
+// result indicates whether value was found in the map.
+func (m *PermanentMap[K, V]) Load(key K) (V, bool) {
+	val, ok := m.items.Load().Load(key)
+	if !ok {
+		return *new(V), false
+	}
+	return val.(*Entry[K, V]).Value, true
+}
+
+// LoadWithMinTTL is the same as Load, entries never expire.
+func (m *PermanentMap[K, V]) LoadWithMinTTL(key K, _ time.Duration) (V, bool) {
+	return m.Load(key)
+}
+
+// LoadAndTouch is the same as Load, entries have no TTL.
+func (m *PermanentMap[K, V]) LoadAndTouch(key K) (V, bool) {
+	return m.Load(key)
+}
+
+// Touch reports whether key was found in the map.
+func (m *PermanentMap[K, V]) Touch(key K) bool {
+	_, ok := m.items.Load().Load(key)
+	return ok
+}
+
+// Promote reports whether key was found in the map.
+func (m *PermanentMap[K, V]) Promote(key K, _ time.Duration) bool {
+	return m.Touch(key)
+}
+
+// Extend reports whether key was found in the map.
+func (m *PermanentMap[K, V]) Extend(key K, _ time.Duration) bool {
+	return m.Touch(key)
+}
+
+// TouchMany returns the number of keys that were found in the
+// map.
+func (m *PermanentMap[K, V]) TouchMany(keys []K) int {
+	found := 0
+	for _, key := range keys {
+		if _, ok := m.items.Load().Load(key); ok {
+			found++
+		}
+	}
+	return found
+}
+
+// GetEntry returns the entry stored in the map for a key,
+// including its metadata.
+func (m *PermanentMap[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	val, ok := m.items.Load().Load(key)
+	if !ok {
+		return Entry[K, V]{}, false
+	}
+	return *val.(*Entry[K, V]), true
+}
+
+// Store sets the value for a key. It is a no-op while the map
+// is frozen.
+func (m *PermanentMap[K, V]) Store(key K, value V) {
+	_ = m.TryStore(key, value)
+}
+
+// StoreWithMeta sets the value for a key and attaches meta to
+// it. It is a no-op while the map is frozen.
+func (m *PermanentMap[K, V]) StoreWithMeta(key K, value V, meta any) {
+	if !m.Frozen() {
+		m.items.Load().Store(key, &Entry[K, V]{Key: key, Value: value, Meta: meta})
+	}
+}
+
+// StoreWithTTL sets the value for a key, the ttl is ignored.
+// It is a no-op while the map is frozen.
+func (m *PermanentMap[K, V]) StoreWithTTL(key K, value V, _ time.Duration) {
+	_ = m.TryStore(key, value)
+}
+
+// TryStore sets the value for a key. It returns ErrFrozen
+// while the map is frozen.
+func (m *PermanentMap[K, V]) TryStore(key K, value V) error {
+	if m.Frozen() {
+		return ErrFrozen
+	}
+
+	m.items.Load().Store(key, &Entry[K, V]{Key: key, Value: value})
+	return nil
+}
+
+// Add stores the value for a key only if the key is not
+// present yet. It returns ErrExists when the key is already
+// present, and ErrFrozen while the map is frozen.
+func (m *PermanentMap[K, V]) Add(key K, value V) error {
+	if m.Frozen() {
+		return ErrFrozen
+	} else if _, loaded := m.LoadOrStore(key, value); loaded {
+		return ErrExists
+	}
+	return nil
+}
+
+// LoadOrStore returns the existing value for the key if
+// present. Otherwise, it stores and returns the given value.
+// While the map is frozen it only loads.
+func (m *PermanentMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if m.Frozen() {
+		return m.Load(key)
+	}
+
+	val, loaded := m.items.Load().LoadOrStore(key, &Entry[K, V]{Key: key, Value: value})
+	return val.(*Entry[K, V]).Value, loaded
+}
+
+// LoadOrCompute returns the value stored in the map for a key,
+// or loads and stores it with loader when the key is missing.
+// Errors of loader are wrapped in a *LoaderError. Concurrent
+// misses are not deduplicated.
+func (m *PermanentMap[K, V]) LoadOrCompute(key K, loader func(key K) (V, error)) (V, error) {
+	if value, ok := m.Load(key); ok {
+		return value, nil
+	}
+
+	value, err := loader(key)
+	if err != nil {
+		return *new(V), &LoaderError{Key: key, Err: err}
+	}
+	_ = m.TryStore(key, value)
+	return value, nil
+}
+
+// CompareAndSwap swaps the old and new values for key if the
+// value stored in the map is equal to old. The old value must
+// be of a comparable type.
+func (m *PermanentMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	if m.Frozen() {
+		return false
+	}
+
+	for {
+		val, ok := m.items.Load().Load(key)
+		if !ok || any(val.(*Entry[K, V]).Value) != any(old) {
+			return false
+		}
+
+		e := *val.(*Entry[K, V])
+		e.Value = new
+		if m.items.Load().CompareAndSwap(key, val, &e) {
+			return true
+		}
+	}
+}
+
+// Swap swaps the value for a key and returns the previous
+// value if any. While the map is frozen it returns the zero
+// value and false.
+func (m *PermanentMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	if m.Frozen() {
+		return *new(V), false
+	}
+
+	val, loaded := m.items.Load().Swap(key, &Entry[K, V]{Key: key, Value: value})
+	if !loaded {
+		return *new(V), false
+	}
+	return val.(*Entry[K, V]).Value, true
+}
+
+// CompareAndDelete deletes the entry for key if its value is
+// equal to old. The old value must be of a comparable type.
+func (m *PermanentMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if m.Frozen() {
+		return false
+	}
+
+	for {
+		val, ok := m.items.Load().Load(key)
+		if !ok || any(val.(*Entry[K, V]).Value) != any(old) {
+			return false
+		} else if m.items.Load().CompareAndDelete(key, val) {
+			return true
+		}
+	}
+}
+
+// Compute atomically replaces the value for key with the
+// result of fn, see TTLMap.Compute.
+func (m *PermanentMap[K, V]) Compute(key K, fn func(old V, exists bool) (new V, delete bool)) (V, bool) {
+	if m.Frozen() {
+		return *new(V), false
+	}
+
+	items := m.items.Load()
+	for {
+		val, ok := items.Load(key)
+		var old V
+		if ok {
+			old = val.(*Entry[K, V]).Value
+		}
+
+		value, del := fn(old, ok)
+		switch {
+		case del && !ok:
+			return *new(V), false
+		case del:
+			if items.CompareAndDelete(key, val) {
+				return *new(V), false
+			}
+		case !ok:
+			if _, loaded := items.LoadOrStore(key, &Entry[K, V]{Key: key, Value: value}); !loaded {
+				return value, true
+			}
+		default:
+			e := *val.(*Entry[K, V])
+			e.Value = value
+			if items.CompareAndSwap(key, val, &e) {
+				return value, true
+			}
+		}
+	}
+}
+
+// Delete deletes the value for a key. It is a no-op while the
+// map is frozen.
+func (m *PermanentMap[K, V]) Delete(key K) {
+	_ = m.TryDelete(key)
+}
+
+// TryDelete deletes the value for a key. It returns ErrFrozen
+// while the map is frozen.
+func (m *PermanentMap[K, V]) TryDelete(key K) error {
+	if m.Frozen() {
+		return ErrFrozen
+	}
+
+	m.items.Load().Delete(key)
+	return nil
+}
+
+// DeleteFunc deletes every entry for which pred returns true,
+// and returns the number of deleted entries. It is a no-op
+// while the map is frozen.
+func (m *PermanentMap[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	if m.Frozen() {
+		return 0
+	}
+
+	deleted := 0
+	items := m.items.Load()
+	items.Range(func(key, val any) bool {
+		if e := val.(*Entry[K, V]); pred(e.Key, e.Value) && items.CompareAndDelete(key, e) {
+			deleted++
+		}
+		return true
+	})
+	return deleted
+}
+
+// LoadAndDelete deletes the value for a key, returning the
+// previous value if any. While the map is frozen it only
+// loads.
+func (m *PermanentMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	if m.Frozen() {
+		return m.Load(key)
+	}
+
+	val, ok := m.items.Load().LoadAndDelete(key)
+	if !ok {
+		return *new(V), false
+	}
+	return val.(*Entry[K, V]).Value, true
+}
+
+// Clear atomically deletes all entries from the map. It is a
+// no-op while the map is frozen.
+func (m *PermanentMap[K, V]) Clear() {
+	if m.Frozen() {
+		return
+	}
+	m.items.Store(&sync.Map{})
+}
+
+// ReplaceAll atomically replaces the contents of the map with
+// entries. It is a no-op while the map is frozen.
+func (m *PermanentMap[K, V]) ReplaceAll(entries map[K]V) {
+	if m.Frozen() {
+		return
+	}
+
+	items := &sync.Map{}
+	for key, value := range entries {
+		items.Store(key, &Entry[K, V]{Key: key, Value: value})
+	}
+	m.items.Store(items)
+}
+
+// Range calls f sequentially for each key and value present
+// in the map. If f returns false, range stops the iteration.
+func (m *PermanentMap[K, V]) Range(f func(key K, value V) bool) {
+	m.items.Load().Range(func(_, val any) bool {
+		e := val.(*Entry[K, V])
+		return f(e.Key, e.Value)
+	})
+}
+
+// Freeze makes the map read-only, see TTLMap.Freeze. Entries
+// never expire, so pauseExpiration has no effect.
+func (m *PermanentMap[K, V]) Freeze(bool) {
+	m.frozen.Store(true)
+}
+
+// Unfreeze makes the map writable again.
+func (m *PermanentMap[K, V]) Unfreeze() {
+	m.frozen.Store(false)
+}
+
+// Frozen reports whether the map is frozen.
+func (m *PermanentMap[K, V]) Frozen() bool {
+	return m.frozen.Load()
+}
+
+// AdvanceTo is a no-op, entries never expire.
+func (m *PermanentMap[K, V]) AdvanceTo(time.Time) {}
+
+// Close is a no-op.
+func (m *PermanentMap[K, V]) Close() {}
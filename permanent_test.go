@@ -0,0 +1,25 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPermanentMap(t *testing.T) {
+	var m Interface[string, string] = NewPermanentMap[string, string]()
+	m.Store("key", "value")
+	m.AdvanceTo(time.Now().Add(time.Hour))
+
+	if value, ok := m.Load("key"); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	} else if err := m.Add("key", "other"); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, but got '%v'", err)
+	} else if !m.CompareAndSwap("key", "value", "other") {
+		t.Errorf("Expected swap to succeed, but it failed")
+	} else if value, _ = m.LoadAndDelete("key"); value != "other" {
+		t.Errorf("Expected value to be 'other', but was '%s'", value)
+	} else if _, ok = m.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
@@ -0,0 +1,240 @@
+package ttlmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WithPersistence restores the entries of the map from the
+// file at path when it is created, and writes them back to it
+// every interval, and when it is closed. The file is written
+// with Save to a temporary file which is renamed into place,
+// so a crash never leaves a partial file. Entries expire at the
+// time they would have expired without the restart.
+//
+// Maps created with NewManual write the file only on Close and
+// Persist. Errors are reported by PersistenceError, a file
+// that can't be restored is replaced by the next write.
+//
+// Experimental: the file is written with Save, its format may
+// change in a minor version.
+func WithPersistence[K comparable, V any](path string, every time.Duration) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.persistence = &persistence[K, V]{path: path, every: every}
+	}
+}
+
+// WithWriteAheadLog appends every store and delete to a log
+// next to the file of WithPersistence, which is replayed on
+// restore. This limits the data lost in a crash to the last
+// operations, instead of everything since the last write of
+// the file. The log is reset every time the file is written.
+// Other changes, like touches and ReplaceAll, are persisted by
+// the next write of the file.
+//
+// Experimental: the format of the log may change in a minor
+// version.
+func WithWriteAheadLog[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.writeAheadLog = true
+	}
+}
+
+// persistence persists a map to a file, see WithPersistence.
+type persistence[K comparable, V any] struct {
+	path   string
+	every  time.Duration
+	ticker Ticker
+
+	// mu guards log, enc, err and closed.
+	mu     sync.Mutex
+	log    *os.File
+	enc    *gob.Encoder
+	err    error
+	closed bool
+}
+
+// logRecord is an operation in the write-ahead log.
+type logRecord[K comparable, V any] struct {
+	Delete    bool
+	Key       K
+	Value     V
+	ExpiresAt time.Time
+}
+
+// Persist writes the entries of the map to the file of
+// WithPersistence, and resets the write-ahead log. It is a
+// no-op without WithPersistence.
+func (m *TTLMap[K, V]) Persist() error {
+	p := m.persistence
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.err = m.persist()
+	return p.err
+}
+
+// PersistenceError returns the error of the last write of the
+// file of WithPersistence, or of restoring it.
+func (m *TTLMap[K, V]) PersistenceError() error {
+	p := m.persistence
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// persist writes the file and resets the log. The caller must
+// hold the lock of the persistence. Operations that are logged
+// meanwhile wait for the lock, so they are in the file or in
+// the new log.
+func (m *TTLMap[K, V]) persist() error {
+	p := m.persistence
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		return err
+	} else if err = writeFileAtomic(filepath.Dir(p.path), filepath.Base(p.path), buf.Bytes()); err != nil {
+		return err
+	} else if !m.writeAheadLog {
+		return nil
+	}
+
+	if p.log != nil {
+		_ = p.log.Close()
+	}
+	log, err := os.Create(p.path + ".log")
+	if err != nil {
+		p.log, p.enc = nil, nil
+		return err
+	}
+	p.log, p.enc = log, gob.NewEncoder(log)
+	return nil
+}
+
+// restorePersisted restores the file and the log of
+// WithPersistence, and writes them again, so the log is reset.
+func (m *TTLMap[K, V]) restorePersisted() {
+	p := m.persistence
+	var errs []error
+	if f, err := os.Open(p.path); err == nil {
+		errs = append(errs, m.Restore(f))
+		f.Close()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	if m.writeAheadLog {
+		errs = append(errs, m.replay(p.path+".log"))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	errs = append(errs, m.persist())
+	p.err = errors.Join(errs...)
+}
+
+// replay applies the operations in the log at path. A record
+// that was written partially by a crash ends the log.
+func (m *TTLMap[K, V]) replay(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	for {
+		var r logRecord[K, V]
+		if err := dec.Decode(&r); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if r.Delete {
+			m.restoreDelete(r.Key)
+		} else if r.ExpiresAt.After(m.clock.Now()) {
+			m.restoreStore(r.Key, r.Value, r.ExpiresAt)
+		}
+	}
+}
+
+// restoreStore stores a restored value for key, which expires
+// at expiresAt. Unlike StoreUntil, the value is not written
+// through to the backend, published to peers or appended to
+// the write-ahead log, since it is no new change.
+func (m *TTLMap[K, V]) restoreStore(key K, value V, expiresAt time.Time) {
+	ttl := expiresAt.Sub(m.clock.Now())
+	ticks := uint64(ttl / m.tickInterval())
+	if ttl < m.tickInterval() {
+		ticks = 1
+	}
+	e := &entry[V]{value: value, restored: true}
+	e.ttl.Store(ticks)
+	_, _ = m.storeDeferred(OpStore, key, e, nil)
+}
+
+// restoreDelete deletes key for a restore, see restoreStore.
+// It is a no-op while the map is frozen, like Delete.
+func (m *TTLMap[K, V]) restoreDelete(key K) {
+	if !m.Frozen() {
+		m.deleteKey(key, true)
+	}
+}
+
+// logged appends an operation to the write-ahead log.
+func (m *TTLMap[K, V]) logged(r logRecord[K, V]) {
+	p := m.persistence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.enc == nil {
+		return
+	}
+	if err := p.enc.Encode(r); err != nil {
+		p.err = err
+	}
+}
+
+// startPersistence starts writing the file every interval.
+func (m *TTLMap[K, V]) startPersistence() {
+	p := m.persistence
+	p.ticker = m.clock.Ticker(p.every, func() {
+		m.labeled(func() {
+			_ = m.Persist()
+		})
+	})
+}
+
+// closePersistence writes the file a last time, and stops
+// logging, before the entries are cleared by Close.
+func (m *TTLMap[K, V]) closePersistence() {
+	p := m.persistence
+	if p.ticker != nil {
+		p.ticker.Stop()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = m.persist()
+	if p.log != nil {
+		_ = p.log.Close()
+	}
+	p.log, p.enc = nil, nil
+	p.closed = true
+}
@@ -0,0 +1,157 @@
+package ttlmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	ttlmap := NewManual(time.Hour, time.Minute, WithPersistence[string, string](path, time.Minute))
+	if err := ttlmap.PersistenceError(); err != nil {
+		t.Fatalf("Expected no error for a missing file, but got '%v'", err)
+	}
+	ttlmap.Store("key1", "value1")
+	if err := ttlmap.Persist(); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	ttlmap.Store("key2", "value2")
+	ttlmap.Close()
+	if err := ttlmap.Persist(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, but got '%v'", err)
+	}
+
+	restored := NewManual(time.Hour, time.Minute, WithPersistence[string, string](path, time.Minute))
+	defer restored.Close()
+	if value, ok := restored.Load("key1"); !ok || value != "value1" {
+		t.Errorf("Expected value to be 'value1', but was '%s'", value)
+	} else if value, ok := restored.Load("key2"); !ok || value != "value2" {
+		t.Errorf("Expected value written by Close to be 'value2', but was '%s'", value)
+	}
+}
+
+func TestPersistenceInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	ttlmap := New(time.Hour, time.Minute, WithPersistence[string, string](path, 10*time.Millisecond))
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+
+	time.Sleep(50 * time.Millisecond)
+	other := NewManual[string, string](time.Hour, time.Minute)
+	defer other.Close()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected the file to be written, but got '%v'", err)
+	}
+	defer f.Close()
+	if err = other.Restore(f); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	} else if _, ok := other.Load("key"); !ok {
+		t.Errorf("Expected key to be persisted, but it was not")
+	}
+}
+
+func TestWriteAheadLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	opts := []Option[string, string]{WithPersistence[string, string](path, time.Minute), WithWriteAheadLog[string, string]()}
+	ttlmap := NewManual(time.Hour, time.Minute, opts...)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	if err := ttlmap.Persist(); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	ttlmap.Store("key3", "value3")
+	ttlmap.Delete("key1")
+
+	// Simulate a crash, the map is not closed.
+	restored := NewManual(time.Hour, time.Minute, opts...)
+	defer restored.Close()
+	if err := restored.PersistenceError(); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	} else if _, ok := restored.Load("key1"); ok {
+		t.Errorf("Expected logged delete to be replayed, but key1 was restored")
+	} else if value, ok := restored.Load("key3"); !ok || value != "value3" {
+		t.Errorf("Expected logged store to be replayed, but got '%s'", value)
+	} else if restored.Len() != 2 {
+		t.Errorf("Expected 2 entries, but had %d", restored.Len())
+	}
+}
+
+func TestWriteAheadLogReplaceAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	opts := []Option[string, string]{WithPersistence[string, string](path, time.Minute), WithWriteAheadLog[string, string]()}
+	ttlmap := NewManual(time.Hour, time.Minute, opts...)
+	ttlmap.Store("key1", "value1")
+	if err := ttlmap.Persist(); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	ttlmap.ReplaceAll(map[string]string{"key2": "value2"})
+
+	// Simulate a crash, the map is not closed.
+	restored := NewManual(time.Hour, time.Minute, opts...)
+	defer restored.Close()
+	if _, ok := restored.Load("key1"); ok {
+		t.Errorf("Expected the replaced key to be deleted, but key1 was restored")
+	} else if value, ok := restored.Load("key2"); !ok || value != "value2" {
+		t.Errorf("Expected the new key to be logged, but got '%s'", value)
+	}
+}
+
+func TestRestoreQuiet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	opts := []Option[string, string]{WithPersistence[string, string](path, time.Minute), WithWriteAheadLog[string, string]()}
+	ttlmap := NewManual(time.Hour, time.Minute, opts...)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	if err := ttlmap.Persist(); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	ttlmap.Delete("key2")
+	ttlmap.Close()
+
+	bus := &memoryBus{}
+	var published int
+	bus.Subscribe(func([]byte) { published++ })
+	backend := newMemoryBackend()
+	restored := NewManual(time.Hour, time.Minute, append(opts,
+		WithBackend[string, string](backend, BackendOptions{}),
+		WithInvalidator[string, string](NewBusInvalidator[string](bus)))...)
+	defer restored.Close()
+	if _, ok := restored.Load("key1"); !ok {
+		t.Errorf("Expected key1 to be restored, but it was not")
+	} else if _, ok = restored.Load("key2"); ok {
+		t.Errorf("Expected the logged delete to be replayed, but key2 was restored")
+	}
+
+	log, _ := os.Stat(path + ".log")
+	restored.ApplyPatch(Patch[string, string]{
+		Stores:  []PatchEntry[string, string]{{Key: "key3", Value: "value3", ExpiresAt: time.Now().Add(time.Hour)}},
+		Deletes: []string{"key1"},
+	})
+	if published != 0 {
+		t.Errorf("Expected restoring to publish no invalidations, but published %d", published)
+	} else if len(backend.values) != 0 {
+		t.Errorf("Expected restoring not to write through, but the backend has %v", backend.values)
+	} else if after, _ := os.Stat(path + ".log"); after.Size() != log.Size() {
+		t.Errorf("Expected restoring not to append to the log, but it grew from %d to %d bytes", log.Size(), after.Size())
+	} else if _, ok := restored.Load("key3"); !ok {
+		t.Errorf("Expected the patch to be applied, but key3 was not stored")
+	}
+}
+
+func TestPersistenceCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ttlmap := NewManual(time.Hour, time.Minute, WithPersistence[string, string](path, time.Minute))
+	defer ttlmap.Close()
+	if err := ttlmap.PersistenceError(); err == nil {
+		t.Errorf("Expected an error for a corrupt file, but got none")
+	} else if err = ttlmap.Persist(); err != nil {
+		t.Errorf("Expected the file to be replaced, but got '%v'", err)
+	}
+}
@@ -0,0 +1,94 @@
+package ttlmap
+
+// Op is an operation class with a configurable TTL policy.
+type Op int
+
+const (
+	// OpStore covers Store, StoreWithMeta and TryStore.
+	OpStore Op = iota
+	// OpLoadOrStore covers LoadOrStore when the value is
+	// loaded.
+	OpLoadOrStore
+	// OpCompareAndSwap covers a successful CompareAndSwap.
+	OpCompareAndSwap
+	// OpLoad covers a successful Load.
+	OpLoad
+	// OpSwap covers Swap.
+	OpSwap
+	// OpCompute covers a Compute that updates an existing
+	// entry.
+	OpCompute
+
+	opCount
+)
+
+// Policy determines what an operation does with the TTL of an
+// existing entry.
+type Policy int
+
+const (
+	// PolicyDefault uses the default policy of the operation.
+	PolicyDefault Policy = iota
+	// PolicyReset gives the entry the full TTL.
+	PolicyReset
+	// PolicyPreserve keeps the deadline of the entry.
+	PolicyPreserve
+	// PolicyExtend gives the entry the full TTL, unless its
+	// current deadline is later.
+	PolicyExtend
+)
+
+// defaultPolicies contains the default policy of each
+// operation class.
+var defaultPolicies = [opCount]Policy{
+	OpStore:          PolicyReset,
+	OpLoadOrStore:    PolicyPreserve,
+	OpCompareAndSwap: PolicyPreserve,
+	OpLoad:           PolicyPreserve,
+	OpSwap:           PolicyReset,
+	OpCompute:        PolicyPreserve,
+}
+
+// policyDeadline returns the deadline of entry e with the
+// given deadline after an operation of class op. Entries
+// without a deadline get the full TTL.
+func (m *TTLMap[K, V]) policyDeadline(op Op, e *entry[V], expires uint64) uint64 {
+	deadline := m.deadlineOf(e)
+	if expires == 0 {
+		return deadline
+	}
+
+	switch m.policies[op] {
+	case PolicyPreserve:
+		return expires
+	case PolicyExtend:
+		if expires > deadline {
+			return expires
+		}
+		return deadline
+	default:
+		return deadline
+	}
+}
+
+// refresh applies the policy of op to the deadline of an
+// existing entry. It returns false when the entry expired
+// concurrently.
+func (m *TTLMap[K, V]) refresh(op Op, key K, e *entry[V]) bool {
+	defer m.operation()()
+	for {
+		expires := e.expires.Load()
+		if expires == 0 {
+			return false
+		}
+
+		deadline := m.policyDeadline(op, e, expires)
+		if deadline == expires {
+			return true
+		} else if e.expires.CompareAndSwap(expires, deadline) {
+			m.stamp(e)
+			m.schedule(deadline, key)
+			return true
+		}
+	}
+}
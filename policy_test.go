@@ -0,0 +1,85 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyStorePreserve(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithTTLPolicy[string, string](OpStore, PolicyPreserve))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+	ttlmap.Store("key", "value2")
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestPolicySwapPreserve(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithTTLPolicy[string, string](OpSwap, PolicyPreserve))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+	if previous, loaded := ttlmap.Swap("key", "value2"); !loaded || previous != "value" {
+		t.Errorf("Expected to swap 'value', but got '%s'", previous)
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestPolicyComputeReset(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithTTLPolicy[string, string](OpCompute, PolicyReset))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+	ttlmap.Compute("key", func(old string, exists bool) (string, bool) { return old + "2", false })
+
+	ttlmap.nextGeneration()
+	if value, ok := ttlmap.Load("key"); !ok || value != "value2" {
+		t.Errorf("Expected to find 'value2' after compute, but got '%s'", value)
+	}
+}
+
+func TestPolicyLoadReset(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithTTLPolicy[string, string](OpLoad, PolicyReset))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key, but did not")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key after load, but did not")
+	}
+}
+
+func TestPolicyLoadOrStoreExtend(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithTTLPolicy[string, string](OpLoadOrStore, PolicyExtend))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if _, loaded := ttlmap.LoadOrStore("key", "value2"); !loaded {
+		t.Errorf("Expected to load key, but did not")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key after extend, but did not")
+	}
+}
+
+func TestPolicyDefault(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour,
+		WithTTLPolicy[string, string](OpLoad, PolicyReset),
+		WithTTLPolicy[string, string](OpLoad, PolicyDefault),
+	)
+
+	if policy := ttlmap.policies[OpLoad]; policy != PolicyPreserve {
+		t.Errorf("Expected policy to be PolicyPreserve, but was %d", policy)
+	}
+}
@@ -0,0 +1,51 @@
+package ttlmap
+
+import "time"
+
+// Quarantine hides the entries for which pred returns true for
+// d: loads miss them and Range skips them, but they are not
+// deleted. This mitigates incidents quickly, like to stop
+// serving the cached prices of a region, and is easy to roll
+// back with Unquarantine. It returns the number of quarantined
+// entries.
+//
+// Quarantine applies to the entries in the map when it is
+// called, a value that is stored for a key later is served.
+// Quarantined entries still expire.
+func (m *TTLMap[K, V]) Quarantine(pred func(key K, value V) bool, d time.Duration) int {
+	until := m.clock.Now().Add(d).UnixNano()
+	n := 0
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if pred(key, m.value(e)) {
+			e.quarantined.Store(until)
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Unquarantine lifts the quarantine of the entries for which
+// pred returns true, see Quarantine. It returns the number of
+// entries that were quarantined.
+func (m *TTLMap[K, V]) Unquarantine(pred func(key K, value V) bool) int {
+	now := m.clock.Now().UnixNano()
+	n := 0
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if e.quarantined.Load() > now && pred(key, m.value(e)) {
+			e.quarantined.Store(0)
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// hidden reports whether loads must miss e, because it lapsed
+// or is quarantined.
+func (m *TTLMap[K, V]) hidden(e *entry[V]) bool {
+	if until := e.quarantined.Load(); until != 0 && m.clock.Now().UnixNano() < until {
+		return true
+	}
+	return m.lapsed(e)
+}
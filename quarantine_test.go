@@ -0,0 +1,76 @@
+package ttlmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuarantine(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := NewManual(time.Hour, time.Minute, WithClock[string, string](clock))
+	defer ttlmap.Close()
+	ttlmap.Store("eu:1", "10")
+	ttlmap.Store("eu:2", "20")
+	ttlmap.Store("us:1", "30")
+
+	n := ttlmap.Quarantine(func(key, _ string) bool {
+		return strings.HasPrefix(key, "eu:")
+	}, time.Minute)
+	if n != 2 {
+		t.Errorf("Expected 2 quarantined entries, but got %d", n)
+	} else if _, ok := ttlmap.Load("eu:1"); ok {
+		t.Errorf("Expected quarantined key to miss, but it was loaded")
+	} else if _, ok := ttlmap.Load("us:1"); !ok {
+		t.Errorf("Expected other key to be loaded, but it was not")
+	} else if keys := ttlmap.Keys(); len(keys) != 1 {
+		t.Errorf("Expected Range to skip quarantined keys, but got %v", keys)
+	} else if ttlmap.Len() != 3 {
+		t.Errorf("Expected quarantined entries to be kept, but had %d entries", ttlmap.Len())
+	}
+
+	ttlmap.Store("eu:2", "21")
+	if value, ok := ttlmap.Load("eu:2"); !ok || value != "21" {
+		t.Errorf("Expected stored value to be served, but got '%s'", value)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if _, ok := ttlmap.Load("eu:1"); !ok {
+		t.Errorf("Expected key to be served after the quarantine, but it was not")
+	}
+}
+
+func TestQuarantineLoads(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := NewManual(time.Hour, time.Minute, WithClock[string, string](clock))
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+	ttlmap.Quarantine(func(string, string) bool { return true }, time.Minute)
+
+	if _, ok := ttlmap.GetEntry("key"); ok {
+		t.Errorf("Expected GetEntry to skip the quarantined key, but it was found")
+	} else if _, _, ok := ttlmap.LoadStale("key"); ok {
+		t.Errorf("Expected LoadStale to skip the quarantined key, but it was found")
+	} else if value, _ := ttlmap.LoadOrCompute("key", func(string) (string, error) { return "computed", nil }); value != "computed" {
+		t.Errorf("Expected the quarantined key to be computed, but got '%s'", value)
+	}
+}
+
+func TestUnquarantine(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+	ttlmap.Store("other", "value")
+
+	all := func(string, string) bool { return true }
+	ttlmap.Quarantine(all, time.Hour)
+	if n := ttlmap.Unquarantine(func(key, _ string) bool { return key == "key" }); n != 1 {
+		t.Errorf("Expected 1 entry to be released, but got %d", n)
+	} else if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected released key to be loaded, but it was not")
+	} else if _, ok := ttlmap.Load("other"); ok {
+		t.Errorf("Expected other key to stay quarantined, but it was loaded")
+	} else if n := ttlmap.Unquarantine(all); n != 1 {
+		t.Errorf("Expected 1 entry to be released, but got %d", n)
+	}
+}
@@ -0,0 +1,111 @@
+package ttlmap
+
+import "unsafe"
+
+// mapEntryOverhead is the approximate overhead of an entry in
+// a Go map or sync.Map, on top of its key and value.
+const mapEntryOverhead = 16
+
+// ResourceReport describes the resources held by a map, see
+// TTLMap.ResourceReport.
+type ResourceReport struct {
+	// Goroutines is the number of goroutines owned by the
+	// map, like its ticker and a background trim.
+	Goroutines int
+	// Timers is the number of active timers owned by the map.
+	// Maps advanced by a parent or a Scheduler own none.
+	Timers int
+
+	// EntryBytes is the approximate memory of the stored
+	// entries. Memory referenced by keys and values, like the
+	// bytes of a string, is only included when the map is
+	// created with WithMaxBytes.
+	EntryBytes int64
+	// ExpirerBytes is the approximate memory of the built-in
+	// expiry engines, it is 0 for custom engines.
+	ExpirerBytes int64
+	// IndexBytes is the approximate memory of the deadline
+	// index, see WithDeadlineIndex.
+	IndexBytes int64
+}
+
+// HeapBytes returns the approximate memory of the map.
+func (r ResourceReport) HeapBytes() int64 {
+	return r.EntryBytes + r.ExpirerBytes + r.IndexBytes
+}
+
+// ResourceReport returns the resources held by the map, which
+// allows auditing the cost of every cache in a large service.
+// Memory is estimated from the number of entries and the sizes
+// of the internal structures, it is not measured.
+func (m *TTLMap[K, V]) ResourceReport() ResourceReport {
+	var report ResourceReport
+	m.advanceMu.Lock()
+	if m.ticker != nil && !m.closed.Load() {
+		report.Goroutines++
+		report.Timers++
+	}
+	m.advanceMu.Unlock()
+	if m.trimming.Load() {
+		report.Goroutines++
+	}
+	if m.workers != nil && !m.closed.Load() {
+		report.Goroutines += m.workers.size
+	}
+
+	keySize := int64(unsafe.Sizeof(*new(K)))
+	entrySize := int64(unsafe.Sizeof(entry[V]{}))
+	report.EntryBytes = m.count.Load()*(keySize+entrySize+mapEntryOverhead) + m.bytes.Load()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report.ExpirerBytes = expirerBytes(m.expirer)
+	if m.index != nil {
+		report.IndexBytes = int64(len(m.index.deadlines)) * (2*keySize + 8 + 2*mapEntryOverhead)
+		report.IndexBytes += int64(len(m.index.buckets)) * (8 + 8 + 2*mapEntryOverhead)
+	}
+	return report
+}
+
+// MemoryUsage returns the approximate memory of the map in
+// bytes, which is the HeapBytes of its ResourceReport. It
+// accounts for the entries, the generations of the expiry
+// engine and the deadline index, and for the memory referenced
+// by keys and values when the map is created with
+// WithMaxBytes. Use it to plan the capacity of many maps, like
+// a map per tenant.
+func (m *TTLMap[K, V]) MemoryUsage() int64 {
+	return m.ResourceReport().HeapBytes()
+}
+
+// expirerBytes returns the approximate memory of a built-in
+// Expirer, or 0 for other expirers.
+func expirerBytes[K comparable](expirer Expirer[K]) int64 {
+	keySize := int64(unsafe.Sizeof(*new(K)))
+	var n int64
+	switch e := expirer.(type) {
+	case *GenerationExpirer[K]:
+		n = int64(len(e.generations)) * int64(unsafe.Sizeof([]K{}))
+		for _, gen := range e.generations {
+			n += int64(cap(gen)) * keySize
+		}
+		for _, keys := range e.free {
+			n += int64(cap(keys)) * keySize
+		}
+		for _, round := range e.rounds {
+			n += 8 + int64(cap(round))*int64(unsafe.Sizeof(roundKey[K]{})) + mapEntryOverhead
+		}
+		n += int64(len(e.index)) * (keySize + int64(unsafe.Sizeof(generationPos{})) + mapEntryOverhead)
+	case *MultiResolutionExpirer[K]:
+		for _, r := range e.rings {
+			n += expirerBytes[K](r.GenerationExpirer)
+		}
+	case *ListExpirer[K]:
+		n = int64(len(e.nodes)) * (keySize + 8 + int64(unsafe.Sizeof(listNode[K]{})) + mapEntryOverhead)
+		n += int64(len(e.buckets)) * (8 + 8 + mapEntryOverhead)
+	case *HeapExpirer[K]:
+		n = int64(cap(e.items)) * 8
+		n += int64(len(e.index)) * (keySize + 8 + int64(unsafe.Sizeof(heapItem[K]{})) + mapEntryOverhead)
+	}
+	return n
+}
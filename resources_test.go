@@ -0,0 +1,43 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResourceReport(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	empty := ttlmap.ResourceReport()
+	ttlmap.Store("key", "value")
+	report := ttlmap.ResourceReport()
+
+	if report.Goroutines != 1 || report.Timers != 1 {
+		t.Errorf("Expected 1 goroutine and 1 timer, but got %d and %d", report.Goroutines, report.Timers)
+	} else if report.EntryBytes <= empty.EntryBytes || report.ExpirerBytes <= empty.ExpirerBytes {
+		t.Errorf("Expected memory to grow with an entry, but got %v and %v", empty, report)
+	}
+
+	ttlmap.Close()
+	if report = ttlmap.ResourceReport(); report.Goroutines != 0 || report.Timers != 0 {
+		t.Errorf("Expected no goroutines and timers after close, but got %d and %d", report.Goroutines, report.Timers)
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	ttlmap := NewManual(time.Hour, time.Minute, WithMaxBytes[string, string](1<<20, func(key, value string) int64 {
+		return int64(len(key) + len(value))
+	}))
+	defer ttlmap.Close()
+	empty := ttlmap.MemoryUsage()
+
+	ttlmap.Store("key", "value")
+	small := ttlmap.MemoryUsage()
+	ttlmap.Store("key", string(make([]byte, 1000)))
+	if small <= empty {
+		t.Errorf("Expected memory to grow with an entry, but got %d and %d", empty, small)
+	} else if large := ttlmap.MemoryUsage(); large-small < 995 {
+		t.Errorf("Expected memory to include the sizes of values, but grew by %d", large-small)
+	} else if usage := ttlmap.MemoryUsage(); usage != ttlmap.ResourceReport().HeapBytes() {
+		t.Errorf("Expected memory usage to be the heap bytes of the report, but got %d", usage)
+	}
+}
@@ -0,0 +1,42 @@
+package ttlmap
+
+import "sync"
+
+// ring keeps the last items added to it. It is safe for
+// concurrent use.
+type ring[T any] struct {
+	mu   sync.Mutex
+	buf  []T
+	next int
+	full bool
+}
+
+// newRing creates a ring that keeps the last n items.
+func newRing[T any](n int) *ring[T] {
+	return &ring[T]{buf: make([]T, n)}
+}
+
+// add adds item to the ring, replacing the oldest item when
+// the ring is full.
+func (r *ring[T]) add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf[r.next] = item
+	r.next++
+	if r.next == len(r.buf) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// items returns the items in the ring, oldest first.
+func (r *ring[T]) items() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []T
+	if r.full {
+		items = append(items, r.buf[r.next:]...)
+	}
+	return append(items, r.buf[:r.next]...)
+}
@@ -0,0 +1,57 @@
+package ttlmap
+
+import "time"
+
+// TTLRounding is the behavior of a map whose TTL is not a
+// multiple of its interval, see WithTTLRounding.
+type TTLRounding int
+
+const (
+	// TTLRoundDown rounds the TTL down to a multiple of the
+	// interval. Entries never outlive the TTL. It is the
+	// default.
+	TTLRoundDown TTLRounding = iota
+	// TTLRoundUp rounds the TTL up to a multiple of the
+	// interval. Entries might outlive the TTL by less than
+	// one interval.
+	TTLRoundUp
+)
+
+// WithTTLRounding sets how the TTL of the map is rounded to a
+// multiple of its interval. With the default TTLRoundDown,
+// New(90*time.Second, time.Minute) has a single generation,
+// and entries expire after zero to one minute, with
+// TTLRoundUp it has two generations, and entries expire after
+// one to two minutes. TTLs shorter than the interval are
+// governed by WithShortTTL. Use Generations and EffectiveTTL
+// to verify the configuration.
+func WithTTLRounding[K comparable, V any](rounding TTLRounding) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.ttlRounding = rounding
+	}
+}
+
+// ticksOf returns the number of ticks of interval in ttl,
+// rounded by the TTLRounding of the map. It is 0 when ttl is
+// shorter than interval.
+func (m *TTLMap[K, V]) ticksOf(ttl, interval time.Duration) uint64 {
+	ticks := uint64(ttl / interval)
+	if m.ttlRounding == TTLRoundUp && ticks > 0 && ttl%interval != 0 {
+		ticks++
+	}
+	return ticks
+}
+
+// Generations returns the number of generations of the map,
+// which is its TTL in ticks.
+func (m *TTLMap[K, V]) Generations() int {
+	return int(m.ttlTicks.Load())
+}
+
+// EffectiveTTL returns the TTL of the map after rounding, the
+// longest time an entry lives without being touched. Entries
+// expire at a tick, so an entry lives for between
+// EffectiveTTL minus one interval and EffectiveTTL.
+func (m *TTLMap[K, V]) EffectiveTTL() time.Duration {
+	return time.Duration(m.ttlTicks.Load()) * m.tickInterval()
+}
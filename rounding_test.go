@@ -0,0 +1,44 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithTTLRounding(t *testing.T) {
+	down := New[string, string](90*time.Second, time.Minute)
+	defer down.Close()
+	up := New(90*time.Second, time.Minute, WithTTLRounding[string, string](TTLRoundUp))
+	defer up.Close()
+
+	if n := down.Generations(); n != 1 {
+		t.Errorf("Expected 1 generation, but got %d", n)
+	} else if ttl := down.EffectiveTTL(); ttl != time.Minute {
+		t.Errorf("Expected effective ttl of 1m, but got %s", ttl)
+	} else if n := up.Generations(); n != 2 {
+		t.Errorf("Expected 2 generations, but got %d", n)
+	} else if ttl := up.EffectiveTTL(); ttl != 2*time.Minute {
+		t.Errorf("Expected effective ttl of 2m, but got %s", ttl)
+	}
+
+	up.Store("key", "value")
+	up.nextGeneration()
+	if _, ok := up.Load("key"); !ok {
+		t.Errorf("Expected key to outlive one interval, but it expired")
+	}
+}
+
+func TestWithTTLRoundingSetTTL(t *testing.T) {
+	ttlmap := New(time.Minute, time.Minute, WithTTLRounding[string, string](TTLRoundUp))
+	defer ttlmap.Close()
+	if err := ttlmap.SetTTL(150*time.Second, time.Minute); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	} else if n := ttlmap.Generations(); n != 3 {
+		t.Errorf("Expected 3 generations, but got %d", n)
+	}
+
+	ttlmap.SetTTL(2*time.Minute, time.Minute)
+	if n := ttlmap.Generations(); n != 2 {
+		t.Errorf("Expected multiples to not be rounded, but got %d generations", n)
+	}
+}
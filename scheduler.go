@@ -0,0 +1,168 @@
+package ttlmap
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler advances many maps from a single ticker. Maps
+// created with WithScheduler don't run a ticker of their own,
+// their ticks are aligned to the scheduler and advanced in
+// one pass. This reduces timer pressure in services with many
+// small maps, like a map per tenant, also when their key and
+// value types differ. Maps attach and detach while they are
+// used with SetScheduler, and detach when they are closed.
+type Scheduler struct {
+	mu     sync.Mutex
+	maps   []advancer
+	ticker Ticker
+	start  time.Time
+}
+
+// advancer is a map that is advanced by a Scheduler.
+type advancer interface {
+	// advanceScheduled advances the map to the current time
+	// of its clock, and recovers panics like the ticker of a
+	// map does.
+	advanceScheduled()
+}
+
+// NewScheduler creates a Scheduler that wakes up every
+// interval. The interval of the maps should be a multiple of
+// it, otherwise their expiration is delayed until the next
+// wakeup of the scheduler.
+func NewScheduler(interval time.Duration) *Scheduler {
+	s := &Scheduler{start: time.Now()}
+	s.ticker = systemClock{}.Ticker(interval, func() {
+		s.mu.Lock()
+		maps := s.maps
+		s.mu.Unlock()
+
+		for _, m := range maps {
+			m.advanceScheduled()
+		}
+	})
+	return s
+}
+
+// Close stops the scheduler and its goroutine. The maps it
+// advanced stop expiring entries.
+func (s *Scheduler) Close() {
+	s.ticker.Stop()
+}
+
+// WithScheduler lets s advance the map, instead of a ticker
+// of its own. The ticks of the map are aligned to the start
+// of the scheduler.
+func WithScheduler[K comparable, V any](s *Scheduler) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.scheduler = s
+	}
+}
+
+// add starts advancing m. The next tick of m is aligned to the
+// start of the scheduler.
+func (s *Scheduler) add(m interface {
+	advancer
+	align(start time.Time)
+}) {
+	m.align(s.start)
+	s.attach(m)
+}
+
+// attach starts advancing m, without aligning it.
+func (s *Scheduler) attach(m advancer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Copy the slice, so a concurrent pass over the old
+	// slice is not affected.
+	maps := make([]advancer, len(s.maps), len(s.maps)+1)
+	copy(maps, s.maps)
+	s.maps = append(maps, m)
+}
+
+// remove stops advancing m.
+func (s *Scheduler) remove(m advancer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	maps := make([]advancer, 0, len(s.maps))
+	for _, other := range s.maps {
+		if other != m {
+			maps = append(maps, other)
+		}
+	}
+	s.maps = maps
+}
+
+// align aligns the next tick of the map to the ticks of a
+// scheduler that started at start.
+func (m *TTLMap[K, V]) align(start time.Time) {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	m.alignLocked(start)
+}
+
+// alignLocked is align for callers that hold advanceMu. The
+// scheduler wakes up by the system clock, the ticks of a map
+// with another clock are not aligned.
+func (m *TTLMap[K, V]) alignLocked(start time.Time) {
+	if _, ok := m.clock.(systemClock); !ok {
+		m.setNextTick(m.clock.Now().Add(m.tickInterval()))
+		return
+	}
+	elapsed := time.Since(start)
+	m.setNextTick(start.Add((elapsed/m.tickInterval() + 1) * m.tickInterval()))
+}
+
+// advanceScheduled implements advancer. Like the ticker of
+// the map, it catches up on dropped ticks by advancing to the
+// current time.
+func (m *TTLMap[K, V]) advanceScheduled() {
+	m.labeled(func() {
+		defer m.recoverPanic()
+		m.AdvanceTo(m.clock.Now())
+	})
+}
+
+// SetScheduler moves the map to the scheduler s while it is
+// used, see WithScheduler. The ticker of the map, or its
+// previous scheduler, stops advancing it. A nil s detaches
+// the map from its scheduler and starts a ticker of its own,
+// maps created with NewManual are advanced by their caller
+// again. Entries keep their deadlines, the next tick is
+// aligned to the new source of ticks.
+//
+// It returns ErrChild for child maps, which are advanced by
+// their parent, and ErrClosed after the map was closed.
+func (m *TTLMap[K, V]) SetScheduler(s *Scheduler) error {
+	if m.parent != nil {
+		return ErrChild
+	}
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	if m.closed.Load() {
+		return ErrClosed
+	} else if s == m.scheduler {
+		return nil
+	}
+
+	if m.scheduler != nil {
+		m.scheduler.remove(m)
+	}
+	if m.ticker != nil {
+		m.ticker.Stop()
+		m.ticker = nil
+	}
+	m.scheduler = s
+	if s != nil {
+		m.alignLocked(s.start)
+		s.attach(m)
+	} else if !m.manual {
+		m.setNextTick(m.clock.Now().Add(m.tickInterval()))
+		m.startTicker()
+	}
+	return nil
+}
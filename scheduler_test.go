@@ -0,0 +1,146 @@
+package ttlmap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(time.Millisecond)
+	defer s.Close()
+	strings := New(time.Millisecond, time.Millisecond, WithScheduler[string, string](s))
+	ints := New(time.Millisecond, time.Millisecond, WithScheduler[int, int](s))
+	strings.Store("key", "value")
+	ints.Store(1, 1)
+
+	if !waitFor(func() bool { return strings.count.Load() == 0 && ints.count.Load() == 0 }) {
+		t.Errorf("Expected entries of both maps to expire, but did not")
+	}
+
+	ints.Close()
+	if len(s.maps) != 1 {
+		t.Errorf("Expected closed map to be detached, but was not")
+	} else if strings.ticker != nil {
+		t.Errorf("Expected map to not have a ticker, but it had")
+	}
+}
+
+func TestSetScheduler(t *testing.T) {
+	s := NewScheduler(time.Millisecond)
+	defer s.Close()
+	ttlmap := New[string, string](time.Millisecond, time.Millisecond)
+	defer ttlmap.Close()
+
+	if err := ttlmap.SetScheduler(s); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if ttlmap.ticker != nil {
+		t.Errorf("Expected ticker of the map to be stopped, but it was not")
+	} else if len(s.maps) != 1 {
+		t.Errorf("Expected map to be attached, but it was not")
+	}
+
+	ttlmap.Store("key", "value")
+	if !waitFor(func() bool { return ttlmap.count.Load() == 0 }) {
+		t.Errorf("Expected entry to expire by the scheduler, but did not")
+	}
+
+	if err := ttlmap.SetScheduler(nil); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if len(s.maps) != 0 {
+		t.Errorf("Expected map to be detached, but it was not")
+	} else if ttlmap.ticker == nil {
+		t.Errorf("Expected map to have a ticker, but it had not")
+	}
+
+	ttlmap.Store("key", "value")
+	if !waitFor(func() bool { return ttlmap.count.Load() == 0 }) {
+		t.Errorf("Expected entry to expire by the ticker, but did not")
+	}
+
+	child := ttlmap.Child(time.Millisecond)
+	if err := child.SetScheduler(s); err != ErrChild {
+		t.Errorf("Expected ErrChild, but got '%v'", err)
+	}
+	ttlmap.Close()
+	if err := ttlmap.SetScheduler(s); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, but got '%v'", err)
+	}
+}
+
+func TestSetSchedulerManual(t *testing.T) {
+	s := NewScheduler(time.Hour)
+	defer s.Close()
+	ttlmap := NewManual[string, string](time.Hour, time.Minute)
+	defer ttlmap.Close()
+
+	if err := ttlmap.SetScheduler(s); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if err = ttlmap.SetScheduler(nil); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if ttlmap.ticker != nil {
+		t.Errorf("Expected manual map to stay without ticker, but it got one")
+	}
+}
+
+func TestSchedulerClose(t *testing.T) {
+	s := NewScheduler(time.Millisecond)
+	s.Close()
+
+	select {
+	case <-s.ticker.(*systemTicker).done:
+	default:
+		t.Errorf("Expected the goroutine of the scheduler to be stopped, but it was not")
+	}
+}
+
+func TestSchedulerClock(t *testing.T) {
+	s := NewScheduler(time.Millisecond)
+	defer s.Close()
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := New(time.Millisecond, time.Millisecond, WithClock[string, string](clock), WithScheduler[string, string](s))
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected key to be kept while the clock of the map is stopped, but it expired")
+	}
+}
+
+func TestSchedulerPanic(t *testing.T) {
+	s := NewScheduler(time.Millisecond)
+	defer s.Close()
+	clock := &panickingClock{}
+	panicking := New(time.Millisecond, time.Millisecond, WithClock[string, string](clock), WithScheduler[string, string](s))
+	defer panicking.Close()
+	ttlmap := New(time.Millisecond, time.Millisecond, WithScheduler[string, string](s))
+	defer ttlmap.Close()
+	clock.panics.Store(true)
+
+	if !waitFor(func() bool { return panicking.Err() != nil }) {
+		t.Errorf("Expected the panic to be recorded, but it was not")
+	}
+	ttlmap.Store("key", "value")
+	if !waitFor(func() bool { return ttlmap.count.Load() == 0 }) {
+		t.Errorf("Expected the scheduler to keep advancing after a panic, but it did not")
+	}
+	clock.panics.Store(false)
+}
+
+// panickingClock is a Clock whose Now panics once panics is
+// set.
+type panickingClock struct {
+	panics atomic.Bool
+}
+
+func (c *panickingClock) Now() time.Time {
+	if c.panics.Load() {
+		panic("now")
+	}
+	return time.Now()
+}
+
+func (c *panickingClock) Ticker(time.Duration, func()) Ticker {
+	return stoppedTicker{}
+}
@@ -0,0 +1,44 @@
+package ttlmap
+
+import "time"
+
+// WithShadow mirrors the operations of the map into shadow,
+// a map with another configuration, like another TTL, policy
+// or capacity. The shadow map never serves values, but its
+// Stats report the hits and misses it would have had. This
+// allows measuring a proposed configuration in production
+// safely, for example:
+//
+//	shadow := ttlmap.New(time.Hour, time.Minute, ttlmap.WithMaxEntries[string, string](1000))
+//	m := ttlmap.New(10*time.Minute, time.Minute, ttlmap.WithShadow(shadow))
+//	// Compare m.Stats().HitRatio() with shadow.Stats().HitRatio().
+//
+// Load, LoadOrStore, stores and deletes are mirrored, other
+// operations are not. A key that is found in the map but
+// missing in the shadow map is stored in the shadow map, like
+// a caller that fills the cache after a miss. Mirroring is
+// synchronous, it roughly doubles the cost of operations.
+func WithShadow[K comparable, V any](shadow *TTLMap[K, V]) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.shadow = shadow
+	}
+}
+
+// shadowLoad mirrors a load of key that returned value and ok
+// into the shadow map.
+func (m *TTLMap[K, V]) shadowLoad(key K, value V, ok bool) {
+	if _, hit := m.shadow.Load(key); !hit && ok {
+		m.shadow.Store(key, value)
+	}
+}
+
+// shadowStore mirrors a store of key into the shadow map. The
+// ttl is the TTL of the entry in ticks, or 0 for the TTL of
+// the map.
+func (m *TTLMap[K, V]) shadowStore(key K, value V, ttl uint64) {
+	if ttl == 0 {
+		m.shadow.Store(key, value)
+		return
+	}
+	m.shadow.StoreWithTTL(key, value, time.Duration(ttl)*m.tickInterval())
+}
@@ -0,0 +1,29 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithShadow(t *testing.T) {
+	shadow := New[string, string](time.Hour, time.Hour)
+	defer shadow.Close()
+	ttlmap := New(2*time.Hour, time.Hour, WithShadow(shadow))
+	defer ttlmap.Close()
+
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+	shadow.nextGeneration()
+	ttlmap.Load("key1")
+	ttlmap.Load("key1")
+	ttlmap.Delete("key1")
+
+	if stats := ttlmap.Stats(); stats.Hits != 2 {
+		t.Errorf("Expected 2 hits in the map, but got %d", stats.Hits)
+	} else if stats := shadow.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss in the shadow, but got %d and %d", stats.Hits, stats.Misses)
+	} else if shadow.Len() != 0 {
+		t.Errorf("Expected deletes to be mirrored, but shadow has %d entries", shadow.Len())
+	}
+}
@@ -0,0 +1,57 @@
+package ttlmap
+
+import "sort"
+
+// EvictN removes the n entries that expire first, and returns
+// them in the order of their deadlines. This sheds memory
+// incrementally during incidents, instead of flushing the whole
+// map with Clear. The order of entries that share a deadline
+// is unspecified, fewer entries are returned when the map holds
+// fewer than n.
+//
+// Evicted entries are reported with EvictionCapacity, see
+// WithOnEvict. It is a no-op while the map is frozen.
+func (m *TTLMap[K, V]) EvictN(n int) []Entry[K, V] {
+	defer m.operation()()
+	if n <= 0 || m.Frozen() {
+		return nil
+	}
+
+	type candidate struct {
+		key     K
+		expires uint64
+	}
+	var candidates []candidate
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if expires := e.expires.Load(); expires != 0 {
+			candidates = append(candidates, candidate{key, expires})
+		}
+		return true
+	})
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].expires < candidates[j].expires
+	})
+
+	evicted := make([]Entry[K, V], 0, min(n, len(candidates)))
+	var batch []ExpiredEntry[K, V]
+	for _, c := range candidates {
+		if len(evicted) == n {
+			break
+		}
+		e := m.expire(c.key, c.expires, EvictionCapacity)
+		if e == nil {
+			// The entry was removed or stored again.
+			continue
+		}
+
+		m.unschedule(c.key)
+		value := m.value(e)
+		if m.disposing() {
+			batch = append(batch, ExpiredEntry[K, V]{Key: c.key, Value: value})
+		}
+		m.release(e)
+		evicted = append(evicted, Entry[K, V]{Key: c.key, Value: value, Meta: e.meta})
+	}
+	m.dispose(batch)
+	return evicted
+}
@@ -0,0 +1,36 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvictN(t *testing.T) {
+	var reasons []EvictionReason
+	ttlmap := NewManual(3*time.Second, time.Second, WithOnEvict(func(_, _ string, reason EvictionReason) {
+		reasons = append(reasons, reason)
+	}))
+	defer ttlmap.Close()
+	ttlmap.Store("a", "1")
+	ttlmap.Tick()
+	ttlmap.Store("b", "2")
+	ttlmap.Tick()
+	ttlmap.Store("c", "3")
+
+	evicted := ttlmap.EvictN(2)
+	if len(evicted) != 2 || evicted[0].Key != "a" || evicted[1].Key != "b" {
+		t.Errorf("Expected a and b to be evicted, but got %v", evicted)
+	} else if evicted[0].Value != "1" {
+		t.Errorf("Expected value to be '1', but was '%s'", evicted[0].Value)
+	} else if ttlmap.Len() != 1 {
+		t.Errorf("Expected 1 entry to be left, but had %d", ttlmap.Len())
+	} else if len(reasons) != 2 || reasons[0] != EvictionCapacity {
+		t.Errorf("Expected 2 capacity evictions, but got %v", reasons)
+	}
+
+	if evicted := ttlmap.EvictN(5); len(evicted) != 1 || evicted[0].Key != "c" {
+		t.Errorf("Expected only c to be evicted, but got %v", evicted)
+	} else if evicted := ttlmap.EvictN(1); len(evicted) != 0 {
+		t.Errorf("Expected nothing to be evicted from an empty map, but got %v", evicted)
+	}
+}
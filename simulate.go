@@ -0,0 +1,151 @@
+package ttlmap
+
+import "time"
+
+// TraceOp is the operation of a TraceEvent.
+type TraceOp int
+
+const (
+	// TraceLoad is a load of a key.
+	TraceLoad TraceOp = iota
+	// TraceStore is a store of a key.
+	TraceStore
+	// TraceDelete is a delete of a key.
+	TraceDelete
+)
+
+// TraceEvent is an access recorded by a map created with
+// WithTraceRecording.
+type TraceEvent[K comparable] struct {
+	Op  TraceOp
+	Key K
+	// Tick is the tick of the map at which the access
+	// happened.
+	Tick uint64
+}
+
+// Trace is a recorded access trace, see TTLMap.Trace.
+type Trace[K comparable] struct {
+	// Interval is the interval of the map, the duration of
+	// a tick.
+	Interval time.Duration
+	Events   []TraceEvent[K]
+}
+
+// WithTraceRecording records the last n loads, stores and
+// deletes of the map. The recorded trace can be replayed
+// against other configurations with Simulate, to tune the
+// configuration offline.
+func WithTraceRecording[K comparable, V any](n int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.tracer = newRing[TraceEvent[K]](n)
+	}
+}
+
+// Trace returns the recorded access trace, oldest access
+// first. It is empty when the map is not created with
+// WithTraceRecording.
+func (m *TTLMap[K, V]) Trace() Trace[K] {
+	trace := Trace[K]{Interval: m.tickInterval()}
+	if m.tracer != nil {
+		trace.Events = m.tracer.items()
+	}
+	return trace
+}
+
+// record records an access when trace recording is enabled,
+// and counts loads for WithFrequencyAdmission.
+func (m *TTLMap[K, V]) record(op TraceOp, key K) {
+	if op == TraceLoad && m.sketch != nil {
+		m.sketch.increment(key)
+	}
+	if m.tracer != nil {
+		m.tracer.add(TraceEvent[K]{Op: op, Key: key, Tick: m.tick.Load()})
+	}
+}
+
+// SimulationConfig is a hypothetical configuration to replay
+// a trace against.
+type SimulationConfig struct {
+	// TTL is the time-to-live of entries.
+	TTL time.Duration
+	// Capacity is the maximum number of entries, entries
+	// closest to expiry are evicted first. Zero means no
+	// limit.
+	Capacity int
+	// Sliding resets the TTL of entries when they are
+	// loaded.
+	Sliding bool
+}
+
+// SimulationResult is the projected outcome of replaying a
+// trace.
+type SimulationResult struct {
+	Hits      int
+	Misses    int
+	Evictions int
+}
+
+// HitRatio returns the fraction of loads that were hits.
+func (r SimulationResult) HitRatio() float64 {
+	if r.Hits+r.Misses == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Hits+r.Misses)
+}
+
+// Simulate replays trace against the hypothetical config and
+// reports the projected hits and misses. The TTL is rounded
+// to the interval of the trace.
+func Simulate[K comparable](trace Trace[K], config SimulationConfig) SimulationResult {
+	ttlTicks := uint64(config.TTL / trace.Interval)
+	deadlines := make(map[K]uint64)
+	order := NewHeapExpirer[K]()
+
+	var (
+		result SimulationResult
+		keys   []K
+	)
+	for _, event := range trace.Events {
+		// Expire all entries that are due.
+		for len(order.items) > 0 && order.items[0].deadline <= event.Tick {
+			keys = order.Advance(order.items[0].deadline, keys[:0])
+			for _, key := range keys {
+				delete(deadlines, key)
+			}
+		}
+
+		switch event.Op {
+		case TraceLoad:
+			if _, ok := deadlines[event.Key]; !ok {
+				result.Misses++
+				continue
+			}
+
+			result.Hits++
+			if config.Sliding {
+				deadlines[event.Key] = event.Tick + ttlTicks
+				order.Schedule(event.Tick+ttlTicks, event.Key)
+			}
+		case TraceStore:
+			if _, ok := deadlines[event.Key]; !ok && config.Capacity > 0 && len(deadlines) >= config.Capacity {
+				evicted := heapPop(order)
+				delete(deadlines, evicted)
+				result.Evictions++
+			}
+			deadlines[event.Key] = event.Tick + ttlTicks
+			order.Schedule(event.Tick+ttlTicks, event.Key)
+		case TraceDelete:
+			delete(deadlines, event.Key)
+			order.Remove(event.Key)
+		}
+	}
+	return result
+}
+
+// heapPop removes the key closest to expiry from h.
+func heapPop[K comparable](h *HeapExpirer[K]) K {
+	key := h.items[0].key
+	h.Remove(key)
+	return key
+}
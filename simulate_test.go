@@ -0,0 +1,48 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTraceRecording(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithTraceRecording[string, string](3))
+	ttlmap.Store("key1", "value1")
+	ttlmap.nextGeneration()
+	ttlmap.Load("key1")
+	ttlmap.Load("key2")
+	ttlmap.Delete("key1")
+
+	trace := ttlmap.Trace()
+	if trace.Interval != time.Hour {
+		t.Errorf("Expected interval to be 1h, but was %s", trace.Interval)
+	} else if len(trace.Events) != 3 {
+		t.Errorf("Expected 3 events, but got %d", len(trace.Events))
+	} else if e := trace.Events[0]; e.Op != TraceLoad || e.Key != "key1" || e.Tick != 1 {
+		t.Errorf("Expected first event to be a load of key1 at tick 1, but was %+v", e)
+	} else if e = trace.Events[2]; e.Op != TraceDelete || e.Key != "key1" {
+		t.Errorf("Expected last event to be a delete of key1, but was %+v", e)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	trace := Trace[string]{Interval: time.Minute, Events: []TraceEvent[string]{
+		{Op: TraceStore, Key: "key1", Tick: 0},
+		{Op: TraceStore, Key: "key2", Tick: 0},
+		{Op: TraceLoad, Key: "key1", Tick: 1},
+		{Op: TraceLoad, Key: "key2", Tick: 3},
+		{Op: TraceLoad, Key: "key1", Tick: 4},
+	}}
+
+	if r := Simulate(trace, SimulationConfig{TTL: 2 * time.Minute}); r.Hits != 1 || r.Misses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses, but got %+v", r)
+	} else if r = Simulate(trace, SimulationConfig{TTL: 4 * time.Minute}); r.Hits != 2 || r.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, but got %+v", r)
+	} else if r = Simulate(trace, SimulationConfig{TTL: 4 * time.Minute, Sliding: true}); r.Hits != 3 {
+		t.Errorf("Expected 3 hits, but got %+v", r)
+	} else if r = Simulate(trace, SimulationConfig{TTL: 4 * time.Minute, Capacity: 1}); r.Hits != 1 || r.Evictions != 1 {
+		t.Errorf("Expected 1 hit and 1 eviction, but got %+v", r)
+	} else if ratio := r.HitRatio(); ratio < 0.33 || ratio > 0.34 {
+		t.Errorf("Expected hit ratio to be 1/3, but was %f", ratio)
+	}
+}
@@ -0,0 +1,229 @@
+package ttlmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotPrefix and snapshotSuffix surround the sequence
+// number in the file names of snapshots.
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".ttlmap"
+)
+
+// snapshotTable is the checksum table of snapshots.
+var snapshotTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteSnapshot writes the entries of the map to a new
+// snapshot in dir, and removes all but the keep newest
+// snapshots. At least the new snapshot is kept. The entries
+// are encoded like Save does, so the time the process is down
+// counts towards their TTL when they are read.
+//
+// A crash during the write never damages earlier snapshots:
+// the snapshot is written to a temporary file that is synced
+// and renamed into place. Every snapshot carries a checksum,
+// which allows ReadSnapshot to fall back to an earlier one.
+func (m *TTLMap[K, V]) WriteSnapshot(dir string, keep int) error {
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		return err
+	}
+	buf.Write(binary.BigEndian.AppendUint32(nil, crc32.Checksum(buf.Bytes(), snapshotTable)))
+
+	snapshots, err := listSnapshots(dir)
+	if err != nil {
+		return err
+	}
+	seq := uint64(1)
+	if len(snapshots) > 0 {
+		seq = snapshots[0].seq + 1
+	}
+	if err = writeFileAtomic(dir, fmt.Sprintf("%s%020d%s", snapshotPrefix, seq, snapshotSuffix), buf.Bytes()); err != nil {
+		return err
+	}
+
+	// The new snapshot is not in snapshots, so one less old
+	// snapshot is kept.
+	if keep < 1 {
+		keep = 1
+	}
+	for i := keep - 1; i < len(snapshots); i++ {
+		if err = os.Remove(snapshots[i].path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSnapshot stores the entries of the newest valid snapshot
+// in dir in the map. Snapshots that fail checksum validation
+// or decoding are skipped, so a damaged snapshot falls back to
+// the previous one. It returns ErrNoSnapshot when dir
+// contains no valid snapshot.
+//
+// Restored entries expire at the time they would have expired
+// in the map that wrote the snapshot, like Restore. Entries
+// that expired meanwhile are skipped.
+func (m *TTLMap[K, V]) ReadSnapshot(dir string) error {
+	snapshots, err := listSnapshots(dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, snapshot := range snapshots {
+		entries, err := readSnapshot[K, V](snapshot.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		m.restoreEntries(entries)
+		return nil
+	}
+	return errors.Join(append([]error{ErrNoSnapshot}, errs...)...)
+}
+
+// Save writes the entries of the map to w, with the time at
+// which every entry expires. Keys and values are encoded with
+// encoding/gob. Together with Restore, this allows a restarted
+// process to start with a warm cache, see WriteSnapshot for
+// snapshots that survive crashes.
+func (m *TTLMap[K, V]) Save(w io.Writer) error {
+	// The times are computed without advanceMu, so saving
+	// doesn't wait for sweeps.
+	var entries []PatchEntry[K, V]
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if expires := e.expires.Load(); expires != 0 && !m.hidden(e) {
+			expiresAt := time.Unix(0, m.expiryTime(expires))
+			entries = append(entries, PatchEntry[K, V]{Key: key, Value: m.value(e), ExpiresAt: expiresAt})
+		}
+		return true
+	})
+	if err := gob.NewEncoder(w).Encode(entries); err != nil {
+		return fmt.Errorf("ttlmap: encoding entries: %w", err)
+	}
+	return nil
+}
+
+// Restore stores the entries written by Save in the map. The
+// entries expire at the time they would have expired in the
+// saved map, like StoreUntil, so the time the process was down
+// counts towards their TTL. Entries that expired meanwhile are
+// skipped. The entries of the map are kept, unless they are
+// overwritten.
+func (m *TTLMap[K, V]) Restore(r io.Reader) error {
+	var entries []PatchEntry[K, V]
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("ttlmap: decoding entries: %w", err)
+	}
+	m.restoreEntries(entries)
+	return nil
+}
+
+// restoreEntries stores the entries written by Save that did
+// not expire yet.
+func (m *TTLMap[K, V]) restoreEntries(entries []PatchEntry[K, V]) {
+	now := m.clock.Now()
+	for _, e := range entries {
+		if e.ExpiresAt.After(now) {
+			m.restoreStore(e.Key, e.Value, e.ExpiresAt)
+		}
+	}
+}
+
+// snapshotFile is a snapshot in a directory.
+type snapshotFile struct {
+	path string
+	seq  uint64
+}
+
+// listSnapshots returns the snapshots in dir, newest first.
+func listSnapshots(dir string) ([]snapshotFile, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []snapshotFile
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+
+		var seq uint64
+		if _, err := fmt.Sscanf(strings.TrimPrefix(name, snapshotPrefix), "%d", &seq); err == nil {
+			snapshots = append(snapshots, snapshotFile{path: filepath.Join(dir, name), seq: seq})
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].seq > snapshots[j].seq
+	})
+	return snapshots, nil
+}
+
+// readSnapshot reads and validates the snapshot at path.
+func readSnapshot[K comparable, V any](path string) ([]PatchEntry[K, V], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4 {
+		return nil, fmt.Errorf("ttlmap: snapshot %s is truncated", path)
+	}
+	data, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(data, snapshotTable) != sum {
+		return nil, fmt.Errorf("ttlmap: snapshot %s has an invalid checksum", path)
+	}
+
+	var entries []PatchEntry[K, V]
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("ttlmap: decoding snapshot %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// writeFileAtomic writes data to the file name in dir. The
+// data is written to a temporary file, which is synced and
+// renamed into place, so the file is never partially written.
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	} else if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	} else if err = tmp.Close(); err != nil {
+		return err
+	} else if err = os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	// Sync the directory, so the rename survives a crash.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
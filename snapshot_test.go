@@ -0,0 +1,112 @@
+package ttlmap
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key1", "value1")
+	if err := ttlmap.WriteSnapshot(dir, 2); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	ttlmap.Store("key2", "value2")
+	if err := ttlmap.WriteSnapshot(dir, 2); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	ttlmap.Store("key3", "value3")
+	if err := ttlmap.WriteSnapshot(dir, 2); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+
+	snapshots, _ := listSnapshots(dir)
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 snapshots, but got %d", len(snapshots))
+	}
+
+	// Damage the newest snapshot, restoring falls back to the
+	// previous one.
+	if err := os.WriteFile(snapshots[0].path, []byte("damaged"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	restored := New[string, string](time.Hour, time.Minute)
+	if err := restored.ReadSnapshot(dir); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if value, ok := restored.Load("key2"); !ok || value != "value2" {
+		t.Errorf("Expected value to be 'value2', but was '%s'", value)
+	} else if _, ok = restored.Load("key3"); ok {
+		t.Errorf("Expected key of damaged snapshot to be missing, but was found")
+	}
+}
+
+func TestSnapshotDowntime(t *testing.T) {
+	dir := t.TempDir()
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := NewManual(time.Hour, time.Minute, WithClock[string, string](clock))
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.StoreWithTTL("key2", "value2", 5*time.Minute)
+	expiresAt, _ := ttlmap.ExpiresAt("key1")
+	if err := ttlmap.WriteSnapshot(dir, 1); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+
+	// The process is down for 10 minutes.
+	later := &stoppedClock{now: clock.now.Add(10 * time.Minute)}
+	restored := NewManual(time.Hour, time.Minute, WithClock[string, string](later))
+	defer restored.Close()
+	if err := restored.ReadSnapshot(dir); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if at, ok := restored.ExpiresAt("key1"); !ok || at.Sub(expiresAt).Abs() > time.Minute {
+		t.Errorf("Expected key1 to expire at %s, but got %s", expiresAt, at)
+	} else if _, ok = restored.Load("key2"); ok {
+		t.Errorf("Expected key2 to expire during the downtime, but it was restored")
+	}
+}
+
+func TestReadSnapshotEmpty(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	if err := ttlmap.ReadSnapshot(t.TempDir()); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("Expected ErrNoSnapshot, but got '%v'", err)
+	}
+}
+
+func TestSaveRestore(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	ttlmap := NewManual(time.Hour, time.Minute, WithClock[string, string](clock))
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.StoreWithTTL("key2", "value2", 5*time.Minute)
+
+	var buf bytes.Buffer
+	if err := ttlmap.Save(&buf); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	expiresAt, _ := ttlmap.ExpiresAt("key1")
+
+	// The restored map starts 10 minutes later, the downtime
+	// counts towards the TTL.
+	clock.now = clock.now.Add(10 * time.Minute)
+	restored := NewManual(time.Hour, time.Minute, WithClock[string, string](clock))
+	defer restored.Close()
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+
+	if value, ok := restored.Load("key1"); !ok || value != "value1" {
+		t.Errorf("Expected value to be 'value1', but was '%s'", value)
+	} else if _, ok := restored.Load("key2"); ok {
+		t.Errorf("Expected key2 to expire during the downtime, but it was restored")
+	} else if at, _ := restored.ExpiresAt("key1"); at.After(expiresAt) || at.Before(expiresAt.Add(-time.Minute)) {
+		t.Errorf("Expected key1 to expire within an interval before %v, but expires at %v", expiresAt, at)
+	}
+
+	if err := restored.Restore(bytes.NewReader([]byte("garbage"))); err == nil {
+		t.Errorf("Expected an error for invalid data, but got none")
+	}
+}
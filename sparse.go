@@ -0,0 +1,118 @@
+package ttlmap
+
+import "time"
+
+// WithSparseTicks lets the ticker of the map sleep until the
+// next tick at which a key is scheduled, instead of waking up
+// every interval. Maps with a long TTL and a fine interval
+// have thousands of generations, when they hold few entries
+// most wakeups advance an empty generation. Scheduling a key
+// before the planned wakeup wakes the ticker up again, so
+// entries expire on time.
+//
+// The ticks that are slept through are advanced on the next
+// wakeup, they are cheap while their generations are empty.
+// Sleeping needs an expiry engine that knows its next
+// deadline, which are the generation, list and heap expirers.
+// Maps with children, and maps of a Scheduler or NewManual,
+// keep their ticks. WithAdaptiveInterval takes precedence.
+func WithSparseTicks[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.sparse = true
+	}
+}
+
+// nextDeadliner is implemented by expirers that know their
+// next deadline.
+type nextDeadliner interface {
+	// nextDeadline returns the earliest deadline of a
+	// scheduled key, or false when no key is scheduled.
+	nextDeadline() (uint64, bool)
+}
+
+// sleep returns the next period of a sparse ticker, and resets
+// the ticker when it changed. The period lasts until the tick
+// of the next scheduled key, or the TTL of the map when none
+// is scheduled.
+func (m *TTLMap[K, V]) sleep(period time.Duration) time.Duration {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	if m.closed.Load() {
+		return period
+	}
+
+	tick := m.tick.Load()
+	wake := tick + 1
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	woken := m.wake.Load() == 0
+	if expirer, ok := m.expirer.(nextDeadliner); ok && len(m.children) == 0 {
+		wake = tick + m.ttlTicks.Load()
+		if deadline, scheduled := expirer.nextDeadline(); scheduled {
+			wake = min(wake, max(deadline, tick+1))
+		}
+	}
+	m.wake.Store(wake)
+
+	// The ticker is reset under mu, so a key that is scheduled
+	// meanwhile resets it after this.
+	next := m.tickInterval()
+	if wake > tick+1 {
+		next = max(m.timeOf(wake, tick, m.nextTick).Sub(m.clock.Now()), m.tickInterval())
+	}
+	if next != period || woken {
+		m.ticker.Reset(next)
+	}
+	return next
+}
+
+// scheduled wakes a sparse ticker up when a key is scheduled
+// before its planned wakeup, the next call of sleep resets it
+// again. The caller must hold mu.
+func (m *TTLMap[K, V]) scheduled(deadline uint64) {
+	if !m.sparse || deadline >= m.wake.Load() {
+		return
+	}
+	m.wake.Store(0)
+	if m.ticker != nil && !m.closed.Load() {
+		m.ticker.Reset(m.tickInterval())
+	}
+}
+
+// nextDeadline implements nextDeadliner.
+func (g *GenerationExpirer[K]) nextDeadline() (uint64, bool) {
+	for i := range uint64(len(g.generations)) {
+		if len(g.generations[g.wrap(g.head+i)]) > 0 {
+			return g.tick + 1 + i, true
+		}
+	}
+
+	var next uint64
+	for _, round := range g.rounds {
+		for _, k := range round {
+			if next == 0 || k.deadline < next {
+				next = k.deadline
+			}
+		}
+	}
+	return next, next != 0
+}
+
+// nextDeadline implements nextDeadliner.
+func (l *ListExpirer[K]) nextDeadline() (uint64, bool) {
+	var next uint64
+	for deadline := range l.buckets {
+		if next == 0 || deadline < next {
+			next = deadline
+		}
+	}
+	return next, next != 0
+}
+
+// nextDeadline implements nextDeadliner.
+func (h *HeapExpirer[K]) nextDeadline() (uint64, bool) {
+	if len(h.items) == 0 {
+		return 0, false
+	}
+	return h.items[0].deadline, true
+}
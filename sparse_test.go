@@ -0,0 +1,96 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+// resetClock is a stoppedClock whose ticker records its
+// period.
+type resetClock struct {
+	stoppedClock
+	ticker resetTicker
+}
+
+func (c *resetClock) Ticker(d time.Duration, _ func()) Ticker {
+	c.ticker.period = d
+	return &c.ticker
+}
+
+// resetTicker is a Ticker that never ticks, but records its
+// period.
+type resetTicker struct {
+	period time.Duration
+}
+
+func (t *resetTicker) Reset(d time.Duration) {
+	t.period = d
+}
+
+func (t *resetTicker) Stop() {}
+
+func TestSparseTicks(t *testing.T) {
+	clock := &resetClock{stoppedClock: stoppedClock{now: time.Now()}}
+	ttlmap := New(time.Hour, time.Minute, WithClock[string, string](clock), WithSparseTicks[string, string]())
+	defer ttlmap.Close()
+
+	// An empty map sleeps for its TTL.
+	period := ttlmap.sleep(time.Minute)
+	if period != time.Hour || clock.ticker.period != time.Hour {
+		t.Errorf("Expected empty map to sleep 1h, but slept %s", clock.ticker.period)
+	}
+
+	// Scheduling a key before the wakeup wakes the ticker.
+	ttlmap.StoreWithTTL("key", "value", 5*time.Minute)
+	if clock.ticker.period != time.Minute {
+		t.Errorf("Expected early key to wake the ticker, but its period is %s", clock.ticker.period)
+	} else if period = ttlmap.sleep(period); period != 5*time.Minute || clock.ticker.period != 5*time.Minute {
+		t.Errorf("Expected map to sleep until the key expires, but slept %s", clock.ticker.period)
+	}
+
+	// Later keys don't wake it.
+	ttlmap.Store("other", "value")
+	if clock.ticker.period != 5*time.Minute {
+		t.Errorf("Expected later key not to wake the ticker, but its period is %s", clock.ticker.period)
+	}
+}
+
+func TestSparseTicksChildren(t *testing.T) {
+	clock := &resetClock{stoppedClock: stoppedClock{now: time.Now()}}
+	ttlmap := New(time.Hour, time.Minute, WithClock[string, string](clock), WithSparseTicks[string, string]())
+	defer ttlmap.Close()
+	ttlmap.Child(time.Hour)
+
+	if period := ttlmap.sleep(time.Minute); period != time.Minute {
+		t.Errorf("Expected map with children to tick every interval, but slept %s", period)
+	}
+}
+
+func TestNextDeadline(t *testing.T) {
+	g := NewGenerationExpirer[string](4)
+	if _, ok := g.nextDeadline(); ok {
+		t.Errorf("Expected empty expirer to have no deadline, but it had one")
+	}
+	g.Schedule(10, "round")
+	if deadline, ok := g.nextDeadline(); !ok || deadline != 10 {
+		t.Errorf("Expected deadline 10 of the round, but got %d", deadline)
+	}
+	g.Schedule(3, "ring")
+	if deadline, ok := g.nextDeadline(); !ok || deadline != 3 {
+		t.Errorf("Expected deadline 3 of the ring, but got %d", deadline)
+	}
+
+	l := NewListExpirer[string]()
+	l.Schedule(7, "a")
+	l.Schedule(5, "b")
+	if deadline, ok := l.nextDeadline(); !ok || deadline != 5 {
+		t.Errorf("Expected deadline 5 of the list, but got %d", deadline)
+	}
+
+	h := NewHeapExpirer[string]()
+	h.Schedule(7, "a")
+	h.Schedule(5, "b")
+	if deadline, ok := h.nextDeadline(); !ok || deadline != 5 {
+		t.Errorf("Expected deadline 5 of the heap, but got %d", deadline)
+	}
+}
@@ -0,0 +1,97 @@
+package ttlmap
+
+// WithStaleWhileRevalidate keeps entries for one extra
+// generation after they expire. The entries are marked stale
+// instead of removed, Load keeps returning their value and
+// LoadStale reports that the value is stale. Stale entries
+// are removed when the extra generation passes, unless they
+// are stored or touched again.
+//
+// When revalidate is not nil, loading a stale entry with Load
+// refreshes it in the background: revalidate is called once
+// per key and the result is stored in the map. Errors of
+// revalidate are discarded, the stale entry is removed at the
+// end of its extra generation. This keeps the latency flat for
+// hot keys whose values are expensive to compute.
+func WithStaleWhileRevalidate[K comparable, V any](revalidate func(key K) (V, error)) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.staleWhileRevalidate = true
+		m.revalidate = revalidate
+	}
+}
+
+// LoadStale returns the value stored in the map for a key,
+// like Load. The stale result reports whether the entry
+// expired and is kept by WithStaleWhileRevalidate. Loading a
+// stale entry with LoadStale does not revalidate it.
+func (m *TTLMap[K, V]) LoadStale(key K) (value V, stale, ok bool) {
+	key = m.key(key)
+	m.record(TraceLoad, key)
+	e, ok := m.storage().Load(key)
+	if !ok || m.hidden(e) {
+		m.miss()
+		return *new(V), false, false
+	}
+
+	if m.policies[OpLoad] != PolicyPreserve && !m.refresh(OpLoad, key, e) {
+		m.miss()
+		return *new(V), false, false
+	}
+	m.hit(e)
+	return m.value(e), e.stale.Load(), true
+}
+
+// markStale marks the entry of key stale when it is due at
+// tick, and schedules it for the next tick. It reports whether
+// the entry was marked, entries that are stale already are
+// expired instead.
+func (m *TTLMap[K, V]) markStale(key K, tick uint64) bool {
+	e, ok := m.storage().Load(key)
+	if !ok {
+		return false
+	}
+
+	expires := e.expires.Load()
+	if expires == 0 || expires > tick || !e.stale.CompareAndSwap(false, true) {
+		return false
+	}
+	if !e.expires.CompareAndSwap(expires, tick+1) {
+		// The entry was touched or claimed concurrently.
+		e.stale.Store(false)
+		return false
+	}
+	m.schedule(tick+1, key)
+	return true
+}
+
+// revalidateStale refreshes the stale entry of key in the
+// background. A refresh shares the in-flight calls of
+// LoadOrCompute, so concurrent refreshes and loads of a key
+// are deduplicated. A panic of revalidate is recovered, see
+// Err.
+func (m *TTLMap[K, V]) revalidateStale(key K) {
+	if m.revalidate == nil {
+		return
+	}
+
+	c := &loaderCall[V]{done: make(chan struct{})}
+	if _, loaded := m.calls.LoadOrStore(key, c); loaded {
+		return
+	}
+	go m.labeled(func() {
+		defer func() {
+			m.calls.Delete(key)
+			close(c.done)
+		}()
+
+		err := errLoaderPanicked
+		m.safely(func() {
+			c.value, err = m.revalidate(key)
+		})
+		if err != nil {
+			c.err = &LoaderError{Key: key, Err: err}
+			return
+		}
+		_ = m.TryStore(key, c.value)
+	})
+}
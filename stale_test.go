@@ -0,0 +1,64 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithStaleWhileRevalidate[string, string](nil))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if value, stale, ok := ttlmap.LoadStale("key"); !ok || !stale || value != "value" {
+		t.Errorf("Expected stale value 'value', but got '%s', %t and %t", value, stale, ok)
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected stale key to expire after a generation, but it did not")
+	}
+}
+
+func TestStaleWhileRevalidateRefresh(t *testing.T) {
+	refreshed := make(chan struct{})
+	ttlmap := New(2*time.Hour, time.Hour, WithStaleWhileRevalidate(func(key string) (string, error) {
+		defer close(refreshed)
+		return "fresh", nil
+	}))
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+
+	if value, ok := ttlmap.Load("key"); !ok || value != "value" {
+		t.Errorf("Expected stale value 'value', but got '%s'", value)
+	}
+	<-refreshed
+	waitFor(func() bool {
+		_, stale, _ := ttlmap.LoadStale("key")
+		return !stale
+	})
+
+	ttlmap.nextGeneration()
+	if value, stale, ok := ttlmap.LoadStale("key"); !ok || stale || value != "fresh" {
+		t.Errorf("Expected fresh value 'fresh', but got '%s', %t and %t", value, stale, ok)
+	}
+}
+
+func TestStaleWhileRevalidatePanic(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithStaleWhileRevalidate(func(key string) (string, error) {
+		panic("revalidate")
+	}))
+	defer ttlmap.Close()
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+
+	if value, ok := ttlmap.Load("key"); !ok || value != "value" {
+		t.Errorf("Expected stale value 'value', but got '%s'", value)
+	} else if !waitFor(func() bool { return ttlmap.Err() != nil }) {
+		t.Errorf("Expected the panic of revalidate to be recorded, but it was not")
+	} else if value, _ := ttlmap.LoadOrCompute("key", func(string) (string, error) { return "computed", nil }); value != "value" {
+		t.Errorf("Expected the stale value to be kept, but got '%s'", value)
+	}
+}
@@ -0,0 +1,195 @@
+package ttlmap
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// Stats contains statistics of a TTLMap, see TTLMap.Stats.
+type Stats struct {
+	// Entries is the number of entries in the map.
+	Entries int64
+	// Hits is the number of loads that found an entry, and
+	// Misses the number of loads that did not. They are
+	// estimates when WithStatsSampling is used.
+	Hits   uint64
+	Misses uint64
+	// Stores is the number of entries that were added to the
+	// map.
+	Stores uint64
+	// Deletes is the number of entries that were deleted
+	// explicitly.
+	Deletes uint64
+	// Expirations is the number of entries that expired.
+	Expirations uint64
+	// DroppedExpirations is the number of expired entries
+	// that were dropped because the expired channel was
+	// full, see WithExpiredChannel.
+	DroppedExpirations uint64
+
+	// StoreRate is the number of entries added per second
+	// during the last interval.
+	StoreRate float64
+	// ExpireRate is the number of entries that expired per
+	// second during the last interval.
+	ExpireRate float64
+
+	// Generations is the number of keys scheduled in every
+	// generation of the ring, starting with the next
+	// generation to expire. It includes keys that were
+	// touched or deleted since they were scheduled, and is nil
+	// unless the map uses a GenerationExpirer.
+	Generations []int
+
+	// Latency contains the latency histograms of the map, it
+	// is nil unless WithLatencyHistograms is used.
+	Latency *LatencyStats
+
+	// Compression contains the decisions of the compression of
+	// the map, it is nil unless WithCompression is used.
+	Compression *CompressionStats
+
+	// Loader contains the statistics of the loaders called by
+	// LoadOrCompute.
+	Loader LoaderStats
+}
+
+// HitRatio returns the fraction of loads that found an entry.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ChurnRatio returns the ratio of the expire rate to the
+// store rate. A ratio above 1 means entries expire faster
+// than they are added, which signals that the TTL is too short
+// or that keys are not reused.
+func (s Stats) ChurnRatio() float64 {
+	if s.StoreRate == 0 {
+		if s.ExpireRate == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return s.ExpireRate / s.StoreRate
+}
+
+// churn tracks the store and expire rates of a map.
+type churn struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	stores      atomic.Uint64
+	deletes     atomic.Uint64
+	expirations atomic.Uint64
+
+	// storeRate and expireRate are float64 bits, updated
+	// every tick.
+	storeRate  atomic.Uint64
+	expireRate atomic.Uint64
+
+	// lastStores and lastExpirations are the counters at the
+	// previous tick, they are guarded by advanceMu.
+	lastStores      uint64
+	lastExpirations uint64
+
+	// sampleRate is the fraction of hits and misses that are
+	// counted, and sampleThreshold its scaled form. They are
+	// 0 unless WithStatsSampling is used.
+	sampleRate      float64
+	sampleThreshold uint32
+
+	// factor and alert are set by WithChurnAlert.
+	factor float64
+	alert  func(stats Stats)
+}
+
+// WithChurnAlert calls alert after a tick in which entries
+// expired more than factor times faster than they were added.
+// Expiry outpacing insertion signals that the TTL is too short
+// or that keys are miskeyed.
+func WithChurnAlert[K comparable, V any](factor float64, alert func(stats Stats)) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.churn.factor = factor
+		m.churn.alert = alert
+	}
+}
+
+// WithStatsSampling counts only a random fraction rate of the
+// hits and misses, which keeps the load path of very busy maps
+// free of contention on the counters. Stats scales the counts
+// back up, so hit ratios remain statistically valid. The rate
+// must be in (0, 1].
+func WithStatsSampling[K comparable, V any](rate float64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.churn.sampleRate = rate
+		m.churn.sampleThreshold = uint32(rate * math.MaxUint32)
+	}
+}
+
+// sampled reports whether a hit or miss is counted.
+func (c *churn) sampled() bool {
+	return c.sampleRate == 0 || rand.Uint32() <= c.sampleThreshold
+}
+
+// scale scales a sampled count up to an estimate of the real
+// count.
+func (c *churn) scale(n uint64) uint64 {
+	if c.sampleRate == 0 {
+		return n
+	}
+	return uint64(float64(n) / c.sampleRate)
+}
+
+// Stats returns statistics of the map, collected with atomic
+// counters. The rates are updated every tick.
+func (m *TTLMap[K, V]) Stats() Stats {
+	stats := Stats{
+		Entries:     m.count.Load(),
+		Hits:        m.churn.scale(m.churn.hits.Load()),
+		Misses:      m.churn.scale(m.churn.misses.Load()),
+		Stores:      m.churn.stores.Load(),
+		Deletes:     m.churn.deletes.Load(),
+		Expirations: m.churn.expirations.Load(),
+		StoreRate:   math.Float64frombits(m.churn.storeRate.Load()),
+		ExpireRate:  math.Float64frombits(m.churn.expireRate.Load()),
+		Loader:      m.loads.stats(),
+	}
+	if m.expiredC != nil {
+		stats.DroppedExpirations = m.expiredC.dropped.Load()
+	}
+	if m.latency != nil {
+		stats.Latency = m.latency.stats()
+	}
+	if m.compression != nil {
+		stats.Compression = m.compression.stats()
+	}
+
+	m.mu.Lock()
+	if g, ok := m.expirer.(*GenerationExpirer[K]); ok {
+		stats.Generations = g.sizes()
+	}
+	m.mu.Unlock()
+	return stats
+}
+
+// updateChurn updates the rates after a tick, and calls the
+// churn alert when expiry outpaces insertion. The caller must
+// hold advanceMu.
+func (m *TTLMap[K, V]) updateChurn() {
+	c := &m.churn
+	stores, expirations := c.stores.Load(), c.expirations.Load()
+	seconds := m.tickInterval().Seconds()
+	c.storeRate.Store(math.Float64bits(float64(stores-c.lastStores) / seconds))
+	c.expireRate.Store(math.Float64bits(float64(expirations-c.lastExpirations) / seconds))
+	c.lastStores, c.lastExpirations = stores, expirations
+
+	if c.alert != nil {
+		stats := m.Stats()
+		if stats.ExpireRate > 0 && stats.ExpireRate > c.factor*stats.StoreRate {
+			c.alert(stats)
+		}
+	}
+}
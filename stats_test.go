@@ -0,0 +1,70 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	var alerts []Stats
+	ttlmap := New(2*time.Hour, time.Hour, WithChurnAlert[string, string](1, func(stats Stats) {
+		alerts = append(alerts, stats)
+	}))
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+
+	if stats := ttlmap.Stats(); stats.Stores != 2 || stats.Entries != 2 {
+		t.Errorf("Expected 2 stores and 2 entries, but got %d and %d", stats.Stores, stats.Entries)
+	} else if stats.StoreRate != 2.0/3600 {
+		t.Errorf("Expected store rate to be %f, but was %f", 2.0/3600, stats.StoreRate)
+	} else if stats.ChurnRatio() != 0 {
+		t.Errorf("Expected churn ratio to be 0, but was %f", stats.ChurnRatio())
+	} else if len(alerts) != 0 {
+		t.Errorf("Expected no alerts, but got %d", len(alerts))
+	}
+
+	ttlmap.Store("key3", "value3")
+	ttlmap.nextGeneration()
+	if stats := ttlmap.Stats(); stats.Expirations != 2 || stats.ChurnRatio() != 2 {
+		t.Errorf("Expected 2 expirations and a churn ratio of 2, but got %d and %f", stats.Expirations, stats.ChurnRatio())
+	} else if len(alerts) != 1 {
+		t.Errorf("Expected 1 alert, but got %d", len(alerts))
+	}
+}
+
+func TestStatsCounters(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.Load("key1")
+	ttlmap.Load("missing")
+	ttlmap.Delete("key2")
+
+	stats := ttlmap.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, but got %d and %d", stats.Hits, stats.Misses)
+	} else if stats.Deletes != 1 {
+		t.Errorf("Expected 1 delete, but got %d", stats.Deletes)
+	} else if stats.HitRatio() != 0.5 {
+		t.Errorf("Expected hit ratio to be 0.5, but was %f", stats.HitRatio())
+	} else if len(stats.Generations) != 2 || stats.Generations[1] != 2 {
+		t.Errorf("Expected 2 keys in the last generation, but got %v", stats.Generations)
+	}
+}
+
+func TestWithStatsSampling(t *testing.T) {
+	ttlmap := New(time.Hour, time.Hour, WithStatsSampling[string, string](0.1))
+	ttlmap.Store("key", "value")
+	for i := 0; i < 10000; i++ {
+		ttlmap.Load("key")
+		ttlmap.Load("missing")
+	}
+
+	stats := ttlmap.Stats()
+	if ratio := stats.HitRatio(); ratio < 0.4 || ratio > 0.6 {
+		t.Errorf("Expected hit ratio to be about 0.5, but was %f", ratio)
+	} else if stats.Hits < 8000 || stats.Hits > 12000 {
+		t.Errorf("Expected about 10000 hits, but got %d", stats.Hits)
+	}
+}
@@ -0,0 +1,275 @@
+package ttlmap
+
+import (
+	"hash/maphash"
+	"runtime"
+	"sync"
+)
+
+// storageEngine stores the entries of a map. Its methods have
+// the semantics of the methods of sync.Map, but they are typed,
+// so engines can store keys and entries without boxing them in
+// interfaces.
+type storageEngine[K comparable, V any] interface {
+	Load(key K) (e *entry[V], ok bool)
+	Store(key K, e *entry[V])
+	LoadOrStore(key K, e *entry[V]) (actual *entry[V], loaded bool)
+	LoadAndDelete(key K) (e *entry[V], loaded bool)
+	Delete(key K)
+	Swap(key K, e *entry[V]) (previous *entry[V], loaded bool)
+	CompareAndSwap(key K, old, new *entry[V]) (swapped bool)
+	CompareAndDelete(key K, old *entry[V]) (deleted bool)
+	Range(f func(key K, e *entry[V]) bool)
+}
+
+// syncMap is the default storageEngine, a sync.Map. It boxes
+// every key it stores.
+type syncMap[K comparable, V any] struct {
+	m sync.Map
+}
+
+// newSyncMap creates an empty syncMap.
+func newSyncMap[K comparable, V any]() storageEngine[K, V] {
+	return &syncMap[K, V]{}
+}
+
+// Load implements storageEngine.
+func (s *syncMap[K, V]) Load(key K) (*entry[V], bool) {
+	val, ok := s.m.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return val.(*entry[V]), true
+}
+
+// Store implements storageEngine.
+func (s *syncMap[K, V]) Store(key K, e *entry[V]) {
+	s.m.Store(key, e)
+}
+
+// LoadOrStore implements storageEngine.
+func (s *syncMap[K, V]) LoadOrStore(key K, e *entry[V]) (*entry[V], bool) {
+	actual, loaded := s.m.LoadOrStore(key, e)
+	return actual.(*entry[V]), loaded
+}
+
+// LoadAndDelete implements storageEngine.
+func (s *syncMap[K, V]) LoadAndDelete(key K) (*entry[V], bool) {
+	val, loaded := s.m.LoadAndDelete(key)
+	if !loaded {
+		return nil, false
+	}
+	return val.(*entry[V]), true
+}
+
+// Delete implements storageEngine.
+func (s *syncMap[K, V]) Delete(key K) {
+	s.m.Delete(key)
+}
+
+// Swap implements storageEngine.
+func (s *syncMap[K, V]) Swap(key K, e *entry[V]) (*entry[V], bool) {
+	previous, loaded := s.m.Swap(key, e)
+	if !loaded {
+		return nil, false
+	}
+	return previous.(*entry[V]), true
+}
+
+// CompareAndSwap implements storageEngine.
+func (s *syncMap[K, V]) CompareAndSwap(key K, old, new *entry[V]) bool {
+	return s.m.CompareAndSwap(key, old, new)
+}
+
+// CompareAndDelete implements storageEngine.
+func (s *syncMap[K, V]) CompareAndDelete(key K, old *entry[V]) bool {
+	return s.m.CompareAndDelete(key, old)
+}
+
+// Range implements storageEngine.
+func (s *syncMap[K, V]) Range(f func(key K, e *entry[V]) bool) {
+	s.m.Range(func(key, val any) bool {
+		return f(key.(K), val.(*entry[V]))
+	})
+}
+
+// WithShardedStorage stores the entries in a sharded Go map,
+// instead of a sync.Map. Keys are spread over shards by their
+// maphash, every shard is guarded by its own mutex.
+//
+// A sync.Map is optimized for keys that are written once and
+// read many times, and allocates on every write. The sharded
+// map is faster when keys are overwritten or touched often,
+// like in a rate limiter that writes on every request. Reads
+// take a read lock, so read-mostly workloads with many cores
+// are faster with a sync.Map. See BenchmarkStorage.
+//
+// The sharded map is typed, it stores keys and entries without
+// boxing them in interfaces. Unlike a sync.Map, which boxes the
+// key on every write, it doesn't allocate when an existing key
+// is stored again, see BenchmarkStorageAllocs. The map itself
+// still allocates an entry for every stored value.
+//
+// The number of shards is rounded up to a power of two, zero
+// picks a number based on GOMAXPROCS.
+func WithShardedStorage[K comparable, V any](shards int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.newStorage = func() storageEngine[K, V] {
+			return newShardedMap[K, V](shards)
+		}
+	}
+}
+
+// shardedMap is a storageEngine that spreads keys over Go maps
+// that are guarded by a mutex each.
+type shardedMap[K comparable, V any] struct {
+	seed   maphash.Seed
+	mask   uint64
+	shards []shard[K, V]
+}
+
+// shard is a part of a shardedMap.
+type shard[K comparable, V any] struct {
+	mu    sync.RWMutex
+	items map[K]*entry[V]
+
+	// Pad the shard to a cache line, so shards that are
+	// next to each other don't contend.
+	_ [32]byte
+}
+
+// newShardedMap creates a shardedMap with at least n shards.
+func newShardedMap[K comparable, V any](n int) *shardedMap[K, V] {
+	if n <= 0 {
+		n = 4 * runtime.GOMAXPROCS(0)
+	}
+	size := 1
+	for size < n {
+		size *= 2
+	}
+
+	s := &shardedMap[K, V]{
+		seed:   maphash.MakeSeed(),
+		mask:   uint64(size - 1),
+		shards: make([]shard[K, V], size),
+	}
+	for i := range s.shards {
+		s.shards[i].items = make(map[K]*entry[V])
+	}
+	return s
+}
+
+// shard returns the shard of key.
+func (s *shardedMap[K, V]) shard(key K) *shard[K, V] {
+	return &s.shards[maphash.Comparable(s.seed, key)&s.mask]
+}
+
+// Load returns the entry of key.
+func (s *shardedMap[K, V]) Load(key K) (*entry[V], bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	e, ok := sh.items[key]
+	sh.mu.RUnlock()
+	return e, ok
+}
+
+// Store sets the entry of key.
+func (s *shardedMap[K, V]) Store(key K, e *entry[V]) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	sh.items[key] = e
+	sh.mu.Unlock()
+}
+
+// LoadOrStore returns the entry of key if present, otherwise
+// it stores e.
+func (s *shardedMap[K, V]) LoadOrStore(key K, e *entry[V]) (*entry[V], bool) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if actual, ok := sh.items[key]; ok {
+		return actual, true
+	}
+	sh.items[key] = e
+	return e, false
+}
+
+// LoadAndDelete deletes key, and returns its previous entry.
+func (s *shardedMap[K, V]) LoadAndDelete(key K) (*entry[V], bool) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	e, ok := sh.items[key]
+	delete(sh.items, key)
+	return e, ok
+}
+
+// Delete deletes key.
+func (s *shardedMap[K, V]) Delete(key K) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	delete(sh.items, key)
+	sh.mu.Unlock()
+}
+
+// Swap sets the entry of key, and returns its previous entry.
+func (s *shardedMap[K, V]) Swap(key K, e *entry[V]) (*entry[V], bool) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	previous, ok := sh.items[key]
+	sh.items[key] = e
+	return previous, ok
+}
+
+// CompareAndSwap sets the entry of key to new, if its entry is
+// old.
+func (s *shardedMap[K, V]) CompareAndSwap(key K, old, new *entry[V]) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if e, ok := sh.items[key]; !ok || e != old {
+		return false
+	}
+	sh.items[key] = new
+	return true
+}
+
+// CompareAndDelete deletes key, if its entry is old.
+func (s *shardedMap[K, V]) CompareAndDelete(key K, old *entry[V]) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if e, ok := sh.items[key]; !ok || e != old {
+		return false
+	}
+	delete(sh.items, key)
+	return true
+}
+
+// Range calls f for every key and entry. Like the Range of a
+// sync.Map, it is not a consistent snapshot: every shard is
+// copied before f is called for its entries, so f may modify
+// the map.
+func (s *shardedMap[K, V]) Range(f func(key K, e *entry[V]) bool) {
+	type kv struct {
+		key K
+		e   *entry[V]
+	}
+	var buf []kv
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		buf = buf[:0]
+		for k, v := range sh.items {
+			buf = append(buf, kv{k, v})
+		}
+		sh.mu.RUnlock()
+
+		for _, kv := range buf {
+			if !f(kv.key, kv.e) {
+				return
+			}
+		}
+	}
+}
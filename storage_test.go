@@ -0,0 +1,177 @@
+package ttlmap
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShardedMap(t *testing.T) {
+	s := newShardedMap[string, string](3)
+	if len(s.shards) != 4 {
+		t.Errorf("Expected 4 shards, but got %d", len(s.shards))
+	}
+
+	a, b, c := &entry[string]{value: "a"}, &entry[string]{value: "b"}, &entry[string]{value: "c"}
+	s.Store("key", a)
+	if e, ok := s.Load("key"); !ok || e != a {
+		t.Errorf("Expected to load entry a, but got %v", e)
+	} else if actual, loaded := s.LoadOrStore("key", b); !loaded || actual != a {
+		t.Errorf("Expected LoadOrStore to load entry a, but got %v", actual)
+	} else if s.CompareAndSwap("key", b, c) {
+		t.Errorf("Expected CompareAndSwap with wrong old entry to fail, but it swapped")
+	} else if !s.CompareAndSwap("key", a, b) {
+		t.Errorf("Expected CompareAndSwap to swap, but it did not")
+	} else if previous, loaded := s.Swap("key", c); !loaded || previous != b {
+		t.Errorf("Expected Swap to return entry b, but got %v", previous)
+	} else if s.CompareAndDelete("key", b) {
+		t.Errorf("Expected CompareAndDelete with wrong old entry to fail, but it deleted")
+	} else if !s.CompareAndDelete("key", c) {
+		t.Errorf("Expected CompareAndDelete to delete, but it did not")
+	} else if _, ok := s.Load("key"); ok {
+		t.Errorf("Expected key to be deleted, but it was not")
+	}
+
+	s.Store("key", a)
+	if e, loaded := s.LoadAndDelete("key"); !loaded || e != a {
+		t.Errorf("Expected LoadAndDelete to return entry a, but got %v", e)
+	} else if _, loaded := s.LoadAndDelete("key"); loaded {
+		t.Errorf("Expected second LoadAndDelete to find nothing, but it did")
+	}
+}
+
+func TestShardedMapRange(t *testing.T) {
+	s := newShardedMap[int, string](0)
+	for i := 0; i < 100; i++ {
+		s.Store(i, &entry[string]{})
+	}
+
+	// Deleting during Range must not deadlock.
+	n := 0
+	s.Range(func(key int, e *entry[string]) bool {
+		s.Delete(key)
+		n++
+		return true
+	})
+	if n != 100 {
+		t.Errorf("Expected Range to visit 100 keys, but visited %d", n)
+	}
+
+	s.Store(1, &entry[string]{})
+	s.Store(2, &entry[string]{})
+	n = 0
+	s.Range(func(key int, e *entry[string]) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Expected Range to stop after 1 key, but visited %d", n)
+	}
+}
+
+func TestShardedStorage(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithShardedStorage[string, string](0))
+	defer ttlmap.Close()
+	if _, ok := ttlmap.storage().(*shardedMap[string, string]); !ok {
+		t.Fatalf("Expected sharded storage, but got %T", ttlmap.storage())
+	}
+
+	ttlmap.Store("key", "value")
+	ttlmap.ReplaceAll(map[string]string{"other": "value"})
+	if _, ok := ttlmap.storage().(*shardedMap[string, string]); !ok {
+		t.Errorf("Expected sharded storage after ReplaceAll, but got %T", ttlmap.storage())
+	} else if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to be replaced, but it was not")
+	} else if value, ok := ttlmap.Load("other"); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	}
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if ttlmap.Len() != 0 {
+		t.Errorf("Expected map to be empty, but had %d entries", ttlmap.Len())
+	}
+}
+
+// storages contains an option for every storage engine.
+var storages = map[string]Option[string, string]{
+	"sync.Map": func(*TTLMap[string, string]) {},
+	"sharded":  WithShardedStorage[string, string](0),
+}
+
+// BenchmarkStorage compares the storage engines, from
+// goroutines that load and store random keys of a small key
+// space. writes is the percentage of operations that store.
+func BenchmarkStorage(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	for _, writes := range []int{0, 10, 50, 100} {
+		for name, opt := range storages {
+			b.Run(name+"/writes="+strconv.Itoa(writes), func(b *testing.B) {
+				ttlmap := New(time.Hour, time.Minute, opt)
+				defer ttlmap.Close()
+				for _, key := range keys {
+					ttlmap.Store(key, "value")
+				}
+
+				var seed atomic.Uint64
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					// xorshift, so the key selection doesn't
+					// contend.
+					x := seed.Add(1) * 0x9e3779b97f4a7c15
+					for pb.Next() {
+						x ^= x << 13
+						x ^= x >> 7
+						x ^= x << 17
+						key := keys[x%uint64(len(keys))]
+						if int(x>>32%100) < writes {
+							ttlmap.Store(key, "value")
+						} else {
+							ttlmap.Load(key)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkStorageAllocs measures the allocations of the
+// storage engines alone, when keys are stored again. The typed
+// sharded map stores them without allocating, a sync.Map boxes
+// the key on every store.
+func BenchmarkStorageAllocs(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	engines := map[string]func() storageEngine[string, string]{
+		"sync.Map": newSyncMap[string, string],
+		"sharded": func() storageEngine[string, string] {
+			return newShardedMap[string, string](0)
+		},
+	}
+
+	for name, newStorage := range engines {
+		b.Run(name, func(b *testing.B) {
+			s := newStorage()
+			e := &entry[string]{}
+			for _, key := range keys {
+				s.Store(key, e)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := keys[i%len(keys)]
+				s.Store(key, e)
+				s.Load(key)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package ttlmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithStrict enables runtime checks of the invariants of the
+// map, which panic with a dump of the state of the map when
+// they are violated. The checks catch integration bugs that
+// the map otherwise tolerates silently:
+//
+//   - Operations on a map after it was closed.
+//   - Entries that are removed from the map twice.
+//   - Counters of entries and bytes that differ from the
+//     contents of the map.
+//   - Entries that outlive their deadline, because their key
+//     was not scheduled in the generation of the deadline.
+//   - Keys of an indexed GenerationExpirer that are not in
+//     exactly one generation.
+//
+// The checks of the expiration only apply to maps that use a
+// GenerationExpirer, which is the default. The map is scanned
+// on every tick in which no other goroutine modifies it, which
+// makes strict mode too slow for production. Run it in tests
+// and staging.
+func WithStrict[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.strict = true
+	}
+}
+
+// checkOpen panics in strict mode when the map is used after
+// it was closed.
+func (m *TTLMap[K, V]) checkOpen(op string) {
+	if m.strict && m.closed.Load() {
+		m.violated(fmt.Sprintf("%s after Close", op))
+	}
+}
+
+// operation marks a modification of the map in strict mode,
+// the returned function must be called when it is done. The
+// checks that scan the map are skipped while modifications
+// are in progress, their state is inconsistent.
+func (m *TTLMap[K, V]) operation() func() {
+	if !m.strict {
+		return func() {}
+	}
+	m.started.Add(1)
+	return func() { m.finished.Add(1) }
+}
+
+// checkRemoved panics in strict mode when e is removed from
+// the map twice.
+func (m *TTLMap[K, V]) checkRemoved(key K, e *entry[V]) {
+	if m.strict && e.removed.Swap(true) {
+		m.violated(fmt.Sprintf("entry for key %v removed twice", key))
+	}
+}
+
+// checkGeneration panics in strict mode when the map is
+// inconsistent after it advanced to tick.
+func (m *TTLMap[K, V]) checkGeneration(tick uint64) {
+	if !m.strict {
+		return
+	}
+
+	m.mu.Lock()
+	g, ok := m.expirer.(*GenerationExpirer[K])
+	var violation string
+	if ok {
+		violation = g.check()
+	}
+	m.mu.Unlock()
+	if violation != "" {
+		m.violated(violation)
+	}
+
+	started := m.started.Load()
+	if m.finished.Load() != started {
+		return
+	}
+	var count, bytes int64
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		count++
+		bytes += m.sizeOf(key, e)
+
+		// Entries that were scheduled late are expired when
+		// the generation of their deadline comes around
+		// again. Stale entries are kept past their deadline
+		// on purpose. Other expirers might expire entries
+		// later, like a MultiResolutionExpirer.
+		if expires := e.expires.Load(); ok && expires != 0 && expires < tick && !e.stale.Load() {
+			m.mu.Lock()
+			scheduled := g.scheduled(key, expires)
+			m.mu.Unlock()
+			if !scheduled {
+				violation = fmt.Sprintf("entry for key %v outlived its deadline %d", key, expires)
+			}
+		}
+		return violation == ""
+	})
+	if m.started.Load() != started {
+		// The map was modified during the scan.
+		return
+	}
+
+	if violation != "" {
+		m.violated(violation)
+	} else if n := m.count.Load(); n != count {
+		m.violated(fmt.Sprintf("entry count is %d, but the map has %d entries", n, count))
+	} else if n := m.bytes.Load(); n != bytes {
+		m.violated(fmt.Sprintf("byte count is %d, but the entries have %d bytes", n, bytes))
+	}
+}
+
+// check returns a description of the first inconsistency of
+// an indexed expirer, or an empty string.
+func (g *GenerationExpirer[K]) check() string {
+	if g.index == nil {
+		// Keys of an unindexed expirer are in several
+		// generations by design.
+		return ""
+	}
+
+	keys := 0
+	for slot, gen := range g.generations {
+		for i, key := range gen {
+			if pos, ok := g.index[key]; !ok || pos.round || pos.slot != uint64(slot) || pos.i != i {
+				return fmt.Sprintf("key %v in generation %d is indexed at %+v", key, slot, pos)
+			}
+		}
+		keys += len(gen)
+	}
+	for slot, round := range g.rounds {
+		for i, k := range round {
+			if pos, ok := g.index[k.key]; !ok || !pos.round || pos.slot != slot || pos.i != i {
+				return fmt.Sprintf("key %v in round %d is indexed at %+v", k.key, slot, pos)
+			}
+		}
+		keys += len(round)
+	}
+	if keys != len(g.index) {
+		return fmt.Sprintf("%d keys in generations, but %d indexed", keys, len(g.index))
+	}
+	return ""
+}
+
+// scheduled reports whether key is in the generation of
+// deadline.
+func (g *GenerationExpirer[K]) scheduled(key K, deadline uint64) bool {
+	gen := g.slot(deadline)
+	if g.index != nil {
+		pos, ok := g.index[key]
+		return ok && !pos.round && pos.slot == gen
+	}
+	for _, k := range g.generations[gen] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// violated panics with a description of the violated
+// invariant and a dump of the state of the map.
+func (m *TTLMap[K, V]) violated(violation string) {
+	var dump strings.Builder
+	fmt.Fprintf(&dump, "ttlmap: strict: %s\n", violation)
+	fmt.Fprintf(&dump, "\ttick: %d\n", m.tick.Load())
+	fmt.Fprintf(&dump, "\tentries: %d\n", m.count.Load())
+	fmt.Fprintf(&dump, "\tbytes: %d\n", m.bytes.Load())
+	fmt.Fprintf(&dump, "\tclosed: %t\n", m.closed.Load())
+	if m.mu.TryLock() {
+		if g, ok := m.expirer.(*GenerationExpirer[K]); ok {
+			fmt.Fprintf(&dump, "\tgenerations: %v\n", g.sizes())
+			fmt.Fprintf(&dump, "\trounds: %d\n", len(g.rounds))
+		}
+		m.mu.Unlock()
+	}
+	panic(dump.String())
+}
@@ -0,0 +1,65 @@
+package ttlmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithStrict(t *testing.T) {
+	ttlmap := newTTLMap(2*time.Hour, time.Hour, WithStrict[string, string](), WithExpirer[string, string](NewIndexedGenerationExpirer[string](2)))
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key1", "value2")
+	ttlmap.Store("key2", "value2")
+	ttlmap.Delete("key2")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+
+	if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected key1 to expire, but it is present")
+	}
+}
+
+func TestWithStrictViolations(t *testing.T) {
+	violation := func(f func()) (msg string) {
+		defer func() {
+			msg, _ = recover().(string)
+		}()
+		f()
+		return ""
+	}
+
+	ttlmap := New(2*time.Hour, time.Hour, WithStrict[string, string]())
+	ttlmap.Close()
+	if msg := violation(func() { ttlmap.Store("key", "value") }); !strings.Contains(msg, "Store after Close") {
+		t.Errorf("Expected a use after close violation, but got %q", msg)
+	}
+
+	ttlmap = newTTLMap(2*time.Hour, time.Hour, WithStrict[string, string]())
+	ttlmap.Store("key", "value")
+	e, _ := ttlmap.storage().Load("key")
+	ttlmap.removed("key", e, EvictionDeleted)
+	if msg := violation(func() { ttlmap.removed("key", e, EvictionDeleted) }); !strings.Contains(msg, "removed twice") {
+		t.Errorf("Expected a double removal violation, but got %q", msg)
+	}
+
+	ttlmap = newTTLMap(2*time.Hour, time.Hour, WithStrict[string, string]())
+	ttlmap.Store("key", "value")
+	ttlmap.mu.Lock()
+	ttlmap.expirer.Reset()
+	ttlmap.mu.Unlock()
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if msg := violation(ttlmap.nextGeneration); !strings.Contains(msg, "outlived its deadline") {
+		t.Errorf("Expected a deadline violation, but got %q", msg)
+	} else if !strings.Contains(msg, "tick: 3") {
+		t.Errorf("Expected a dump of the map, but got %q", msg)
+	}
+
+	ttlmap = newTTLMap(2*time.Hour, time.Hour, WithStrict[string, string]())
+	ttlmap.Store("key", "value")
+	ttlmap.count.Add(1)
+	if msg := violation(ttlmap.nextGeneration); !strings.Contains(msg, "entry count is 2") {
+		t.Errorf("Expected a counter violation, but got %q", msg)
+	}
+}
@@ -0,0 +1,61 @@
+package ttlmap
+
+// WithStrictTTL makes entries never expire before their TTL.
+// By default an entry expires at the tick of its deadline,
+// which is up to one interval before its TTL passed when it
+// was stored late in an interval. With strict TTL the map
+// records the time at which the TTL of an entry starts, and
+// the sweep keeps entries that are not due yet until the next
+// tick. Entries then expire up to one interval late instead.
+//
+// The timestamp costs a clock read per store and touch. The
+// deadlines of WithJitter are kept, entries expire at the
+// jittered deadline, never before it.
+func WithStrictTTL[K comparable, V any]() Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.strictTTL = true
+	}
+}
+
+// stamp records that the TTL of e starts now, in strict TTL
+// mode.
+func (m *TTLMap[K, V]) stamp(e *entry[V]) {
+	if m.strictTTL {
+		e.stamped.Store(m.clock.Now().UnixNano())
+	}
+}
+
+// postpone keeps the entry for key until the next tick, when
+// it is due at tick but its TTL did not pass yet. It reports
+// whether the entry was kept. The deadline of an entry is the
+// tick in which its TTL passes, the time into the interval at
+// which it was stamped pins when.
+func (m *TTLMap[K, V]) postpone(key K, tick uint64) bool {
+	e, ok := m.storage().Load(key)
+	if !ok {
+		return false
+	}
+
+	stamped := e.stamped.Load()
+	if stamped == 0 || e.expires.Load() != tick {
+		return false
+	}
+	root := m
+	for root.parent != nil {
+		root = root.parent
+	}
+	interval := int64(m.tickInterval())
+	if (stamped-root.epoch.Load())%interval == 0 {
+		// The entry was stamped at the start of a tick, its
+		// TTL passes exactly now.
+		return false
+	}
+
+	// The stamp is cleared, so the entry expires at the next
+	// tick. Stores and touches stamp it again.
+	if !e.stamped.CompareAndSwap(stamped, 0) || !e.expires.CompareAndSwap(tick, tick+1) {
+		return false
+	}
+	m.schedule(tick+1, key)
+	return true
+}
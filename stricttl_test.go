@@ -0,0 +1,55 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithStrictTTL(t *testing.T) {
+	start := time.Now()
+	clock := &stoppedClock{now: start}
+	ttlmap := New(2*time.Second, time.Second,
+		WithClock[string, string](clock),
+		WithStrictTTL[string, string]())
+	defer ttlmap.Close()
+	ttlmap.Store("start", "value")
+	clock.now = start.Add(500 * time.Millisecond)
+	ttlmap.Store("late", "value")
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("start"); ok {
+		t.Errorf("Expected key stored at a tick to expire after its ttl, but it did not")
+	} else if _, ok := ttlmap.Load("late"); !ok {
+		t.Errorf("Expected key stored late to be kept until its ttl passed, but it expired")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("late"); ok {
+		t.Errorf("Expected key stored late to expire at the next tick, but it did not")
+	}
+}
+
+func TestWithStrictTTLTouch(t *testing.T) {
+	start := time.Now()
+	clock := &stoppedClock{now: start}
+	ttlmap := New(2*time.Second, time.Second,
+		WithClock[string, string](clock),
+		WithStrictTTL[string, string]())
+	defer ttlmap.Close()
+	clock.now = start.Add(500 * time.Millisecond)
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+	clock.now = start.Add(1500 * time.Millisecond)
+	ttlmap.Touch("key")
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected touched key to be kept until its ttl passed, but it expired")
+	}
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected touched key to expire, but it did not")
+	}
+}
@@ -0,0 +1,182 @@
+// Package tokencache caches credentials that expire, like
+// OAuth2 access tokens or the per-RPC credentials of gRPC
+// clients. Tokens are cached until the expiry they carry, are
+// refreshed in the background before they expire, and
+// concurrent misses share a single fetch. It has no
+// dependencies, an oauth2.TokenSource is wrapped like this:
+//
+//	cache := tokencache.New(func(ctx context.Context, _ string) (tokencache.Token[*oauth2.Token], error) {
+//		t, err := source.Token()
+//		if err != nil {
+//			return tokencache.Token[*oauth2.Token]{}, err
+//		}
+//		return tokencache.Token[*oauth2.Token]{Value: t, Expiry: t.Expiry}, nil
+//	}, tokencache.Options{})
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Token is a credential with the time at which it expires.
+type Token[T any] struct {
+	Value T
+	// Expiry is the time at which the token expires, the zero
+	// time means it is cached for the TTL of the cache.
+	Expiry time.Time
+}
+
+// Fetch fetches a new token for key.
+type Fetch[K comparable, T any] func(ctx context.Context, key K) (Token[T], error)
+
+// Options configures a Cache.
+type Options struct {
+	// RefreshBefore is the time before the expiry of a token
+	// at which it is refreshed in the background, it is one
+	// minute when 0.
+	RefreshBefore time.Duration
+	// TTL is the time tokens without expiry are cached, it is
+	// one hour when 0.
+	TTL time.Duration
+	// Interval is the precision of the expiry of tokens, it is
+	// one second when 0. See ttlmap.New.
+	Interval time.Duration
+	// Clock is the source of time of the cache, it is the
+	// system clock when nil.
+	Clock ttlmap.Clock
+}
+
+// Cache caches the tokens of keys, like the tokens of
+// different audiences or scopes.
+type Cache[K comparable, T any] struct {
+	tokens        *ttlmap.TTLMap[K, Token[T]]
+	fetch         Fetch[K, T]
+	refreshBefore time.Duration
+	ttl           time.Duration
+	clock         ttlmap.Clock // nil for the system clock
+
+	// fetches shares the running fetches of keys, with
+	// LoadOrCompute. It admits no entries, fetched tokens are
+	// stored in tokens with their own expiry.
+	fetches *ttlmap.TTLMap[K, Token[T]]
+}
+
+// fetched is the result of a fetch.
+type fetched[T any] struct {
+	token Token[T]
+	err   error
+}
+
+// New creates a Cache that fetches tokens with fetch.
+func New[K comparable, T any](fetch Fetch[K, T], opts Options) *Cache[K, T] {
+	if opts.RefreshBefore <= 0 {
+		opts.RefreshBefore = time.Minute
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Hour
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+
+	tokenOpts := []ttlmap.Option[K, Token[T]]{ttlmap.WithLazyExpiry[K, Token[T]]()}
+	fetchOpts := []ttlmap.Option[K, Token[T]]{ttlmap.WithAdmission(func(K, Token[T]) bool {
+		return false
+	})}
+	if opts.Clock != nil {
+		tokenOpts = append(tokenOpts, ttlmap.WithClock[K, Token[T]](opts.Clock))
+		fetchOpts = append(fetchOpts, ttlmap.WithClock[K, Token[T]](opts.Clock))
+	}
+	return &Cache[K, T]{
+		tokens:        ttlmap.New(opts.TTL, opts.Interval, tokenOpts...),
+		fetches:       ttlmap.New(opts.TTL, opts.Interval, fetchOpts...),
+		fetch:         fetch,
+		refreshBefore: opts.RefreshBefore,
+		ttl:           opts.TTL,
+		clock:         opts.Clock,
+	}
+}
+
+// Token returns the token of key. A cached token is returned
+// until it expires, a token that expires within RefreshBefore
+// is refreshed in the background. When no token is cached, it
+// is fetched, concurrent callers wait for the same fetch until
+// ctx is done. Errors of fetch are not cached.
+func (c *Cache[K, T]) Token(ctx context.Context, key K) (T, error) {
+	now := c.now()
+	if token, ok := c.tokens.Load(key); ok && (token.Expiry.IsZero() || now.Before(token.Expiry)) {
+		if !token.Expiry.IsZero() && token.Expiry.Sub(now) <= c.refreshBefore {
+			c.start(ctx, key)
+		}
+		return token.Value, nil
+	}
+
+	done := c.start(ctx, key)
+	select {
+	case f := <-done:
+		return f.token.Value, f.err
+	case <-ctx.Done():
+		return *new(T), ctx.Err()
+	}
+}
+
+// Invalidate removes the cached token of key, like after the
+// token was rejected by a server. The next call of Token
+// fetches a new one.
+func (c *Cache[K, T]) Invalidate(key K) {
+	c.tokens.Delete(key)
+}
+
+// Close stops the ticker of the cache.
+func (c *Cache[K, T]) Close() {
+	c.tokens.Close()
+	c.fetches.Close()
+}
+
+// start starts a fetch of the token of key, or joins the
+// running one, and returns a channel that receives its result.
+// The fetch is shared by callers, so it is not canceled with
+// ctx.
+func (c *Cache[K, T]) start(ctx context.Context, key K) <-chan fetched[T] {
+	done := make(chan fetched[T], 1)
+	go func() {
+		token, err := c.fetches.LoadOrCompute(key, func(key K) (Token[T], error) {
+			return c.refresh(context.WithoutCancel(ctx), key)
+		})
+		// Callers get the error of fetch, not the error of
+		// the map.
+		var loaderErr *ttlmap.LoaderError
+		if errors.As(err, &loaderErr) {
+			err = loaderErr.Err
+		}
+		done <- fetched[T]{token, err}
+	}()
+	return done
+}
+
+// refresh fetches the token of key, and caches it until its
+// expiry.
+func (c *Cache[K, T]) refresh(ctx context.Context, key K) (Token[T], error) {
+	token, err := c.fetch(ctx, key)
+	if err != nil {
+		return token, err
+	}
+	if token.Expiry.IsZero() {
+		c.tokens.StoreWithTTL(key, token, c.ttl)
+	} else if token.Expiry.After(c.now()) {
+		c.tokens.StoreUntil(key, token, token.Expiry)
+	}
+	return token, nil
+}
+
+// now returns the time of the clock of the cache.
+func (c *Cache[K, T]) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
@@ -0,0 +1,129 @@
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap/ttlmaptest"
+)
+
+func TestToken(t *testing.T) {
+	clock := ttlmaptest.NewClock(time.Now())
+	var fetches atomic.Int32
+	cache := New(func(ctx context.Context, key string) (Token[string], error) {
+		n := fetches.Add(1)
+		return Token[string]{Value: key + strconv.Itoa(int(n)), Expiry: clock.Now().Add(10 * time.Minute)}, nil
+	}, Options{Clock: clock})
+	defer cache.Close()
+
+	ctx := context.Background()
+	if token, err := cache.Token(ctx, "a"); err != nil || token != "a1" {
+		t.Errorf("Expected token 'a1', but got '%s' (%v)", token, err)
+	} else if token, err := cache.Token(ctx, "a"); err != nil || token != "a1" {
+		t.Errorf("Expected cached token 'a1', but got '%s' (%v)", token, err)
+	} else if fetches.Load() != 1 {
+		t.Errorf("Expected 1 fetch, but got %d", fetches.Load())
+	}
+
+	// The token expires within RefreshBefore, it is served
+	// while it is refreshed.
+	clock.Advance(9*time.Minute + 30*time.Second)
+	if token, err := cache.Token(ctx, "a"); err != nil || token != "a1" {
+		t.Errorf("Expected cached token 'a1' during the refresh, but got '%s' (%v)", token, err)
+	}
+	for i := 0; i < 100 && fetches.Load() != 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		if token, _ := cache.Token(ctx, "a"); token == "a2" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if token, err := cache.Token(ctx, "a"); err != nil || token != "a2" {
+		t.Errorf("Expected refreshed token 'a2', but got '%s' (%v)", token, err)
+	}
+
+	cache.Invalidate("a")
+	if token, err := cache.Token(ctx, "a"); err != nil || token != "a3" {
+		t.Errorf("Expected new token 'a3' after Invalidate, but got '%s' (%v)", token, err)
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	clock := ttlmaptest.NewClock(time.Now())
+	var fetches atomic.Int32
+	cache := New(func(ctx context.Context, key string) (Token[int], error) {
+		return Token[int]{Value: int(fetches.Add(1)), Expiry: clock.Now().Add(time.Minute)}, nil
+	}, Options{Clock: clock, RefreshBefore: time.Second})
+	defer cache.Close()
+
+	ctx := context.Background()
+	if token, _ := cache.Token(ctx, "key"); token != 1 {
+		t.Errorf("Expected token 1, but got %d", token)
+	}
+	clock.Advance(2 * time.Minute)
+	if token, _ := cache.Token(ctx, "key"); token != 2 {
+		t.Errorf("Expected expired token to be fetched again, but got %d", token)
+	}
+}
+
+func TestTokenSingleFetch(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	cache := New(func(ctx context.Context, key string) (Token[string], error) {
+		fetches.Add(1)
+		<-release
+		return Token[string]{Value: "token"}, nil
+	}, Options{})
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := cache.Token(context.Background(), "key"); err != nil || token != "token" {
+				t.Errorf("Expected token 'token', but got '%s' (%v)", token, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if fetches.Load() != 1 {
+		t.Errorf("Expected concurrent misses to share 1 fetch, but got %d", fetches.Load())
+	}
+}
+
+func TestTokenError(t *testing.T) {
+	errFetch := errors.New("unavailable")
+	fail := true
+	cache := New(func(ctx context.Context, key string) (Token[string], error) {
+		if fail {
+			return Token[string]{}, errFetch
+		}
+		return Token[string]{Value: "token"}, nil
+	}, Options{})
+	defer cache.Close()
+
+	if _, err := cache.Token(context.Background(), "key"); !errors.Is(err, errFetch) {
+		t.Errorf("Expected the error of fetch, but got %v", err)
+	}
+	fail = false
+	if token, err := cache.Token(context.Background(), "key"); err != nil || token != "token" {
+		t.Errorf("Expected errors not to be cached, but got '%s' (%v)", token, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cache.Invalidate("key")
+	if _, err := cache.Token(ctx, "key"); err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a canceled context to be reported, but got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package ttlmap
+
+import "time"
+
+// SetTTL changes the TTL and interval of the map while it is
+// used, see New for their meaning. The TTL is rounded like
+// the TTL of New, see WithTTLRounding. Live entries are kept, the
+// remaining time of every entry is converted to ticks of the
+// new interval, rounded up. Entries stored with StoreWithTTL
+// keep their own TTL. The generations are resized to the new
+// TTL, and the ticker is retuned to the new interval.
+//
+// Children keep their TTL, and follow the interval of their
+// parent. Calling SetTTL on a child only changes its TTL, it
+// returns ErrInterval when interval differs from the interval
+// of the parent. It returns ErrClosed after the map was
+// closed.
+//
+// Entries that are stored or touched concurrently with SetTTL
+// might get a deadline of the old configuration.
+func (m *TTLMap[K, V]) SetTTL(ttl, interval time.Duration) error {
+	if m.closed.Load() {
+		return ErrClosed
+	} else if m.parent != nil && interval != m.tickInterval() {
+		return ErrInterval
+	}
+
+	ttlTicks := m.ticksOf(ttl, interval)
+	if ttlTicks == 0 {
+		// The ttl is shorter than the interval.
+		ttlTicks = 1
+		if m.parent == nil && m.shortTTL == ShortTTLShrinkInterval {
+			interval = ttl
+		}
+	}
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	old := m.tickInterval()
+	m.retime(ttlTicks, interval)
+	for _, child := range m.children {
+		child.advanceMu.Lock()
+		child.retime(max(child.ttlTicks.Load()*uint64(old)/uint64(interval), 1), interval)
+		child.advanceMu.Unlock()
+	}
+	if m.parent != nil || interval == old {
+		return nil
+	}
+
+	if m.ticker != nil {
+		m.ticker.Reset(interval)
+	}
+	if m.scheduler != nil {
+		elapsed := time.Since(m.scheduler.start)
+		m.setNextTick(m.scheduler.start.Add((elapsed/interval + 1) * interval))
+	} else {
+		m.setNextTick(m.clock.Now().Add(interval))
+	}
+	return nil
+}
+
+// retime sets the TTL and interval of the map, and converts
+// the deadlines of all entries to the new interval. The
+// caller must hold advanceMu.
+func (m *TTLMap[K, V]) retime(ttlTicks uint64, interval time.Duration) {
+	old := m.tickInterval()
+	ticks := func(n uint64) uint64 {
+		// Round up, so no entry expires early.
+		return uint64(max((time.Duration(n)*old+interval-1)/interval, 1))
+	}
+	tick := m.tick.Load()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.interval.Store(int64(interval))
+	m.ttlTicks.Store(ttlTicks)
+	if g, ok := m.expirer.(*GenerationExpirer[K]); ok {
+		resized := NewGenerationExpirer[K](int(ttlTicks))
+		resized.hint = g.hint
+		if g.index != nil {
+			resized.index = make(map[K]generationPos)
+		}
+		resized.tick = tick
+		m.expirer = resized
+	} else {
+		m.expirer.Reset()
+	}
+	if m.index != nil {
+		m.index.reset()
+	}
+
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if ttl := e.ttl.Load(); ttl != 0 && interval != old {
+			e.ttl.Store(ticks(ttl))
+		}
+		for {
+			expires := e.expires.Load()
+			if expires == 0 {
+				// The entry expired concurrently.
+				return true
+			}
+
+			deadline := tick + 1
+			if expires > tick {
+				deadline = tick + ticks(expires-tick)
+			}
+			if e.expires.CompareAndSwap(expires, deadline) {
+				m.expirer.Schedule(deadline, key)
+				if m.index != nil {
+					m.index.add(deadline, key)
+				}
+				return true
+			}
+		}
+	})
+}
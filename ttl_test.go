@@ -0,0 +1,55 @@
+package ttlmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetTTL(t *testing.T) {
+	ttlmap := New[string, string](4*time.Hour, time.Hour)
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.StoreWithTTL("key2", "value2", 2*time.Hour)
+	ttlmap.nextGeneration()
+
+	if err := ttlmap.SetTTL(time.Hour, 30*time.Minute); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	ttlmap.Store("key3", "value3")
+
+	// key1 has 3 hours left, key2 1 hour and key3 1 hour.
+	for i := 0; i < 2; i++ {
+		ttlmap.nextGeneration()
+	}
+	if _, ok := ttlmap.Load("key2"); ok {
+		t.Errorf("Expected key2 to expire after 1 hour, but it is present")
+	} else if _, ok := ttlmap.Load("key3"); ok {
+		t.Errorf("Expected key3 to expire after the new ttl, but it is present")
+	} else if _, ok := ttlmap.Load("key1"); !ok {
+		t.Errorf("Expected key1 to be present, but it expired")
+	}
+
+	for i := 0; i < 4; i++ {
+		ttlmap.nextGeneration()
+	}
+	if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected key1 to expire after 3 hours, but it is present")
+	} else if n := len(ttlmap.Stats().Generations); n != 2 {
+		t.Errorf("Expected 2 generations, but got %d", n)
+	}
+}
+
+func TestSetTTLChild(t *testing.T) {
+	parent := New[string, string](2*time.Hour, time.Hour)
+	defer parent.Close()
+	child := parent.Child(2 * time.Hour)
+
+	if err := child.SetTTL(time.Hour, time.Minute); !errors.Is(err, ErrInterval) {
+		t.Errorf("Expected ErrInterval, but got %v", err)
+	} else if err := parent.SetTTL(time.Hour, 30*time.Minute); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	} else if child.ttlTicks.Load() != 4 || child.tickInterval() != 30*time.Minute {
+		t.Errorf("Expected child to keep its ttl, but has %d ticks of %s", child.ttlTicks.Load(), child.tickInterval())
+	}
+}
@@ -1,17 +1,26 @@
 package ttlmap
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // TTLMap is an efficient concurrent map with TTL support.
 //
-// It uses a sync.Map internally as storage, and keeps track
-// of expiration times using a [][]MapKey slice. The outer
-// slice represents a generation, while the inner slice
+// It uses a sync.Map internally as storage, see
+// WithShardedStorage for an alternative, and by default
+// keeps track of expiration times using a [][]K slice. The
+// outer slice represents a generation, while the inner slice
 // contains bucket keys. All keys in a generation expire at
-// the same time.
+// the same time. WithExpirer selects an alternative expiry
+// engine.
+//
+// Every entry records the tick at which it expires. Touching
+// an entry moves its deadline and appends the key to the
+// current generation; the stale key in the old generation
+// is skipped when that generation is advanced.
 //
 // There are (ttl / interval) generations, every time the internal timer
 // ticks the map advances a generation. The TTLMap does the following when advancing:
@@ -22,12 +31,310 @@ import (
 // Because of this, the map is never scanned and cleaning up
 // expired items is very cheap. The downside of this approach
 // is that it uses a little more memory, and is not perfectly accurate.
+//
+// An entry is removed at most ttl after it was stored or
+// its TTL was reset, and at least ttl minus interval after
+// it. This holds for every expiry engine, as long as the
+// ticker is not delayed by more than an interval. When ticks
+// are delayed or dropped, the next tick advances through all
+// generations that are due.
+//
+// The operations interact with the TTL of an entry as follows:
+//   - Store, StoreWithMeta, StoreWithTTL and TryStore reset
+//     the TTL. This is configurable with OpStore.
+//   - A loading LoadOrStore preserves the TTL. This is
+//     configurable with OpLoadOrStore.
+//   - CompareAndSwap preserves the TTL. This is configurable
+//     with OpCompareAndSwap.
+//   - Load preserves the TTL. This is configurable with
+//     OpLoad.
+//   - Swap resets the TTL. This is configurable with OpSwap.
+//   - Touch, LoadAndTouch and TouchMany reset the TTL.
+//   - Extend pushes the deadline out.
+//   - Add, ReplaceAll and a storing LoadOrStore give the entry
+//     the full TTL.
+//   - LoadWithMinTTL behaves like Load.
+//   - GetEntry and Range preserve the TTL.
+//   - Compute, and Append and Increment of the typed maps,
+//     preserve the TTL of existing entries. This is
+//     configurable with OpCompute.
+//
+// See WithTTLPolicy to configure the TTL behavior.
 type TTLMap[K comparable, V any] struct {
-	items sync.Map
+	// items points to the storage engine that stores all
+	// entries. It is a pointer so ReplaceAll can swap in a new
+	// map.
+	items atomic.Pointer[storageEngine[K, V]]
+
+	// newStorage creates an empty storage engine, see
+	// WithShardedStorage.
+	newStorage func() storageEngine[K, V]
+
+	// name labels the work of the map in profiles, see
+	// WithName.
+	name string
+
+	// advanceMu serializes advancing generations.
+	advanceMu sync.Mutex
+	clock     Clock
+	ticker    Ticker
+	closed    atomic.Bool
+	nextTick  time.Time
+	tick      atomic.Uint64
+
+	// epoch is the time of tick 0 in unix nanoseconds, so the
+	// time of a deadline can be computed without advanceMu,
+	// see lapsed.
+	epoch atomic.Int64
+
+	// interval is the duration of a tick in nanoseconds, and
+	// ttlTicks the TTL of the map in ticks. They are atomic,
+	// so SetTTL can change them while the map is used.
+	interval atomic.Int64
+	ttlTicks atomic.Uint64
+
+	// mu guards expirer and index.
+	mu      sync.Mutex
+	expirer Expirer[K]
+	// generationIndex is set by WithGenerationIndex, the
+	// expirer is created once the TTL in ticks is known.
+	generationIndex bool
+
+	// index maps deadlines to keys, it is nil unless
+	// WithDeadlineIndex is used.
+	index *deadlineIndex[K]
+
+	// expired is reused by nextGeneration to collect the keys
+	// that are due.
+	expired []K
+
+	// disposal is reused by nextGeneration to collect the
+	// entries that expired, see dispose.
+	disposal []ExpiredEntry[K, V]
+
+	frozen atomic.Int32
+
+	// strict enables the invariant checks of WithStrict,
+	// started and finished count the modifications of the
+	// map in strict mode.
+	strict   bool
+	started  atomic.Uint64
+	finished atomic.Uint64
+
+	policies [opCount]Policy
+
+	// labels maps labels to their *labelStats.
+	labels sync.Map
+
+	// admit decides whether values are stored, it is nil
+	// unless WithAdmission is used.
+	admit func(key K, value V) bool
+
+	// keyFunc canonicalizes keys, it is nil unless
+	// WithKeyFunc is used.
+	keyFunc func(key K) K
+
+	// contextKey derives keys from a context, it is nil
+	// unless WithContextKey is used.
+	contextKey func(ctx context.Context, key K) K
+
+	// calls maps keys to their running *loaderCall, loads
+	// records the calls of loaders.
+	calls sync.Map
+	loads loaderStats
+
+	// encoder and decoder transform values around storage,
+	// they are nil unless WithTransform is used.
+	encoder func(value V) V
+	decoder func(value V) V
+
+	// compression is set by WithCompression, which uses the
+	// encoder and decoder.
+	compression *compression
+
+	// persistence is set by WithPersistence, and writeAheadLog
+	// by WithWriteAheadLog.
+	persistence   *persistence[K, V]
+	writeAheadLog bool
+
+	// tracer records accesses, it is nil unless
+	// WithTraceRecording is used.
+	tracer *ring[TraceEvent[K]]
+
+	// evictions records expired entries, it is nil unless
+	// WithRecentEvictions is used.
+	evictions *evictionLog[K, V]
+
+	// shortTTL is the behavior for a ttl shorter than the
+	// interval, see WithShortTTL.
+	shortTTL ShortTTL
+	// strictTTL keeps entries until their TTL passed, see
+	// WithStrictTTL.
+	strictTTL bool
+	// ttlRounding is the rounding of a TTL that is not a
+	// multiple of the interval, see WithTTLRounding.
+	ttlRounding TTLRounding
+
+	// blobs stores values larger than blobThreshold, it is
+	// nil unless WithBlobStore is used.
+	blobs         BlobStore[V]
+	blobThreshold int64
+	blobSize      func(value V) int64
+
+	// pinMu guards pins, the blobs pinned by handles, see
+	// Acquire.
+	pinMu sync.Mutex
+	pins  map[string]*pin
+
+	// maxInterval is the upper bound of the adaptive ticker
+	// period, it is 0 unless WithAdaptiveInterval is used.
+	maxInterval time.Duration
+
+	// sparse is set by WithSparseTicks, wake is the tick at
+	// which a sparse ticker wakes up next.
+	sparse bool
+	wake   atomic.Uint64
+
+	// jitterFraction is the fraction of the TTL by which
+	// deadlines are moved earlier, see WithJitter.
+	jitterFraction float64
+
+	// lazyExpiry hides entries whose deadline passed before
+	// they are swept, see WithLazyExpiry.
+	lazyExpiry bool
+
+	// count is the number of entries in the map.
+	count atomic.Int64
+	churn churn
+
+	// latency records latency histograms, it is nil unless
+	// WithLatencyHistograms is used.
+	latency *latency
+
+	// softLimit is the number of entries above which the map
+	// is trimmed in the background, it is 0 unless
+	// WithSoftLimit is used.
+	softLimit int64
+	trimming  atomic.Bool
+	// hand is the position of trim in the generations, it is
+	// guarded by trimming.
+	hand trimHand[K]
+
+	// orderedExpiry expires the keys of a generation in
+	// insertion order, seq numbers the inserted entries.
+	orderedExpiry bool
+	seq           atomic.Uint64
+
+	// maxEntries is the number of entries above which
+	// entries are evicted eagerly, it is 0 unless
+	// WithMaxEntries is used.
+	maxEntries int64
+
+	// watermark evicts entries when the process is near its
+	// memory limit, it is nil unless WithMemoryWatermark is
+	// used.
+	watermark *watermark
+
+	// sketch counts the uses of keys to admit new keys while
+	// the map is full, it is nil unless WithFrequencyAdmission
+	// and WithMaxEntries are used.
+	sketch *frequencySketch[K]
+
+	// maxBytes is the approximate footprint above which
+	// entries are evicted eagerly, bytes is the
+	// footprint measured by sizer. They are 0 unless
+	// WithMaxBytes is used.
+	maxBytes int64
+	bytes    atomic.Int64
+	sizer    func(key K, value V) int64
+
+	// hardLimit is the number of entries above which new keys
+	// are rejected, it is 0 unless WithHardLimit is used.
+	hardLimit int64
+	overflow  func(key K, value V)
+
+	// writeOnce makes stores fail for present keys.
+	writeOnce bool
+
+	// staleWhileRevalidate keeps entries for a generation
+	// after they expire, see WithStaleWhileRevalidate.
+	// revalidate refreshes stale entries, it may be nil.
+	staleWhileRevalidate bool
+	revalidate           func(key K) (V, error)
+
+	// shadow mirrors the operations of the map, it is nil
+	// unless WithShadow is used.
+	shadow *TTLMap[K, V]
+
+	// backend is the second level cache of WithBackend, it is
+	// nil unless it is used.
+	backend     Backend[K, V]
+	backendOpts BackendOptions
+
+	// invalidator is set by WithInvalidator, and unsubscribe
+	// stops its subscription.
+	invalidator Invalidator[K]
+	unsubscribe func()
+
+	// penalty counts the misses of keys, see WithMissPenalty.
+	penalty *missPenalty[K]
+	// negative caches the errors of loaders, see
+	// WithNegativeCaching.
+	negative *negativeCache[K]
+
+	// capacityHint is the capacity of the generations of a
+	// GenerationExpirer, see WithCapacityHint.
+	capacityHint int
+
+	// logger is called with lifecycle events, see WithLogger.
+	logger func(event Event)
+	// hooks instrument the map, see WithHooks.
+	hooks []Hooks[K]
+	// panicked is the last panic recovered in the background
+	// work of the map, see Err.
+	panicked atomic.Pointer[PanicError]
+	// workers runs the eviction callbacks, see
+	// WithAsyncEvictions.
+	workers *workerPool
+
+	// extend keeps due entries up to maxExtensions times, it
+	// is nil unless WithExtendOnExpire is used.
+	extend        func(key K, value V) time.Duration
+	maxExtensions uint32
 
-	generations [][]K
-	ticker      *time.Ticker
-	currentGen  int
+	// scheduler advances the map, it is nil unless
+	// WithScheduler is used. It is guarded by advanceMu after
+	// the map was created, see SetScheduler.
+	scheduler *Scheduler
+
+	// manual is set for maps that are advanced by their
+	// caller, see NewManual.
+	manual bool
+
+	// parent is the map that advances this map, it is nil
+	// unless the map was created with Child. children is
+	// guarded by advanceMu.
+	parent   *TTLMap[K, V]
+	children []*TTLMap[K, V]
+
+	// group is the name of the map in its parent, it is empty
+	// unless the map was created with Group. groupMu
+	// serializes creating groups.
+	group   string
+	groupMu sync.Mutex
+
+	// onEvict is called for every value that leaves the map,
+	// it is nil unless WithOnEvict is used.
+	onEvict func(key K, value V, reason EvictionReason)
+
+	// expiredC delivers expired entries, it is nil unless
+	// WithExpiredChannel is used.
+	expiredC *expiredChannel[K, V]
+
+	// onExpireBatch is called with the entries that expired
+	// in a generation, it is nil unless WithOnExpireBatch is
+	// used.
+	onExpireBatch func(batch []ExpiredEntry[K, V])
 }
 
 // New creates a new TTLMap.
@@ -35,117 +342,1420 @@ type TTLMap[K comparable, V any] struct {
 // The ttl is the time-to-live for each item in the map. The
 // interval determines how often the TTLMap checks for
 // expired items. A small interval value uses a tiny bit
-// more memory and CPU, but is more accurate.
-func New[K comparable, V any](ttl, interval time.Duration) *TTLMap[K, V] {
-	ttlMap := &TTLMap[K, V]{
-		ticker:      time.NewTicker(interval),
-		generations: make([][]K, ttl/interval),
+// more memory and CPU, but is more accurate. The behavior of
+// the map can be customized using opts.
+//
+// A ttl shorter than the interval lowers the interval to the
+// ttl, see WithShortTTL. Other ttls are rounded down to a
+// multiple of the interval, see WithTTLRounding.
+func New[K comparable, V any](ttl, interval time.Duration, opts ...Option[K, V]) *TTLMap[K, V] {
+	ttlMap := newTTLMap(ttl, interval, opts...)
+	if ttlMap.persistence != nil {
+		ttlMap.startPersistence()
+	}
+	if ttlMap.scheduler != nil {
+		ttlMap.scheduler.add(ttlMap)
+		return ttlMap
 	}
+	ttlMap.startTicker()
+	return ttlMap
+}
 
-	go func() {
-		for range ttlMap.ticker.C {
-			ttlMap.nextGeneration()
-		}
-	}()
+// startTicker starts the ticker of the map.
+func (m *TTLMap[K, V]) startTicker() {
+	// Use the current time instead of the time of the tick,
+	// so ticks that were dropped while the map was advancing
+	// are caught up.
+	period := m.tickInterval()
+	m.ticker = m.clock.Ticker(m.tickInterval(), func() {
+		m.labeled(func() {
+			defer m.recoverPanic()
+			expirations := m.churn.expirations.Load()
+			m.AdvanceTo(m.clock.Now())
+			if m.maxInterval > 0 {
+				period = m.adapt(period, m.churn.expirations.Load() != expirations)
+			} else if m.sparse {
+				period = m.sleep(period)
+			}
+		})
+	})
+}
 
+// newTTLMap creates a TTLMap without starting its ticker.
+func newTTLMap[K comparable, V any](ttl, interval time.Duration, opts ...Option[K, V]) *TTLMap[K, V] {
+	ttlMap := &TTLMap[K, V]{
+		clock:    systemClock{},
+		policies: defaultPolicies,
+	}
+	ttlMap.interval.Store(int64(interval))
+	ttlMap.ttlTicks.Store(uint64(ttl / interval))
+	for _, opt := range opts {
+		opt(ttlMap)
+	}
+	if ttlMap.ttlRounding != TTLRoundDown {
+		ttlMap.ttlTicks.Store(ttlMap.ticksOf(ttl, interval))
+	}
+	if ttlMap.ttlTicks.Load() == 0 {
+		// The ttl is shorter than the interval.
+		ttlMap.ttlTicks.Store(1)
+		if ttlMap.shortTTL == ShortTTLShrinkInterval {
+			ttlMap.interval.Store(int64(ttl))
+		}
+	}
+	ttlMap.setNextTick(ttlMap.clock.Now().Add(ttlMap.tickInterval()))
+	if ttlMap.generationIndex {
+		ttlMap.expirer = NewIndexedGenerationExpirer[K](int(ttlMap.ttlTicks.Load()))
+	} else if ttlMap.expirer == nil {
+		ttlMap.expirer = NewGenerationExpirer[K](int(ttlMap.ttlTicks.Load()))
+	}
+	if g, ok := ttlMap.expirer.(*GenerationExpirer[K]); ok {
+		g.hint = ttlMap.capacityHint
+	}
+	if ttlMap.sketch != nil && ttlMap.maxEntries > 0 {
+		ttlMap.sketch.init(ttlMap.maxEntries)
+	} else {
+		ttlMap.sketch = nil
+	}
+	if ttlMap.newStorage == nil {
+		ttlMap.newStorage = newSyncMap[K, V]
+	}
+	items := ttlMap.newStorage()
+	ttlMap.items.Store(&items)
+	if ttlMap.persistence != nil {
+		ttlMap.restorePersisted()
+	}
+	if ttlMap.invalidator != nil {
+		ttlMap.unsubscribe = ttlMap.invalidator.Subscribe(ttlMap.invalidate)
+	}
+	if ttlMap.workers != nil {
+		ttlMap.workers.start(ttlMap.labeled)
+	}
 	return ttlMap
 }
 
-// Load returns the value stored in the map for a key, or nil
-// if no value is present. The ok result indicates whether
+// Load returns the value stored in the map for a key, or the
+// zero value if no value is present. The ok result indicates whether
 // value was found in the map.
-func (m *TTLMap[K, V]) Load(key any) (V, bool) {
-	val, ok := m.items.Load(key)
-	if !ok {
+func (m *TTLMap[K, V]) Load(key K) (V, bool) {
+	if m.latency != nil {
+		defer m.latency.load.observe(m.latency.start())
+	}
+	// A key is penalized before this load, so the miss that
+	// penalizes it still falls through to the backend.
+	penalized := m.penalty != nil && m.penalized(m.key(key))
+	value, ok := m.load(key)
+	if !ok && m.backend != nil && !penalized {
+		value, ok = m.backendLoad(m.key(key))
+	}
+	if !ok && m.penalty != nil {
+		m.missed(m.key(key))
+	}
+	if m.shadow != nil {
+		m.shadowLoad(key, value, ok)
+	}
+	return value, ok
+}
+
+// load returns the value stored in the map for a key, see
+// Load.
+func (m *TTLMap[K, V]) load(key K) (V, bool) {
+	m.checkOpen("Load")
+	key = m.key(key)
+	m.record(TraceLoad, key)
+	e, ok := m.storage().Load(key)
+	if !ok || m.hidden(e) {
+		m.miss()
+		return *new(V), false
+	}
+
+	if m.policies[OpLoad] != PolicyPreserve && !m.refresh(OpLoad, key, e) {
+		m.miss()
+		return *new(V), false
+	}
+	if e.stale.Load() {
+		m.revalidateStale(key)
+	}
+	m.hit(e)
+	return m.value(e), true
+}
+
+// LoadWithMinTTL returns the value stored in the map for a
+// key, like Load, but treats entries that expire within min as
+// missing. This avoids using a value that expires while it is
+// needed, like a token for the rest of a request.
+//
+// The remaining TTL is estimated conservatively, with the
+// precision of the interval.
+func (m *TTLMap[K, V]) LoadWithMinTTL(key K, min time.Duration) (V, bool) {
+	key = m.key(key)
+	m.record(TraceLoad, key)
+	e, ok := m.storage().Load(key)
+	if !ok || m.hidden(e) {
+		m.miss()
+		return *new(V), false
+	}
+
+	if m.remaining(e.expires.Load()) < min {
+		m.miss()
+		return *new(V), false
+	} else if m.policies[OpLoad] != PolicyPreserve && !m.refresh(OpLoad, key, e) {
+		m.miss()
+		return *new(V), false
+	}
+	m.hit(e)
+	return m.value(e), true
+}
+
+// LoadAndTouch returns the value stored in the map for a
+// key and resets its TTL in the same step. The ok result
+// indicates whether value was found in the map.
+func (m *TTLMap[K, V]) LoadAndTouch(key K) (V, bool) {
+	key = m.key(key)
+	m.record(TraceLoad, key)
+	e, ok := m.storage().Load(key)
+	if !ok || m.hidden(e) {
+		m.miss()
 		return *new(V), false
 	}
-	return val.(V), ok
+
+	if !m.touch(key, e) {
+		m.miss()
+		return *new(V), false
+	}
+	m.hit(e)
+	return m.value(e), true
+}
+
+// Touch resets the TTL of key. It reports whether the key was
+// found in the map.
+//
+// The entry records its new deadline, so the key is not
+// expired early when its old generation is advanced.
+func (m *TTLMap[K, V]) Touch(key K) bool {
+	key = m.key(key)
+	e, ok := m.storage().Load(key)
+	return ok && m.touch(key, e)
+}
+
+// Extend pushes the expiration of key out by d, which is
+// rounded down to a multiple of the interval. It reports
+// whether the key was found in the map.
+func (m *TTLMap[K, V]) Extend(key K, d time.Duration) bool {
+	defer m.operation()()
+	key = m.key(key)
+	e, ok := m.storage().Load(key)
+	if !ok {
+		return false
+	}
+
+	ticks := uint64(d / m.tickInterval())
+	for {
+		expires := e.expires.Load()
+		if expires == 0 {
+			return false
+		} else if ticks == 0 {
+			return true
+		} else if e.expires.CompareAndSwap(expires, expires+ticks) {
+			m.schedule(expires+ticks, key)
+			return true
+		}
+	}
 }
 
-// Store sets the value for a key.
+// Promote moves the entry for key to another TTL class: it
+// expires after ttl, and touching it resets it to ttl from then
+// on, like it was stored with StoreWithTTL. The value is not
+// stored again, which suits provisional entries that are
+// confirmed later. The ttl is rounded like StoreWithTTL. It
+// reports whether the key was found in the map, and returns
+// false while the map is frozen.
+func (m *TTLMap[K, V]) Promote(key K, ttl time.Duration) bool {
+	defer m.operation()()
+	key = m.key(key)
+	if m.Frozen() {
+		return false
+	}
+
+	ticks := uint64(ttl / m.tickInterval())
+	if ttl < m.tickInterval() {
+		ticks = 1
+	}
+	for {
+		e, ok := m.storage().Load(key)
+		if !ok {
+			return false
+		}
+
+		if e.expires.Load() == 0 {
+			return false
+		}
+		promoted := e.with(e.value)
+		promoted.blob = e.blob
+		promoted.ttl.Store(ticks)
+		promoted.expires.Store(m.deadlineOf(promoted))
+		m.stamp(promoted)
+		if m.storage().CompareAndSwap(key, e, promoted) {
+			m.schedule(promoted.expires.Load(), key)
+			return true
+		}
+	}
+}
+
+// TouchMany resets the TTL of all given keys in one pass. It
+// returns the number of keys that were found in the map.
+func (m *TTLMap[K, V]) TouchMany(keys []K) int {
+	defer m.operation()()
+	deadline := m.deadline()
+	keysToMove := make([]K, 0, len(keys))
+
+	found := 0
+	for _, key := range keys {
+		key = m.key(key)
+		e, ok := m.storage().Load(key)
+		if !ok {
+			continue
+		}
+
+		if e.ttl.Load() != 0 {
+			if m.touch(key, e) {
+				found++
+			}
+			continue
+		}
+
+		if touched, moved := e.touch(deadline); touched {
+			m.stamp(e)
+			found++
+			if moved {
+				keysToMove = append(keysToMove, key)
+			}
+		}
+	}
+
+	m.schedule(deadline, keysToMove...)
+	return found
+}
+
+// Store sets the value for a key. It is a no-op while the map
+// is frozen.
 func (m *TTLMap[K, V]) Store(key K, value V) {
-	m.items.Store(key, value)
-	m.addToGeneration(key)
+	_ = m.TryStore(key, value)
+}
+
+// StoreWithTTL sets the value for a key, which expires after
+// ttl instead of the TTL of the map. Touching the entry resets
+// it to this TTL. The ttl is rounded down to a multiple of the
+// interval, with a minimum of one interval. It is a no-op
+// while the map is frozen.
+//
+// Deadlines beyond the TTL of the map are supported by every
+// expiry engine, the GenerationExpirer cascades them into its
+// ring when they come near.
+func (m *TTLMap[K, V]) StoreWithTTL(key K, value V, ttl time.Duration) {
+	ticks := uint64(ttl / m.tickInterval())
+	if ttl < m.tickInterval() {
+		ticks = 1
+	}
+	e := &entry[V]{value: value}
+	e.ttl.Store(ticks)
+	_, _ = m.store(key, e)
 }
 
-// Delete deletes the value for a key.
+// TryStore sets the value for a key. It returns ErrFrozen
+// while the map is frozen.
 //
-// It does not reset the TTL value for the key, because this
-// would be a very expensive operation. The ticker will
-// automatically remove the TTL value. Do not reuses keys
-// after deleting them, else they might expire faster then
-// expected.
+// It returns ErrNotAdmitted when the admission function of
+// the map rejects the value, see WithAdmission, and ErrFull
+// when key is new and the map is at its hard limit, see
+// WithHardLimit. It returns ErrExists when key is present and
+// the map is write-once, see WithWriteOnce.
+func (m *TTLMap[K, V]) TryStore(key K, value V) error {
+	_, err := m.store(key, &entry[V]{value: value})
+	return err
+}
+
+// Swap swaps the value for a key and returns the previous
+// value if any. The loaded result reports whether the key was
+// present. The entry gets a TTL according to OpSwap, which
+// resets it like Store by default. While the map is frozen,
+// or when the store fails, it returns the zero value and
+// false.
+func (m *TTLMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	old, err := m.storeOp(OpSwap, key, &entry[V]{value: value})
+	if err != nil || old == nil {
+		return *new(V), false
+	}
+	return m.value(old), true
+}
+
+// Delete deletes the value for a key. It is a no-op while the
+// map is frozen.
+//
+// Deleted keys can be reused, a key that is stored again gets
+// a fresh TTL. The default expiry engine keeps a deleted key
+// in its generation until the generation is advanced, an
+// indexed engine removes it right away, see
+// WithGenerationIndex.
 func (m *TTLMap[K, V]) Delete(key K) {
-	m.items.Delete(key)
+	_ = m.TryDelete(key)
+}
+
+// TryDelete deletes the value for a key. It returns ErrFrozen
+// while the map is frozen.
+func (m *TTLMap[K, V]) TryDelete(key K) error {
+	if m.Frozen() {
+		return ErrFrozen
+	}
+
+	m.delete(key)
+	return nil
+}
+
+// Add stores the value for a key only if the key is not
+// present yet. It returns ErrExists when the key is already
+// present, in which case the existing value is left untouched,
+// ErrFrozen while the map is frozen, ErrClosed after it was
+// closed, ErrNotAdmitted when the value is rejected by the
+// admission function or WithFrequencyAdmission and ErrFull
+// when the map is at its hard limit.
+func (m *TTLMap[K, V]) Add(key K, value V) error {
+	if m.Frozen() {
+		return ErrFrozen
+	} else if _, loaded, err := m.loadOrStore(key, value); err != nil {
+		return err
+	} else if loaded {
+		return ErrExists
+	}
+	return nil
 }
 
 // LoadOrStore returns the existing value for the key if
 // present. Otherwise, it stores and returns the given
 // value. The loaded result is true if the value was loaded,
-// false if stored.
+// false if stored. While the map is frozen or after it was
+// closed it only loads, and returns the zero value and false
+// for missing keys.
+//
+// When goroutines race to LoadOrStore the same missing key,
+// exactly one of them stores its value and schedules the key
+// for expiration, once. The others load the value of the
+// winner, and only schedule the key again when the TTL policy
+// of LoadOrStore moves its deadline.
 func (m *TTLMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
-	if actual, loaded := m.items.LoadOrStore(key, value); loaded {
-		return actual.(V), true
-	} else {
-		m.addToGeneration(key)
-		return value, false
+	actual, loaded, _ = m.loadOrStore(key, value)
+	return actual, loaded
+}
+
+// loadOrStore is like LoadOrStore, but returns the error when
+// a missing key is not stored.
+func (m *TTLMap[K, V]) loadOrStore(key K, value V) (actual V, loaded bool, err error) {
+	defer m.operation()()
+	if m.shadow != nil {
+		m.shadow.LoadOrStore(key, value)
+	}
+	key = m.key(key)
+	m.record(TraceLoad, key)
+	if e, ok := m.storage().Load(key); ok {
+		actual, loaded = m.loaded(key, e)
+		return actual, loaded, nil
+	}
+
+	m.miss()
+	if m.Frozen() {
+		return *new(V), false, ErrFrozen
+	} else if m.closed.Load() {
+		return *new(V), false, ErrClosed
+	} else if m.admit != nil && !m.admit(key, value) {
+		return value, false, ErrNotAdmitted
+	} else if m.sketch != nil && !m.admitted(key, false) {
+		return value, false, ErrNotAdmitted
+	} else if m.full(key, value) {
+		return value, false, ErrFull
+	}
+
+	e := m.newEntry(value)
+	if other, ok := m.storage().LoadOrStore(key, e); ok {
+		m.release(e)
+		actual, loaded = m.loaded(key, other)
+		return actual, loaded, nil
+	}
+	m.record(TraceStore, key)
+	m.schedule(e.expires.Load(), key)
+	m.added(key, e)
+	if m.backend != nil {
+		m.backendStore(key, value, 0)
+	}
+	if m.invalidator != nil {
+		m.invalidator.Publish(key)
+	}
+	return value, false, nil
+}
+
+// CompareAndSwap swaps the old and new values for key if the
+// value stored in the map is equal to old. The old value must
+// be of a comparable type. The swapped result reports whether
+// the value was swapped.
+func (m *TTLMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	defer m.operation()()
+	key = m.key(key)
+	if m.Frozen() {
+		return false
+	}
+
+	for {
+		e, ok := m.storage().Load(key)
+		if !ok {
+			return false
+		}
+
+		expires := e.expires.Load()
+		if expires == 0 || any(m.value(e)) != any(old) {
+			return false
+		}
+
+		deadline := m.policyDeadline(OpCompareAndSwap, e, expires)
+		swapped := e.with(m.encode(new))
+		swapped.expires.Store(deadline)
+		if deadline != expires {
+			m.stamp(swapped)
+		}
+		m.stash(swapped)
+		if m.storage().CompareAndSwap(key, e, swapped) {
+			if deadline != expires {
+				m.schedule(deadline, key)
+			}
+			m.replaced(key, e, swapped)
+			return true
+		}
+		m.release(swapped)
+	}
+}
+
+// CompareAndDelete deletes the entry for key if its value is
+// equal to old. The old value must be of a comparable type.
+// The deleted result reports whether the entry was deleted.
+// It returns false while the map is frozen.
+func (m *TTLMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	defer m.operation()()
+	key = m.key(key)
+	if m.Frozen() {
+		return false
+	}
+
+	for {
+		e, ok := m.storage().Load(key)
+		if !ok {
+			return false
+		}
+
+		if e.expires.Load() == 0 || any(m.value(e)) != any(old) {
+			return false
+		} else if m.storage().CompareAndDelete(key, e) {
+			m.record(TraceDelete, key)
+			m.unschedule(key)
+			m.removed(key, e, EvictionDeleted)
+			return true
+		}
 	}
 }
 
+// Compute atomically replaces the value for key with the
+// result of fn, which is called with the current value and
+// whether the key exists. When fn returns delete true, the
+// entry is deleted instead. Compute returns the new value, and
+// whether the key is present in the map afterwards.
+//
+// Existing entries keep their metadata, and their deadline
+// according to OpCompute. Missing entries are stored with the
+// full TTL. Updates are optimistic:
+// when the key is changed concurrently, fn is called again with
+// the new value, so no update is lost. fn must not access the
+// map.
+//
+// The ok result is false when the map is frozen or closed, or
+// when key is missing and the map is at its hard limit.
+func (m *TTLMap[K, V]) Compute(key K, fn func(old V, exists bool) (new V, delete bool)) (value V, ok bool) {
+	defer m.operation()()
+	key = m.key(key)
+	if m.Frozen() || m.closed.Load() {
+		return *new(V), false
+	}
+
+	for {
+		old, _ := m.storage().Load(key)
+		expires := uint64(0)
+		if old != nil {
+			expires = old.expires.Load()
+		}
+		if expires == 0 {
+			// The key is missing, or the old entry is being
+			// expired. Replace it with a new entry.
+			value, del := fn(*new(V), false)
+			if del {
+				return *new(V), false
+			} else if old == nil && m.full(key, value) {
+				return *new(V), false
+			}
+
+			e := m.newEntry(value)
+			if old == nil {
+				if _, loaded := m.storage().LoadOrStore(key, e); loaded {
+					m.release(e)
+					continue
+				}
+			} else if !m.storage().CompareAndSwap(key, old, e) {
+				m.release(e)
+				continue
+			}
+
+			m.record(TraceStore, key)
+			m.schedule(e.expires.Load(), key)
+			m.added(key, e)
+			if old != nil {
+				// The sweep claimed the old entry, but
+				// could not remove it.
+				m.removed(key, old, EvictionExpired)
+				if m.disposing() {
+					m.dispose([]ExpiredEntry[K, V]{{Key: key, Value: m.value(old)}})
+				}
+				m.release(old)
+			}
+			return value, true
+		}
+
+		value, del := fn(m.value(old), true)
+		if del {
+			if m.storage().CompareAndDelete(key, old) {
+				m.record(TraceDelete, key)
+				m.unschedule(key)
+				m.removed(key, old, EvictionDeleted)
+				return *new(V), false
+			}
+			continue
+		}
+
+		deadline := m.policyDeadline(OpCompute, old, expires)
+		e := old.with(m.encode(value))
+		e.expires.Store(deadline)
+		if deadline != expires {
+			m.stamp(e)
+		}
+		m.stash(e)
+		if m.storage().CompareAndSwap(key, old, e) {
+			if deadline != expires {
+				m.schedule(deadline, key)
+			}
+			m.replaced(key, old, e)
+			return value, true
+		}
+		m.release(e)
+	}
+}
+
+// DeleteFunc deletes every entry for which pred returns true,
+// and returns the number of deleted entries. The keys are
+// removed from their generations, so no garbage is left
+// behind. Entries stored concurrently might not be visited.
+// It is a no-op while the map is frozen.
+func (m *TTLMap[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	defer m.operation()()
+	if m.Frozen() {
+		return 0
+	}
+
+	deleted := 0
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if !pred(key, m.value(e)) || !m.storage().CompareAndDelete(key, e) {
+			return true
+		}
+
+		m.record(TraceDelete, key)
+		m.unschedule(key)
+		m.removed(key, e, EvictionDeleted)
+		deleted++
+		return true
+	})
+	return deleted
+}
+
 // LoadAndDelete deletes the value for a key, returning the
 // previous value if any. The loaded result reports whether
-// the key was present.
+// the key was present. While the map is frozen it only
+// loads.
 //
-// It does not reset the TTL value for the key, because this
-// would be a very expensive operation. The ticker will
-// automatically remove the TTL value. Do not reuses keys
-// after deleting them, else they might expire faster then
-// expected.
+// Deleted keys can be reused, a key that is stored again gets
+// a fresh TTL. The default expiry engine keeps a deleted key
+// in its generation until the generation is advanced, an
+// indexed engine removes it right away, see
+// WithGenerationIndex.
 func (m *TTLMap[K, V]) LoadAndDelete(key K) (V, bool) {
-	if val, ok := m.items.LoadAndDelete(key); ok {
-		return val.(V), ok
+	if m.Frozen() {
+		return m.Load(key)
+	}
+
+	return m.delete(key)
+}
+
+// Clear deletes all entries from the map, and resets every
+// generation. It runs atomically with respect to advancing
+// generations, and is much faster than deleting every key.
+// Stores that run concurrently with Clear might be lost. It is
+// a no-op while the map is frozen.
+//
+// The entries are reported with EvictionDeleted, see
+// WithOnEvict.
+func (m *TTLMap[K, V]) Clear() {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	if m.Frozen() {
+		return
+	}
+	m.clear()
+	if m.negative != nil {
+		m.clearErrors()
+	}
+}
+
+// clear deletes all entries from the map, and resets every
+// generation. The caller must hold advanceMu.
+func (m *TTLMap[K, V]) clear() {
+	m.mu.Lock()
+	m.expirer.Reset()
+	if m.index != nil {
+		m.index.reset()
 	}
-	return *new(V), false
+	items := m.newStorage()
+	old := m.items.Swap(&items)
+	m.mu.Unlock()
+
+	(*old).Range(func(key K, e *entry[V]) bool {
+		m.removed(key, e, EvictionDeleted)
+		return true
+	})
+}
+
+// ReplaceAll replaces the contents of the map with entries.
+//
+// The new contents are built in a fresh internal map, which
+// is swapped in atomically. Readers observe either the old
+// or the new contents, never a mix of both. All entries get
+// the full TTL, and the old entries are dropped as a whole.
+// Entries are checked like stores: entries rejected by the
+// admission function or beyond the hard limit are skipped,
+// and keys that are equal after WithKeyFunc are stored once.
+// Stores that run concurrently with ReplaceAll might be lost.
+// It is a no-op while the map is frozen.
+func (m *TTLMap[K, V]) ReplaceAll(entries map[K]V) {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	if m.Frozen() {
+		return
+	}
+
+	canonical := entries
+	if m.keyFunc != nil {
+		canonical = make(map[K]V, len(entries))
+		for key, value := range entries {
+			canonical[m.key(key)] = value
+		}
+	}
+
+	items := m.newStorage()
+	stored := make(map[K]*entry[V], len(canonical))
+	// Entries have the same deadline, unless WithJitter is
+	// used.
+	deadlines := make(map[uint64][]K, 1)
+	for key, value := range canonical {
+		if m.admit != nil && !m.admit(key, value) {
+			continue
+		} else if m.hardLimit > 0 && int64(len(stored)) >= m.hardLimit {
+			if m.overflow != nil {
+				m.overflow(key, value)
+			}
+			continue
+		}
+		e := m.newEntry(value)
+		items.Store(key, e)
+		stored[key] = e
+		deadline := e.expires.Load()
+		deadlines[deadline] = append(deadlines[deadline], key)
+	}
+
+	m.mu.Lock()
+	m.expirer.Reset()
+	if m.index != nil {
+		m.index.reset()
+	}
+	for deadline, keys := range deadlines {
+		m.expirer.Schedule(deadline, keys...)
+		if m.index != nil {
+			m.index.add(deadline, keys...)
+		}
+		m.scheduled(deadline)
+	}
+	old := m.items.Swap(&items)
+	m.mu.Unlock()
+
+	(*old).Range(func(key K, e *entry[V]) bool {
+		m.removed(key, e, EvictionReplaced)
+		if _, ok := stored[key]; !ok && m.writeAheadLog && m.persistence != nil {
+			m.logged(logRecord[K, V]{Delete: true, Key: key})
+		}
+		return true
+	})
+	for key, e := range stored {
+		m.added(key, e)
+	}
+}
+
+// Len returns the number of entries in the map. It reads a
+// counter that is maintained by stores, deletes and
+// expiration, so it is cheap enough to call on every request.
+//
+// The count is exact when the map is not modified
+// concurrently. Otherwise it reflects every operation that
+// completed before the call, and might or might not include
+// operations that run concurrently. Entries that are past
+// their deadline but were not expired yet are counted, like
+// they are visible to Load.
+func (m *TTLMap[K, V]) Len() int {
+	return int(m.count.Load())
 }
 
 // Range calls f sequentially for each key and value present
 // in the map. If f returns false, range stops the
 // iteration.
+//
+// Range is best-effort: generations are advanced concurrently,
+// so entries may expire while the map is iterated. Use
+// RangeConsistent when this is not acceptable.
 func (m *TTLMap[K, V]) Range(f func(key K, value V) bool) {
-	m.items.Range(func(key any, value any) bool {
-		return f(key.(K), value.(V))
+	m.storage().Range(func(key K, e *entry[V]) bool {
+		if m.hidden(e) {
+			return true
+		}
+		return f(key, m.value(e))
+	})
+}
+
+// Keys returns the keys in the map, in unspecified order.
+// Like Range, it is not a consistent snapshot when the map is
+// modified concurrently, see RangeConsistent.
+func (m *TTLMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	m.Range(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns the values in the map, in unspecified order.
+// See Keys for its consistency.
+func (m *TTLMap[K, V]) Values() []V {
+	values := make([]V, 0, m.Len())
+	m.Range(func(_ K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Snapshot returns a copy of the contents of the map. See Keys
+// for its consistency.
+func (m *TTLMap[K, V]) Snapshot() map[K]V {
+	snapshot := make(map[K]V, m.Len())
+	m.Range(func(key K, value V) bool {
+		snapshot[key] = value
+		return true
 	})
+	return snapshot
 }
 
-// Close stops the ticker.
+// RangeConsistent is like Range, but holds the advancement of
+// generations for the duration of the iteration. No entry is
+// expired while f runs, so every entry that is visited stays
+// present unless it is deleted, replaced or evicted for
+// capacity.
+//
+// The trade-off is that expiration stalls while f runs, and a
+// slow f delays the sweep of the whole map and its children.
+// The f must not call AdvanceTo or Close on the map.
+func (m *TTLMap[K, V]) RangeConsistent(f func(key K, value V) bool) {
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	m.Range(f)
+}
+
+// AdvanceTo advances the map through every generation that
+// is due up to now. Generations that were already advanced,
+// either by the internal ticker or an earlier call, are not
+// advanced again, so calling AdvanceTo with a time that is not
+// due yet is a no-op. This allows external schedulers and
+// tests to drive expiration, and catches up when ticks were
+// missed.
+//
+// Child maps are advanced together with their parent, calling
+// AdvanceTo on a child map is a no-op.
+func (m *TTLMap[K, V]) AdvanceTo(now time.Time) {
+	if m.parent != nil {
+		return
+	}
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	for !now.Before(m.nextTick) {
+		m.advance()
+		m.nextTick = m.nextTick.Add(m.tickInterval())
+	}
+}
+
+// Close stops the ticker and tears the map down. Closing a
+// child map detaches it from its parent, and closing a map of
+// a Scheduler detaches it from the scheduler, which stops its
+// expiration.
+//
+// All entries are deleted and reported with EvictionDeleted,
+// like Clear, and the memory of the generations is released.
+// Stores after Close are rejected with ErrClosed, loads miss.
+// Stores that run concurrently with Close might be kept, but
+// never expire. Closing a map twice is a no-op.
 func (m *TTLMap[K, V]) Close() {
-	m.ticker.Stop()
+	if m.closed.Swap(true) {
+		return
+	}
+	if m.parent != nil {
+		m.parent.removeChild(m)
+	}
+	if m.persistence != nil {
+		m.closePersistence()
+	}
+	if m.unsubscribe != nil {
+		m.unsubscribe()
+	}
+
+	m.advanceMu.Lock()
+	defer m.advanceMu.Unlock()
+	if m.scheduler != nil {
+		m.scheduler.remove(m)
+	}
+	if m.ticker != nil {
+		m.ticker.Stop()
+	}
+	m.clear()
+	m.expired = nil
+	m.disposal = nil
+	if m.workers != nil {
+		m.workers.stop()
+	}
+	if m.hooks != nil {
+		m.closedHooks()
+	}
+}
+
+// advance advances the map and its children by one
+// generation. The caller must hold advanceMu.
+func (m *TTLMap[K, V]) advance() {
+	if m.frozen.Load() != frozenPaused {
+		m.nextGeneration()
+		if m.watermark != nil {
+			m.checkWatermark()
+		}
+	} else {
+		// The deadlines of a paused map move with time.
+		m.epoch.Add(int64(m.tickInterval()))
+	}
+
+	for _, child := range m.children {
+		child.advanceMu.Lock()
+		child.advance()
+		child.advanceMu.Unlock()
+	}
 }
 
 // nextGeneration advances the TTLMap to the next generation.
 func (m *TTLMap[K, V]) nextGeneration() {
-	nextGen := (m.currentGen + 1) % len(m.generations)
+	if m.latency != nil {
+		defer m.latency.sweep.observe(time.Now())
+	}
+	tick := m.tick.Load() + 1
+	var start time.Time
+	var grown, shrunk int
+	if m.logger != nil || m.hooks != nil {
+		start = time.Now()
+	}
+
+	m.mu.Lock()
+	m.expired = m.expirer.Advance(tick, m.expired[:0])
+	m.tick.Store(tick)
+	if g, ok := m.expirer.(*GenerationExpirer[K]); ok && m.logger != nil {
+		grown, shrunk = g.grown, g.shrunk
+		g.grown, g.shrunk = 0, 0
+	}
+	m.mu.Unlock()
 
 	// Remove all items that are stored in the next
-	// generation. These are expired.
-	for _, key := range m.generations[nextGen] {
-		m.items.Delete(key)
+	// generation and are due. Keys that were touched after
+	// being added to this generation are skipped.
+	if m.orderedExpiry {
+		m.order(m.expired)
 	}
 
-	// addToGeneration grows the backing array of the inner
-	// slice when many items are added to a single
-	// generation. When the capacity isn't used in the next
-	// generation, shrink the slice.
-	if len(m.generations) < cap(m.generations[nextGen])/8 {
-		m.generations[nextGen] = make([]K, cap(m.generations[nextGen])/8)
+	disposing := m.disposing()
+	moves := m.movesKeys()
+	expired := 0
+	for _, key := range m.expired {
+		if m.extend != nil && m.extendExpiry(key, tick) {
+			continue
+		} else if m.strictTTL && m.postpone(key, tick) {
+			continue
+		} else if m.staleWhileRevalidate && m.markStale(key, tick) {
+			continue
+		}
+		e := m.expire(key, tick, EvictionExpired)
+		if e == nil {
+			if moves {
+				m.reschedule(key, tick)
+			}
+			continue
+		}
+		expired++
+		if disposing {
+			m.disposal = append(m.disposal, ExpiredEntry[K, V]{Key: key, Value: m.value(e)})
+		}
+		m.release(e)
+	}
+	m.dispose(m.disposal)
+	m.checkGeneration(tick)
+	if m.logger != nil {
+		m.logAdvanced(expired, start, grown, shrunk)
+	}
+	if m.hooks != nil {
+		m.advancedHooks(expired, time.Since(start))
 	}
 
-	// Reset the next generation.
-	m.generations[nextGen] = m.generations[nextGen][:0]
-	m.currentGen = nextGen
+	// Release the keys, the buffers are reused by the next
+	// generation.
+	clear(m.expired)
+	clear(m.disposal)
+	m.disposal = m.disposal[:0]
+	m.updateChurn()
 }
 
-// addToGeneration adds a key to the current generation.
-func (m *TTLMap[K, V]) addToGeneration(key K) {
-	m.generations[m.currentGen] = append(m.generations[m.currentGen], key)
+// expire removes the entry for key if it is due at tick. It
+// returns the removed entry, or nil when no entry was removed.
+func (m *TTLMap[K, V]) expire(key K, tick uint64, reason EvictionReason) *entry[V] {
+	for {
+		e, ok := m.storage().Load(key)
+		if !ok {
+			return nil
+		}
+
+		expires := e.expires.Load()
+		if expires == 0 || expires > tick || !e.expires.CompareAndSwap(expires, 0) {
+			return nil
+		}
+		if m.storage().CompareAndDelete(key, e) {
+			m.evicted(key, e)
+			m.removed(key, e, reason)
+			return e
+		}
+		// The entry was replaced after it was claimed,
+		// check the replacement.
+	}
+}
+
+// movesKeys reports whether the expirer moves keys that are
+// scheduled again, instead of reporting them for every
+// deadline they were scheduled at.
+func (m *TTLMap[K, V]) movesKeys() bool {
+	switch expirer := m.expirer.(type) {
+	case *GenerationExpirer[K]:
+		return expirer.index != nil
+	case *MultiResolutionExpirer[K]:
+		return false
+	default:
+		return true
+	}
+}
+
+// reschedule schedules key again when its entry is due after
+// tick. A touch of an entry that was replaced concurrently
+// might move the key to the old deadline of the touched
+// entry, an expirer that moves keys would never report the
+// replacement otherwise.
+func (m *TTLMap[K, V]) reschedule(key K, tick uint64) {
+	if e, ok := m.storage().Load(key); ok {
+		if expires := e.expires.Load(); expires > tick {
+			m.schedule(expires, key)
+		}
+	}
+}
+
+// touch resets the TTL of an entry by moving it to the
+// current generation. It returns false when the entry
+// expired concurrently.
+func (m *TTLMap[K, V]) touch(key K, e *entry[V]) bool {
+	defer m.operation()()
+	deadline := m.deadlineOf(e)
+	ok, moved := e.touch(deadline)
+	if ok {
+		m.stamp(e)
+	}
+	if moved {
+		m.schedule(deadline, key)
+	}
+	return ok
+}
+
+// update atomically replaces the value for key with the result
+// of fn, see Compute.
+func (m *TTLMap[K, V]) update(key K, fn func(old V, loaded bool) V) (V, bool) {
+	return m.Compute(key, func(old V, exists bool) (V, bool) {
+		return fn(old, exists), false
+	})
+}
+
+// store stores an entry for key and adds it to the generation
+// of its deadline, and returns the entry it replaced. The
+// deadline is set according to the OpStore policy. When the
+// entry is not admitted, an existing entry for key is deleted
+// so no outdated value is served.
+//
+// The value of e is not encoded yet, store encodes it after
+// the admission check.
+func (m *TTLMap[K, V]) store(key K, e *entry[V]) (old *entry[V], err error) {
+	return m.storeOp(OpStore, key, e)
+}
+
+// storeOp is store, with the policy of op.
+func (m *TTLMap[K, V]) storeOp(op Op, key K, e *entry[V]) (old *entry[V], err error) {
+	value := e.value
+	old, err = m.storeDeferred(op, key, e, nil)
+	if err == nil && m.backend != nil {
+		m.backendStore(m.key(key), value, e.ttl.Load())
+	}
+	if err == nil && m.invalidator != nil {
+		m.invalidator.Publish(m.key(key))
+	}
+	return old, err
+}
+
+// storeDeferred is like storeOp, but when deferred is not nil,
+// the key is added to deferred by its deadline instead of
+// being scheduled, so the caller can schedule many keys at
+// once.
+func (m *TTLMap[K, V]) storeDeferred(op Op, key K, e *entry[V], deferred map[uint64][]K) (old *entry[V], err error) {
+	m.checkOpen("Store")
+	defer m.operation()()
+	if m.latency != nil {
+		defer m.latency.store.observe(m.latency.start())
+	}
+	key = m.key(key)
+	if m.Frozen() {
+		return nil, ErrFrozen
+	} else if m.closed.Load() {
+		return nil, ErrClosed
+	} else if m.admit != nil && !m.admit(key, e.value) {
+		m.delete(key)
+		return nil, ErrNotAdmitted
+	} else if m.sketch != nil && !m.admitted(key, true) {
+		return nil, ErrNotAdmitted
+	} else if _, ok := m.storage().Load(key); !ok && m.full(key, e.value) {
+		return nil, ErrFull
+	}
+	value := e.value
+	e.value = m.encode(e.value)
+	m.stash(e)
+
+	expires := uint64(0)
+	var stamped int64
+	if m.policies[op] != PolicyReset {
+		if prev, ok := m.storage().Load(key); ok {
+			expires, stamped = prev.expires.Load(), prev.stamped.Load()
+		}
+	}
+
+	m.record(TraceStore, key)
+	deadline := m.policyDeadline(op, e, expires)
+	e.expires.Store(deadline)
+	if deadline != expires {
+		m.stamp(e)
+	} else {
+		e.stamped.Store(stamped)
+	}
+	e.created = m.tick.Load()
+	e.used.Store(e.created + 1)
+	e.seq = m.sequence()
+
+	var loaded bool
+	if m.writeOnce {
+		if _, loaded := m.storage().LoadOrStore(key, e); loaded {
+			m.release(e)
+			return nil, ErrExists
+		}
+	} else {
+		old, loaded = m.storage().Swap(key, e)
+	}
+	if deadline != expires && deferred != nil {
+		deferred[deadline] = append(deferred[deadline], key)
+	} else if deadline != expires {
+		m.schedule(deadline, key)
+	}
+	m.added(key, e)
+	if loaded {
+		m.removed(key, old, EvictionReplaced)
+	}
+	if m.shadow != nil {
+		m.shadowStore(key, value, e.ttl.Load())
+	}
+	return old, nil
+}
+
+// delete deletes the entry for key, and returns its value.
+func (m *TTLMap[K, V]) delete(key K) (V, bool) {
+	return m.deleteKey(key, false)
+}
+
+// deleteKey is delete, a delete by a restore is not written
+// to the backend, published to peers or appended to the
+// write-ahead log, see restoreDelete.
+func (m *TTLMap[K, V]) deleteKey(key K, restore bool) (V, bool) {
+	m.checkOpen("Delete")
+	defer m.operation()()
+	key = m.key(key)
+	m.record(TraceDelete, key)
+	e, ok := m.storage().LoadAndDelete(key)
+	if m.invalidator != nil && !restore {
+		// Peers may hold a key that this map does not.
+		m.invalidator.Publish(key)
+	}
+	if !ok {
+		return *new(V), false
+	}
+
+	e.restoreDeleted = restore
+	m.unschedule(key)
+	m.removed(key, e, EvictionDeleted)
+	if m.shadow != nil {
+		m.shadow.Delete(key)
+	}
+	if m.backend != nil && !restore {
+		m.backendDelete(key)
+	}
+	return m.value(e), true
+}
+
+// loaded applies the OpLoadOrStore policy to an entry that
+// was loaded by LoadOrStore.
+func (m *TTLMap[K, V]) loaded(key K, e *entry[V]) (V, bool) {
+	if m.policies[OpLoadOrStore] != PolicyPreserve {
+		m.refresh(OpLoadOrStore, key, e)
+	}
+	m.hit(e)
+	return m.value(e), true
+}
+
+// encode encodes a value before it is stored.
+func (m *TTLMap[K, V]) encode(value V) V {
+	if m.encoder == nil {
+		return value
+	}
+	return m.encoder(value)
+}
+
+// value returns the decoded value of an entry.
+func (m *TTLMap[K, V]) value(e *entry[V]) V {
+	value := e.value
+	if e.blob != "" {
+		value = m.unstash(e)
+	}
+	if m.decoder == nil {
+		return value
+	}
+	return m.decoder(value)
+}
+
+// hit is called when an entry is loaded.
+func (m *TTLMap[K, V]) hit(e *entry[V]) {
+	// Only write the tick when it changes, so hot keys don't
+	// contend on the cache line of their entry.
+	if tick := m.tick.Load() + 1; e.used.Load() != tick {
+		e.used.Store(tick)
+	}
+	if m.churn.sampled() {
+		m.churn.hits.Add(1)
+	}
+	if e.label != nil {
+		e.label.hits.Add(1)
+	}
+}
+
+// miss is called when a load does not find an entry.
+func (m *TTLMap[K, V]) miss() {
+	if m.churn.sampled() {
+		m.churn.misses.Add(1)
+	}
+}
+
+// added is called exactly once for every entry that is added
+// to the map.
+func (m *TTLMap[K, V]) added(key K, e *entry[V]) {
+	m.count.Add(1)
+	m.bytes.Add(m.sizeOf(key, e))
+	m.churn.stores.Add(1)
+	if e.label != nil {
+		e.label.stored()
+	}
+	if m.writeAheadLog && m.persistence != nil && !e.restored {
+		m.logged(logRecord[K, V]{Key: key, Value: m.value(e), ExpiresAt: time.Unix(0, m.expiryTime(e.expires.Load()))})
+	}
+	m.resized()
+}
+
+// removed is called exactly once for every entry that is
+// removed from the map, either because it expired, was
+// deleted or was replaced by a store.
+func (m *TTLMap[K, V]) removed(key K, e *entry[V], reason EvictionReason) {
+	m.count.Add(-1)
+	m.bytes.Add(-m.sizeOf(key, e))
+	m.checkRemoved(key, e)
+	if reason == EvictionExpired {
+		m.churn.expirations.Add(1)
+		if m.backend != nil && m.backendOpts.PropagateExpirations {
+			m.backendDelete(key)
+		}
+	} else if reason == EvictionDeleted {
+		m.churn.deletes.Add(1)
+		if m.writeAheadLog && m.persistence != nil && !e.restoreDeleted {
+			m.logged(logRecord[K, V]{Delete: true, Key: key})
+		}
+	}
+	if e.label != nil {
+		e.label.removed(m.tick.Load() - e.created)
+	}
+	if m.index != nil && reason != EvictionDeleted {
+		// Deleted keys are removed from the index by
+		// unschedule.
+		m.mu.Lock()
+		if _, ok := m.storage().Load(key); !ok {
+			m.index.remove(key)
+		}
+		m.mu.Unlock()
+	}
+	m.notify(key, e, reason)
+	if m.hooks != nil {
+		m.removedHooks(reason)
+	}
+	if reason != EvictionExpired {
+		// Expired entries are released after they are
+		// disposed.
+		m.release(e)
+	}
+}
+
+// replaced is called when the value of old is replaced in
+// place by e, which keeps the deadline of the logical entry.
+func (m *TTLMap[K, V]) replaced(key K, old, e *entry[V]) {
+	if m.sizer != nil {
+		m.bytes.Add(m.sizeOf(key, e) - m.sizeOf(key, old))
+		m.resized()
+	}
+	m.notify(key, old, EvictionReplaced)
+	m.release(old)
+}
+
+// notify calls the eviction callback when the value of e left
+// the map.
+func (m *TTLMap[K, V]) notify(key K, e *entry[V], reason EvictionReason) {
+	if m.onEvict == nil {
+		return
+	}
+	value := m.value(e)
+	if m.workers != nil {
+		m.workers.run(func() {
+			m.safely(func() { m.onEvict(key, value, reason) })
+		})
+		return
+	}
+	m.safely(func() { m.onEvict(key, value, reason) })
+}
+
+// newEntry creates an entry that expires after the full TTL.
+// The value is encoded by the transform of the map.
+func (m *TTLMap[K, V]) newEntry(value V) *entry[V] {
+	e := &entry[V]{value: m.encode(value), created: m.tick.Load(), seq: m.sequence()}
+	e.used.Store(e.created + 1)
+	m.stash(e)
+	e.expires.Store(m.deadline())
+	m.stamp(e)
+	return e
+}
+
+// key returns the canonical form of key, see WithKeyFunc.
+func (m *TTLMap[K, V]) key(key K) K {
+	if m.keyFunc == nil {
+		return key
+	}
+	return m.keyFunc(key)
+}
+
+// deadline returns the tick at which an entry stored now
+// expires.
+func (m *TTLMap[K, V]) deadline() uint64 {
+	ttl := m.ttlTicks.Load()
+	return m.tick.Load() + ttl - m.jitter(ttl)
+}
+
+// remaining returns the minimum time until an entry with the
+// given deadline expires.
+func (m *TTLMap[K, V]) remaining(expires uint64) time.Duration {
+	tick := m.tick.Load()
+	if expires <= tick+1 {
+		return 0
+	}
+	return time.Duration(expires-tick-1) * m.tickInterval()
+}
+
+// deadlineOf returns the tick at which e expires when its TTL
+// is reset now.
+func (m *TTLMap[K, V]) deadlineOf(e *entry[V]) uint64 {
+	if ttl := e.ttl.Load(); ttl != 0 {
+		return m.tick.Load() + ttl - m.jitter(ttl)
+	}
+	return m.deadline()
+}
+
+// tickInterval returns the duration of a tick.
+func (m *TTLMap[K, V]) tickInterval() time.Duration {
+	return time.Duration(m.interval.Load())
+}
+
+// storage returns the storage engine that stores all entries.
+func (m *TTLMap[K, V]) storage() storageEngine[K, V] {
+	return *m.items.Load()
+}
+
+// schedule registers that keys expire at the deadline tick.
+func (m *TTLMap[K, V]) schedule(deadline uint64, keys ...K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expirer.Schedule(deadline, keys...)
+	if m.index != nil {
+		m.index.add(deadline, keys...)
+	}
+	m.scheduled(deadline)
+}
+
+// unschedule unregisters key after it was deleted. The key is
+// not unregistered when it was stored again concurrently.
+func (m *TTLMap[K, V]) unschedule(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.storage().Load(key); !ok {
+		m.expirer.Remove(key)
+		if m.index != nil {
+			m.index.remove(key)
+		}
+	}
 }
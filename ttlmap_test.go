@@ -1,14 +1,26 @@
 package ttlmap
 
 import (
+	"errors"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// currentGeneration returns the keys in the generation new
+// entries are added to.
+func currentGeneration[K comparable, V any](m *TTLMap[K, V]) []K {
+	g := m.expirer.(*GenerationExpirer[K])
+	return g.generations[g.slot(m.deadline())]
+}
+
 func TestLoad(t *testing.T) {
 	ttlmap := New[string, string](time.Hour, time.Minute)
-	ttlmap.items.Store("key", "value")
+	ttlmap.storage().Store("key", ttlmap.newEntry("value"))
 
 	if value, ok := ttlmap.Load("key"); !ok {
 		t.Errorf("Expected to find key, but did not")
@@ -17,25 +29,120 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadWithMinTTL(t *testing.T) {
+	ttlmap := New[string, string](4*time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+
+	if value, ok := ttlmap.LoadWithMinTTL("key", 3*time.Hour); !ok || value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.LoadWithMinTTL("key", 3*time.Hour); ok {
+		t.Errorf("Expected key expiring within min to be missing, but was not")
+	} else if _, ok = ttlmap.LoadWithMinTTL("key", 2*time.Hour); !ok {
+		t.Errorf("Expected to find key, but did not")
+	}
+}
+
+func TestLoadAndTouch(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if value, ok := ttlmap.LoadAndTouch("key"); !ok {
+		t.Errorf("Expected to find key, but did not")
+	} else if value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key after touch, but did not")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	} else if _, ok := ttlmap.LoadAndTouch("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestTouch(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if !ttlmap.Touch("key") {
+		t.Errorf("Expected to touch key, but did not")
+	} else if ttlmap.Touch("missing") {
+		t.Errorf("Expected to not touch missing key, but did")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key after touch, but did not")
+	}
+}
+
+func TestExtend(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+
+	if !ttlmap.Extend("key", 3*time.Hour) {
+		t.Errorf("Expected to extend key, but did not")
+	}
+
+	for i := 0; i < 4; i++ {
+		ttlmap.nextGeneration()
+		if _, ok := ttlmap.Load("key"); !ok {
+			t.Errorf("Expected to find key at tick %d, but did not", i+1)
+		}
+	}
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire after 5 ticks, but did not")
+	}
+}
+
+func TestTouchMany(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+
+	if n := ttlmap.TouchMany([]string{"key1", "key2", "key3"}); n != 2 {
+		t.Errorf("Expected 2 keys to be touched, but got %d", n)
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key1"); !ok {
+		t.Errorf("Expected to find key1 after touch, but did not")
+	} else if _, ok := ttlmap.Load("key2"); !ok {
+		t.Errorf("Expected to find key2 after touch, but did not")
+	}
+}
+
 func TestStore(t *testing.T) {
 	ttlmap := New[string, string](time.Hour, time.Minute)
 	ttlmap.Store("key", "value")
 
-	if value, ok := ttlmap.items.Load("key"); !ok {
+	if value, ok := ttlmap.storage().Load("key"); !ok {
 		t.Errorf("Expected to find key, but did not")
-	} else if value != "value" {
+	} else if value := value.value; value != "value" {
 		t.Errorf("Expected value to be 'value', but was '%s'", value)
-	} else if ttlmap.generations[ttlmap.currentGen][0] != "key" {
+	} else if currentGeneration(ttlmap)[0] != "key" {
 		t.Errorf("Expected key to be in generation 0, but was not")
 	}
 }
 
 func TestDelete(t *testing.T) {
 	ttlmap := New[string, string](time.Hour, time.Minute)
-	ttlmap.items.Store("key", "value")
+	ttlmap.storage().Store("key", ttlmap.newEntry("value"))
 	ttlmap.Delete("key")
 
-	if _, ok := ttlmap.items.Load("key"); ok {
+	if _, ok := ttlmap.storage().Load("key"); ok {
 		t.Errorf("Expected to not find key, but did")
 	}
 }
@@ -47,7 +154,7 @@ func TestLoadOrStore(t *testing.T) {
 		t.Errorf("Expected to not find key, but did")
 	} else if value != "value" {
 		t.Errorf("Expected value to be 'value', but was '%s'", value)
-	} else if ttlmap.generations[ttlmap.currentGen][0] != "key" {
+	} else if currentGeneration(ttlmap)[0] != "key" {
 		t.Errorf("Expected key to be in generation 0, but was not")
 	} else if value, loaded = ttlmap.LoadOrStore("key", "value2"); !loaded {
 		t.Errorf("Expected to find key, but did not")
@@ -56,9 +163,184 @@ func TestLoadOrStore(t *testing.T) {
 	}
 }
 
+func TestLoadOrStoreRace(t *testing.T) {
+	for _, policy := range []Policy{PolicyPreserve, PolicyReset} {
+		ttlmap := NewManual(time.Hour, time.Minute, WithTTLPolicy[string, string](OpLoadOrStore, policy))
+
+		var wg sync.WaitGroup
+		var stored atomic.Int64
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, loaded := ttlmap.LoadOrStore("key", strconv.Itoa(i)); !loaded {
+					stored.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		scheduled := 0
+		for _, gen := range ttlmap.expirer.(*GenerationExpirer[string]).generations {
+			for _, key := range gen {
+				if key == "key" {
+					scheduled++
+				}
+			}
+		}
+		if n := stored.Load(); n != 1 {
+			t.Errorf("Expected 1 goroutine to store with policy %v, but %d did", policy, n)
+		} else if scheduled != 1 {
+			t.Errorf("Expected key to be scheduled once with policy %v, but was scheduled %d times", policy, scheduled)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+
+	if err := ttlmap.Add("key", "value"); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if err = ttlmap.Add("key", "value2"); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, but got '%v'", err)
+	} else if value, _ := ttlmap.Load("key"); value != "value" {
+		t.Errorf("Expected value to be 'value', but was '%s'", value)
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	closed := New[string, string](time.Hour, time.Minute)
+	closed.Close()
+	if err := closed.Add("key", "value"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, but got '%v'", err)
+	}
+
+	admission := New(time.Hour, time.Hour, WithMaxEntries[string, int](4), WithFrequencyAdmission[string, int]())
+	defer admission.Close()
+	for i := range 4 {
+		key := strconv.Itoa(i)
+		admission.Store(key, i)
+		for range 3 {
+			admission.Load(key)
+		}
+	}
+	if err := admission.Add("new", 0); !errors.Is(err, ErrNotAdmitted) {
+		t.Errorf("Expected ErrNotAdmitted, but got '%v'", err)
+	} else if _, ok := admission.Load("new"); ok {
+		t.Errorf("Expected rejected key not to be stored, but it was")
+	}
+
+	// Every Add that returns nil stored its key, also when
+	// Adds race for the last free entries.
+	full := New(time.Hour, time.Minute, WithHardLimit[int, int](10, nil))
+	defer full.Close()
+	var wg sync.WaitGroup
+	var added atomic.Int64
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := full.Add(i, i); err == nil {
+				added.Add(1)
+			} else if !errors.Is(err, ErrFull) {
+				t.Errorf("Expected ErrFull, but got '%v'", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := full.Len(); int64(n) != added.Load() {
+		t.Errorf("Expected %d added keys, but the map has %d", added.Load(), n)
+	}
+}
+
+func TestReplaceAllChecks(t *testing.T) {
+	ttlmap := New(time.Hour, time.Minute,
+		WithKeyFunc[string, string](strings.ToLower),
+		WithAdmission(func(_ string, value string) bool { return value != "rejected" }),
+		WithHardLimit[string, string](2, nil),
+		WithMaxBytes(100, func(_ string, value string) int64 { return int64(len(value)) }))
+	defer ttlmap.Close()
+	ttlmap.Store("old", "value")
+
+	ttlmap.ReplaceAll(map[string]string{"KEY": "value", "key": "value", "rejected": "rejected"})
+	if n := ttlmap.Len(); n != 1 {
+		t.Errorf("Expected equal canonical keys to be stored once, but got %d entries", n)
+	} else if n := ttlmap.bytes.Load(); n != 5 {
+		t.Errorf("Expected 5 bytes, but got %d", n)
+	}
+
+	ttlmap.ReplaceAll(map[string]string{"key1": "1", "key2": "2", "key3": "3"})
+	if n := ttlmap.Len(); n != 2 {
+		t.Errorf("Expected the hard limit to apply, but got %d entries", n)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+
+	if _, loaded := ttlmap.Swap("key", "value"); loaded {
+		t.Errorf("Expected key to not be loaded, but was")
+	} else if old, loaded := ttlmap.Swap("key", "other"); !loaded || old != "value" {
+		t.Errorf("Expected old value to be 'value', but was '%s'", old)
+	} else if value, _ := ttlmap.Load("key"); value != "other" {
+		t.Errorf("Expected value to be 'other', but was '%s'", value)
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key", "value")
+
+	if ttlmap.CompareAndDelete("key", "other") {
+		t.Errorf("Expected key with another value to not be deleted, but was")
+	} else if !ttlmap.CompareAndDelete("key", "value") {
+		t.Errorf("Expected key to be deleted, but was not")
+	} else if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	} else if ttlmap.count.Load() != 0 {
+		t.Errorf("Expected map to be empty, but was not")
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if ttlmap.CompareAndSwap("key", "other", "value2") {
+		t.Errorf("Expected swap with wrong old value to fail, but it succeeded")
+	} else if !ttlmap.CompareAndSwap("key", "value", "value2") {
+		t.Errorf("Expected swap to succeed, but it failed")
+	} else if value, _ := ttlmap.Load("key"); value != "value2" {
+		t.Errorf("Expected value to be 'value2', but was '%s'", value)
+	} else if ttlmap.CompareAndSwap("missing", "", "value") {
+		t.Errorf("Expected swap of missing key to fail, but it succeeded")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestCompareAndSwapRefresh(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithCompareAndSwapRefresh[string, string]())
+	ttlmap.Store("key", "value")
+	ttlmap.nextGeneration()
+
+	if !ttlmap.CompareAndSwap("key", "value", "value2") {
+		t.Errorf("Expected swap to succeed, but it failed")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); !ok {
+		t.Errorf("Expected to find key after refresh, but did not")
+	}
+}
+
 func TestLoadAndDelete(t *testing.T) {
 	ttlmap := New[string, string](time.Hour, time.Minute)
-	ttlmap.items.Store("key", "value")
+	ttlmap.storage().Store("key", ttlmap.newEntry("value"))
 
 	if value, loaded := ttlmap.LoadAndDelete("key"); !loaded {
 		t.Errorf("Expected to find key, but did not")
@@ -71,8 +353,8 @@ func TestLoadAndDelete(t *testing.T) {
 
 func TestRange(t *testing.T) {
 	ttlmap := New[string, string](time.Hour, time.Minute)
-	ttlmap.items.Store("key1", "value1")
-	ttlmap.items.Store("key2", "value2")
+	ttlmap.storage().Store("key1", ttlmap.newEntry("value1"))
+	ttlmap.storage().Store("key2", ttlmap.newEntry("value2"))
 
 	var (
 		keys   []string
@@ -83,6 +365,8 @@ func TestRange(t *testing.T) {
 		values = append(values, value)
 		return true
 	})
+	sort.Strings(keys)
+	sort.Strings(values)
 
 	if len(keys) != 2 {
 		t.Errorf("Expected 2 keys, but got %d", len(keys))
@@ -95,8 +379,149 @@ func TestRange(t *testing.T) {
 	}
 }
 
+func TestPromote(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Hour)
+	ttlmap.Store("key", "value")
+
+	if !ttlmap.Promote("key", 3*time.Hour) {
+		t.Errorf("Expected key to be promoted, but was not")
+	} else if ttlmap.Promote("missing", 3*time.Hour) {
+		t.Errorf("Expected missing key to not be promoted, but was")
+	}
+
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	ttlmap.Touch("key")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+	if value, ok := ttlmap.Load("key"); !ok || value != "value" {
+		t.Errorf("Expected promoted key to keep its value and TTL, but got '%s'", value)
+	}
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected promoted key to expire, but did not")
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Hour, WithExpirer[string, string](NewListExpirer[string]()))
+	ttlmap.Store("tenant1/key1", "value1")
+	ttlmap.Store("tenant1/key2", "value2")
+	ttlmap.Store("tenant2/key1", "value3")
+
+	deleted := ttlmap.DeleteFunc(func(key string, _ string) bool {
+		return strings.HasPrefix(key, "tenant1/")
+	})
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted entries, but got %d", deleted)
+	} else if n := ttlmap.Len(); n != 1 {
+		t.Errorf("Expected 1 entry to remain, but got %d", n)
+	} else if nodes := len(ttlmap.expirer.(*ListExpirer[string]).nodes); nodes != 1 {
+		t.Errorf("Expected deleted keys to be unscheduled, but %d keys are scheduled", nodes)
+	}
+}
+
+func TestClear(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Hour)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.Clear()
+
+	if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected map to be empty after clear, but found key1")
+	} else if n := ttlmap.Len(); n != 0 {
+		t.Errorf("Expected length to be 0, but was %d", n)
+	} else if generations := ttlmap.Stats().Generations; generations[0] != 0 {
+		t.Errorf("Expected generations to be reset, but got %v", generations)
+	}
+}
+
+func TestLen(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Hour)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.Store("key2", "value2")
+	ttlmap.Delete("key1")
+
+	if n := ttlmap.Len(); n != 1 {
+		t.Errorf("Expected length to be 1, but was %d", n)
+	}
+	ttlmap.nextGeneration()
+	if n := ttlmap.Len(); n != 0 {
+		t.Errorf("Expected length to be 0 after expiry, but was %d", n)
+	}
+}
+
+func TestKeysValuesSnapshot(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+
+	keys, values, snapshot := ttlmap.Keys(), ttlmap.Values(), ttlmap.Snapshot()
+	sort.Strings(keys)
+	sort.Strings(values)
+
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Errorf("Expected keys to be 'key1' and 'key2', but got %v", keys)
+	} else if len(values) != 2 || values[0] != "value1" || values[1] != "value2" {
+		t.Errorf("Expected values to be 'value1' and 'value2', but got %v", values)
+	} else if len(snapshot) != 2 || snapshot["key1"] != "value1" {
+		t.Errorf("Expected snapshot to contain both entries, but got %v", snapshot)
+	}
+}
+
+func TestRangeConsistent(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Minute)
+	ttlmap.Store("key", "value")
+
+	done := make(chan struct{})
+	ttlmap.RangeConsistent(func(key string, value string) bool {
+		go func() {
+			ttlmap.AdvanceTo(time.Now().Add(2 * time.Hour))
+			close(done)
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		if _, ok := ttlmap.Load(key); !ok {
+			t.Errorf("Expected key to not expire during iteration, but it did")
+		}
+		return true
+	})
+	<-done
+
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire after iteration, but it did not")
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+	ttlmap.nextGeneration()
+
+	ttlmap.ReplaceAll(map[string]string{"key2": "new2", "key3": "new3"})
+	if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected to not find key1, but did")
+	} else if value, _ := ttlmap.Load("key2"); value != "new2" {
+		t.Errorf("Expected value to be 'new2', but was '%s'", value)
+	} else if value, _ := ttlmap.Load("key3"); value != "new3" {
+		t.Errorf("Expected value to be 'new3', but was '%s'", value)
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key2"); !ok {
+		t.Errorf("Expected to find key2, but did not")
+	}
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key3"); ok {
+		t.Errorf("Expected to not find key3, but did")
+	}
+}
+
 func TestNextGeneration(t *testing.T) {
-	ttlmap := New[string, string](2, 1)
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
 	ttlmap.Store("key1", "value1")
 
 	ttlmap.nextGeneration()
@@ -110,6 +535,35 @@ func TestNextGeneration(t *testing.T) {
 	}
 }
 
+func TestAdvanceTo(t *testing.T) {
+	ttlmap := New[string, string](2*time.Hour, time.Hour)
+	start := ttlmap.nextTick.Add(-time.Hour)
+	ttlmap.Store("key1", "value1")
+
+	ttlmap.AdvanceTo(start.Add(time.Hour))
+	if _, ok := ttlmap.Load("key1"); !ok {
+		t.Errorf("Expected to find key1, but did not")
+	}
+
+	ttlmap.Store("key2", "value2")
+	ttlmap.AdvanceTo(start.Add(2 * time.Hour))
+	ttlmap.AdvanceTo(start.Add(2 * time.Hour))
+	if _, ok := ttlmap.Load("key1"); ok {
+		t.Errorf("Expected to not find key1, but did")
+	} else if _, ok := ttlmap.Load("key2"); !ok {
+		t.Errorf("Expected to find key2, but did not")
+	} else if tick := ttlmap.tick.Load(); tick != 2 {
+		t.Errorf("Expected tick to be 2, but was %d", tick)
+	}
+
+	ttlmap.AdvanceTo(start.Add(5 * time.Hour))
+	if _, ok := ttlmap.Load("key2"); ok {
+		t.Errorf("Expected to not find key2, but did")
+	} else if tick := ttlmap.tick.Load(); tick != 5 {
+		t.Errorf("Expected tick to be 5, but was %d", tick)
+	}
+}
+
 func BenchmarkStore(b *testing.B) {
 	ttlmap := New[string, string](time.Duration(b.N+1)*time.Minute, time.Minute)
 	for i := 0; i < b.N; i++ {
@@ -159,3 +613,158 @@ func BenchmarkNextGeneration(b *testing.B) {
 		ttlmap.nextGeneration()
 	}
 }
+
+func TestStoreWithTTL(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			ttlmap := newConformanceMap(newExpirer)
+			ttlmap.StoreWithTTL(1, 1, time.Hour)
+			ttlmap.StoreWithTTL(2, 2, 10*time.Hour)
+			ttlmap.nextGeneration()
+
+			if _, ok := ttlmap.Load(1); ok {
+				t.Errorf("Expected key 1 to expire after 1 tick, but did not")
+			} else if _, ok := ttlmap.LoadAndTouch(2); !ok {
+				t.Errorf("Expected to find key 2, but did not")
+			}
+
+			for i := 0; i < 9; i++ {
+				ttlmap.nextGeneration()
+				if _, ok := ttlmap.Load(2); !ok {
+					t.Errorf("Expected to find key 2 at tick %d, but did not", i+2)
+				}
+			}
+			ttlmap.nextGeneration()
+			if _, ok := ttlmap.Load(2); ok {
+				t.Errorf("Expected key 2 to expire after 11 ticks, but did not")
+			}
+		})
+	}
+}
+
+// TestConcurrentStore stores keys from many goroutines, and
+// verifies that the expiration of every key is tracked. Run it
+// with -race to detect unsynchronized access to generations.
+func TestConcurrentStore(t *testing.T) {
+	for name, newExpirer := range expirers {
+		t.Run(name, func(t *testing.T) {
+			ttlmap := newConformanceMap(newExpirer)
+
+			var wg sync.WaitGroup
+			for g := 0; g < 16; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 500; i++ {
+						ttlmap.Store(g*500+i, i)
+						if i%100 == 0 {
+							ttlmap.AdvanceTo(time.Now())
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			if n := ttlmap.count.Load(); n != 16*500 {
+				t.Errorf("Expected %d entries, but got %d", 16*500, n)
+			}
+			for i := 0; i < 4; i++ {
+				ttlmap.nextGeneration()
+			}
+			if n := ttlmap.count.Load(); n != 0 {
+				t.Errorf("Expected all entries to expire, but %d remain", n)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	ttlmap := New[string, int](time.Hour, time.Minute)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				ttlmap.Compute("key", func(old int, _ bool) (int, bool) {
+					return old + 1, false
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := ttlmap.Load("key"); value != 800 {
+		t.Errorf("Expected value to be 800, but was %d", value)
+	} else if _, ok := ttlmap.Compute("key", func(int, bool) (int, bool) { return 0, true }); ok {
+		t.Errorf("Expected key to be deleted, but was not")
+	} else if _, ok = ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestStoreUntilClock(t *testing.T) {
+	clock := &stoppedClock{now: time.Now().Add(-time.Hour)}
+	ttlmap := New(2*time.Hour, time.Hour, WithClock[string, string](clock))
+	ttlmap.StoreUntil("key", "value", clock.now.Add(time.Hour))
+
+	if expiresAt, ok := ttlmap.ExpiresAt("key"); !ok || !expiresAt.Equal(clock.now.Add(time.Hour)) {
+		t.Errorf("Expected key to expire 1 hour after the clock, but expires at %v", expiresAt)
+	}
+}
+
+// stoppedClock is a Clock that never moves.
+type stoppedClock struct {
+	now time.Time
+}
+
+func (c *stoppedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *stoppedClock) Ticker(time.Duration, func()) Ticker {
+	return stoppedTicker{}
+}
+
+// stoppedTicker is a Ticker that never ticks.
+type stoppedTicker struct{}
+
+func (stoppedTicker) Reset(time.Duration) {}
+
+func (stoppedTicker) Stop() {}
+
+func TestRescheduleMovedKey(t *testing.T) {
+	ttlmap := New(2*time.Hour, time.Hour, WithExpirer[string, string](NewIndexedGenerationExpirer[string](2)))
+	ttlmap.Store("key", "value")
+
+	// A touch of a replaced entry moves the key to an earlier
+	// deadline than the deadline of its entry.
+	ttlmap.schedule(1, "key")
+	ttlmap.nextGeneration()
+	ttlmap.nextGeneration()
+
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected key to expire, but it is present")
+	}
+}
+
+func TestClose(t *testing.T) {
+	var evicted int
+	ttlmap := New(time.Hour, time.Hour, WithOnEvict(func(key, value string, reason EvictionReason) {
+		evicted++
+	}))
+	ttlmap.Store("key", "value")
+	ttlmap.Close()
+	ttlmap.Close()
+
+	if evicted != 1 {
+		t.Errorf("Expected 1 evicted entry, but got %d", evicted)
+	} else if ttlmap.Len() != 0 {
+		t.Errorf("Expected closed map to be empty, but has %d entries", ttlmap.Len())
+	} else if err := ttlmap.TryStore("key", "value"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected store to fail with ErrClosed, but got %v", err)
+	} else if _, loaded := ttlmap.LoadOrStore("key", "value"); loaded || ttlmap.Len() != 0 {
+		t.Errorf("Expected LoadOrStore to not store, but it did")
+	}
+}
@@ -0,0 +1,258 @@
+// Package ttlmaphttp caches the responses of an http.Handler in
+// a TTLMap. Responses to GET requests are cached by the host
+// and URI of the request, with a TTL per route, and the request
+// headers named by the Vary header of the response are part of
+// the key.
+//
+// Responses are only cached when their status is cacheable,
+// they don't set cookies, and their Cache-Control header does
+// not forbid it with no-store or private. Requests with an
+// Authorization header, or with a no-store or no-cache
+// Cache-Control header, bypass the cache.
+package ttlmaphttp
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Response is a cached response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// vary is set on the entry of the key of a request when
+	// its responses vary, with the names of the headers they
+	// vary by. The responses are stored under their variant
+	// keys.
+	vary []string
+}
+
+// Option configures Middleware.
+type Option func(c *config)
+
+// config is the configuration of Middleware.
+type config struct {
+	ttl     time.Duration
+	routes  map[string]time.Duration
+	maxBody int64
+	m       *ttlmap.TTLMap[string, *Response]
+}
+
+// WithTTL sets the TTL of the responses of routes without a
+// TTL of their own, it is one minute by default.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithRoute sets the TTL of the responses to requests whose
+// path starts with prefix, the longest matching prefix wins. A
+// ttl that is not positive disables caching for the route.
+func WithRoute(prefix string, ttl time.Duration) Option {
+	return func(c *config) {
+		c.routes[prefix] = ttl
+	}
+}
+
+// WithMaxBodySize sets the size of the largest body that is
+// cached, it is 1 MiB by default.
+func WithMaxBodySize(n int64) Option {
+	return func(c *config) {
+		c.maxBody = n
+	}
+}
+
+// WithMap caches the responses in m instead of a map created
+// by Middleware, so they can be shared, inspected and
+// cleared, and the map can be closed. Responses are stored with
+// the TTL of their route.
+func WithMap(m *ttlmap.TTLMap[string, *Response]) Option {
+	return func(c *config) {
+		c.m = m
+	}
+}
+
+// Middleware returns a handler that serves the responses of
+// next from the cache. Without WithMap, the responses are
+// cached in a map that lives as long as the process, with the
+// longest TTL of the routes.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	c := &config{
+		ttl:     time.Minute,
+		routes:  make(map[string]time.Duration),
+		maxBody: 1 << 20,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.m == nil {
+		ttl, interval := c.ttl, c.ttl
+		for _, routeTTL := range c.routes {
+			if routeTTL > 0 {
+				ttl, interval = max(ttl, routeTTL), min(interval, routeTTL)
+			}
+		}
+		c.m = ttlmap.New[string, *Response](ttl, max(min(interval, time.Second), time.Millisecond))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ttl := c.routeTTL(r.URL.Path)
+		if r.Method != http.MethodGet || ttl <= 0 || bypass(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.Method + " " + r.Host + r.URL.RequestURI()
+		if cached, ok := c.m.Load(key); ok {
+			if cached.vary != nil {
+				cached, ok = c.m.Load(variant(key, cached.vary, r))
+			}
+			if ok {
+				serve(w, cached)
+				return
+			}
+		}
+
+		rec := &recorder{ResponseWriter: w, limit: c.maxBody}
+		next.ServeHTTP(rec, r)
+		if !rec.cacheable() {
+			return
+		}
+
+		resp := &Response{Status: rec.status, Header: w.Header().Clone(), Body: rec.body.Bytes()}
+		if vary := varyHeaders(resp.Header); vary == nil {
+			c.m.StoreWithTTL(key, resp, ttl)
+		} else {
+			c.m.StoreWithTTL(key, &Response{vary: vary}, ttl)
+			c.m.StoreWithTTL(variant(key, vary, r), resp, ttl)
+		}
+	})
+}
+
+// routeTTL returns the TTL of the route of path.
+func (c *config) routeTTL(path string) time.Duration {
+	ttl, longest := c.ttl, -1
+	for prefix, routeTTL := range c.routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			ttl, longest = routeTTL, len(prefix)
+		}
+	}
+	return ttl
+}
+
+// bypass reports whether r must not be served from the cache.
+func bypass(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" {
+		return true
+	}
+	cc := r.Header.Get("Cache-Control")
+	return strings.Contains(cc, "no-store") || strings.Contains(cc, "no-cache")
+}
+
+// varyHeaders returns the canonical names of the headers in
+// the Vary header of a response, or nil.
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// variant returns the key of the response to r, for responses
+// that vary by the headers in vary.
+func variant(key string, vary []string, r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// serve writes a cached response to w.
+func serve(w http.ResponseWriter, resp *Response) {
+	header := w.Header()
+	for name, values := range resp.Header {
+		header[name] = values
+	}
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// recorder passes a response through to the client, and
+// records it for the cache.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	limit  int64
+	// overflow is set when the body exceeds limit, the
+	// response is not cached.
+	overflow bool
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.overflow {
+		if int64(r.body.Len()+len(b)) > r.limit {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// cacheable reports whether the recorded response can be
+// cached.
+func (r *recorder) cacheable() bool {
+	if r.overflow {
+		return false
+	}
+	switch r.status {
+	case 0:
+		r.status = http.StatusOK
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+
+	header := r.Header()
+	cc := header.Get("Cache-Control")
+	return header.Get("Set-Cookie") == "" &&
+		!strings.Contains(cc, "no-store") && !strings.Contains(cc, "private") &&
+		header.Get("Vary") != "*"
+}
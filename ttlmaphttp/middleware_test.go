@@ -0,0 +1,125 @@
+package ttlmaphttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// counter is a handler that counts its calls, and replies
+// with the count.
+type counter struct {
+	calls  int
+	header http.Header
+	status int
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.calls++
+	for name, values := range c.header {
+		w.Header()[name] = values
+	}
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+	}
+	w.Write([]byte(strconv.Itoa(c.calls) + " " + r.Header.Get("Accept-Language")))
+}
+
+func get(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func newMap(t *testing.T) *ttlmap.TTLMap[string, *Response] {
+	m := ttlmap.NewManual[string, *Response](time.Hour, time.Second)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func TestMiddleware(t *testing.T) {
+	next := &counter{header: http.Header{"Content-Type": {"text/plain"}}}
+	h := Middleware(next, WithMap(newMap(t)))
+
+	get(h, "/a", nil)
+	if w := get(h, "/a", nil); w.Body.String() != "1 " || w.Code != http.StatusOK {
+		t.Errorf("Expected cached response, but got %d '%s'", w.Code, w.Body)
+	} else if w.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("Expected cached headers, but got %v", w.Header())
+	} else if w = get(h, "/a?page=2", nil); w.Body.String() != "2 " {
+		t.Errorf("Expected query to be part of the key, but got '%s'", w.Body)
+	} else if w = get(h, "/a", http.Header{"Authorization": {"Bearer token"}}); w.Body.String() != "3 " {
+		t.Errorf("Expected Authorization to bypass the cache, but got '%s'", w.Body)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/a", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if next.calls != 4 {
+		t.Errorf("Expected POST not to be cached, but got %d calls", next.calls)
+	}
+}
+
+func TestMiddlewareRoutes(t *testing.T) {
+	m := newMap(t)
+	next := &counter{}
+	h := Middleware(next, WithMap(m), WithTTL(time.Hour), WithRoute("/short", time.Minute), WithRoute("/short/never", 0))
+
+	get(h, "/long", nil)
+	get(h, "/short/a", nil)
+	get(h, "/short/never", nil)
+	if w := get(h, "/short/never", nil); w.Body.String() != "4 " {
+		t.Errorf("Expected route without TTL not to be cached, but got '%s'", w.Body)
+	}
+
+	m.Advance(int(time.Minute / time.Second))
+	if w := get(h, "/short/a", nil); w.Body.String() != "5 " {
+		t.Errorf("Expected short route to expire, but got '%s'", w.Body)
+	} else if w = get(h, "/long", nil); w.Body.String() != "1 " {
+		t.Errorf("Expected long route to stay cached, but got '%s'", w.Body)
+	}
+}
+
+func TestMiddlewareVary(t *testing.T) {
+	next := &counter{header: http.Header{"Vary": {"Accept-Language"}}}
+	h := Middleware(next, WithMap(newMap(t)))
+
+	en := http.Header{"Accept-Language": {"en"}}
+	nl := http.Header{"Accept-Language": {"nl"}}
+	get(h, "/", en)
+	get(h, "/", nl)
+	if w := get(h, "/", en); w.Body.String() != "1 en" {
+		t.Errorf("Expected variant for en, but got '%s'", w.Body)
+	} else if w = get(h, "/", nl); w.Body.String() != "2 nl" {
+		t.Errorf("Expected variant for nl, but got '%s'", w.Body)
+	}
+}
+
+func TestMiddlewareNotCacheable(t *testing.T) {
+	for _, next := range []*counter{
+		{status: http.StatusInternalServerError},
+		{header: http.Header{"Cache-Control": {"private, max-age=60"}}},
+		{header: http.Header{"Set-Cookie": {"session=1"}}},
+		{header: http.Header{"Vary": {"*"}}},
+	} {
+		h := Middleware(next, WithMap(newMap(t)))
+		get(h, "/", nil)
+		if get(h, "/", nil); next.calls != 2 {
+			t.Errorf("Expected response with %d %v not to be cached, but it was", next.status, next.header)
+		}
+	}
+
+	next := &counter{}
+	h := Middleware(next, WithMap(newMap(t)), WithMaxBodySize(1))
+	get(h, "/", nil)
+	if get(h, "/", nil); next.calls != 2 {
+		t.Errorf("Expected large body not to be cached, but it was")
+	}
+}
@@ -0,0 +1,159 @@
+// Package ttlmaplimit limits the rate of events per key, like
+// the requests of a client, with counters in a TTLMap. The
+// counters of keys that go quiet expire with the generations of
+// the map, so the limiter needs no cleanup.
+//
+//	limiter := ttlmaplimit.New[string](ttlmaplimit.Options{Limit: 100, Window: time.Minute})
+//	defer limiter.Close()
+//
+//	if !limiter.Allow(clientIP) {
+//		http.Error(w, "slow down", http.StatusTooManyRequests)
+//	}
+package ttlmaplimit
+
+import (
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Options configures a Limiter.
+type Options struct {
+	// Limit is the number of events allowed per window.
+	Limit int
+	// Window is the length of a window, it is one second when
+	// 0.
+	Window time.Duration
+	// Sliding counts with a sliding window instead of fixed
+	// windows, see Limiter.
+	Sliding bool
+	// Interval is the precision of the windows, it is a
+	// sixtieth of Window when 0. See ttlmap.New.
+	Interval time.Duration
+	// Clock is the source of time of the limiter, it is the
+	// system clock when nil.
+	Clock ttlmap.Clock
+}
+
+// counter is the count of events of a key.
+type counter struct {
+	// count is the number of events in the window, and
+	// previous the number in the window before it. They are
+	// only used for sliding windows.
+	count, previous int
+	// window is the number of the window since the unix
+	// epoch, for sliding windows.
+	window int64
+}
+
+// Limiter limits the rate of events per key.
+//
+// With fixed windows, a window starts at the first event of a
+// key, and its counter expires with the window, the precision
+// is the interval. This allows bursts of twice the limit
+// around the end of a window.
+//
+// With sliding windows, the windows are aligned to the clock,
+// and the count of the previous window is weighted by the
+// part of it that overlaps with a window ending now. This
+// smooths bursts at the edges of windows, at the cost of a
+// little more state per key.
+type Limiter[K comparable] struct {
+	m     *ttlmap.TTLMap[K, counter]
+	opts  Options
+	clock ttlmap.Clock // nil for the system clock
+}
+
+// New creates a Limiter.
+func New[K comparable](opts Options) *Limiter[K] {
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = max(opts.Window/60, time.Millisecond)
+	}
+
+	// Sliding windows need the count of the previous window,
+	// so their counters live for two windows.
+	ttl := opts.Window
+	if opts.Sliding {
+		ttl = 2 * opts.Window
+	}
+	var mapOpts []ttlmap.Option[K, counter]
+	if opts.Clock != nil {
+		mapOpts = append(mapOpts, ttlmap.WithClock[K, counter](opts.Clock))
+	}
+	return &Limiter[K]{
+		m:     ttlmap.New(ttl, opts.Interval, mapOpts...),
+		opts:  opts,
+		clock: opts.Clock,
+	}
+}
+
+// Allow counts an event of key, and reports whether it is
+// within the limit. Events that are not allowed are not
+// counted.
+func (l *Limiter[K]) Allow(key K) bool {
+	if l.opts.Sliding {
+		return l.allowSliding(key)
+	}
+
+	var allowed bool
+	l.m.Compute(key, func(old counter, exists bool) (counter, bool) {
+		// Compute keeps the deadline of the counter, so it
+		// expires at the end of its window.
+		if allowed = old.count < l.opts.Limit; allowed {
+			old.count++
+		}
+		return old, false
+	})
+	return allowed
+}
+
+// allowSliding is Allow with sliding windows.
+func (l *Limiter[K]) allowSliding(key K) bool {
+	now := l.now().UnixNano()
+	window, elapsed := now/int64(l.opts.Window), now%int64(l.opts.Window)
+	overlap := 1 - float64(elapsed)/float64(l.opts.Window)
+
+	var allowed bool
+	l.m.Compute(key, func(old counter, exists bool) (counter, bool) {
+		switch {
+		case old.window == window-1:
+			old.previous, old.count = old.count, 0
+		case old.window != window:
+			old.previous, old.count = 0, 0
+		}
+		old.window = window
+
+		estimate := float64(old.previous)*overlap + float64(old.count)
+		if allowed = estimate < float64(l.opts.Limit); allowed {
+			old.count++
+		}
+		return old, false
+	})
+	if allowed {
+		// The counter is needed until the window after this
+		// one ends.
+		l.m.Touch(key)
+	}
+	return allowed
+}
+
+// Reset forgets the events of key.
+func (l *Limiter[K]) Reset(key K) {
+	l.m.Delete(key)
+}
+
+// Close stops the limiter.
+func (l *Limiter[K]) Close() {
+	l.m.Close()
+}
+
+// now returns the current time of the clock of the limiter.
+func (l *Limiter[K]) now() time.Time {
+	if l.clock == nil {
+		return time.Now()
+	}
+	return l.clock.Now()
+}
@@ -0,0 +1,64 @@
+package ttlmaplimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap/ttlmaptest"
+)
+
+// allowed returns the number of n events of key that are
+// allowed.
+func allowed(l *Limiter[string], key string, n int) int {
+	count := 0
+	for range n {
+		if l.Allow(key) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLimiter(t *testing.T) {
+	clock := ttlmaptest.NewClock(time.Unix(0, 0))
+	l := New[string](Options{Limit: 3, Window: time.Minute, Clock: clock})
+	defer l.Close()
+
+	if n := allowed(l, "a", 5); n != 3 {
+		t.Errorf("Expected 3 allowed events, but got %d", n)
+	} else if n = allowed(l, "b", 1); n != 1 {
+		t.Errorf("Expected keys to be limited separately, but got %d", n)
+	}
+
+	clock.Advance(time.Minute)
+	if n := allowed(l, "a", 5); n != 3 {
+		t.Errorf("Expected a new window after the window passed, but got %d", n)
+	}
+
+	l.Reset("a")
+	if n := allowed(l, "a", 1); n != 1 {
+		t.Errorf("Expected reset key to be allowed, but got %d", n)
+	}
+}
+
+func TestLimiterSliding(t *testing.T) {
+	clock := ttlmaptest.NewClock(time.Unix(0, 0))
+	l := New[string](Options{Limit: 10, Window: time.Minute, Sliding: true, Clock: clock})
+	defer l.Close()
+
+	if n := allowed(l, "a", 20); n != 10 {
+		t.Errorf("Expected 10 allowed events, but got %d", n)
+	}
+
+	// Half of the previous window overlaps with a window that
+	// ends now, so half of its events still count.
+	clock.Advance(90 * time.Second)
+	if n := allowed(l, "a", 20); n != 5 {
+		t.Errorf("Expected 5 allowed events, but got %d", n)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if n := allowed(l, "a", 20); n != 10 {
+		t.Errorf("Expected 10 allowed events after two windows, but got %d", n)
+	}
+}
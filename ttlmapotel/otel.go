@@ -0,0 +1,113 @@
+// Package ttlmapotel instruments ttlmap maps with OpenTelemetry
+// traces and metrics. It is a separate module, so users of
+// ttlmap that don't use OpenTelemetry don't depend on it.
+package ttlmapotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// attrMap is the attribute with the name of the map, see
+// ttlmap.WithName.
+const attrMap = "ttlmap.map"
+
+// WithTracer creates a span for every call of a loader by
+// LoadOrCompute, so misses that went to the origin show up in
+// traces. Loaders don't receive a context, so the spans are
+// the roots of their traces.
+func WithTracer[K comparable, V any](tracer trace.Tracer) ttlmap.Option[K, V] {
+	return func(m *ttlmap.TTLMap[K, V]) {
+		ttlmap.WithHooks[K, V](ttlmap.Hooks[K]{
+			Load: func(K) func(err error) {
+				_, span := tracer.Start(context.Background(), "ttlmap.load",
+					trace.WithSpanKind(trace.SpanKindClient),
+					trace.WithAttributes(attributes(m)...))
+				return func(err error) {
+					if err != nil {
+						span.RecordError(err)
+						span.SetStatus(codes.Error, err.Error())
+					}
+					span.End()
+				}
+			},
+		})(m)
+	}
+}
+
+// WithMeter records the metrics of the map with meter:
+//
+//   - ttlmap.entries, the number of entries
+//   - ttlmap.hits and ttlmap.misses, the number of loads
+//   - ttlmap.hit_ratio, the fraction of loads that hit
+//   - ttlmap.evictions, the entries that left the map, by reason
+//   - ttlmap.advance.duration, the duration of the ticks
+//
+// The observed metrics read the Stats of the map, until it is
+// closed. Errors of creating the instruments are reported with
+// otel.Handle.
+func WithMeter[K comparable, V any](meter metric.Meter) ttlmap.Option[K, V] {
+	return func(m *ttlmap.TTLMap[K, V]) {
+		entries, err := meter.Int64ObservableGauge("ttlmap.entries",
+			metric.WithDescription("Number of entries in the map."))
+		otel.Handle(err)
+		hits, err := meter.Int64ObservableCounter("ttlmap.hits",
+			metric.WithDescription("Number of loads that found an entry."))
+		otel.Handle(err)
+		misses, err := meter.Int64ObservableCounter("ttlmap.misses",
+			metric.WithDescription("Number of loads that did not find an entry."))
+		otel.Handle(err)
+		hitRatio, err := meter.Float64ObservableGauge("ttlmap.hit_ratio",
+			metric.WithDescription("Fraction of loads that found an entry."))
+		otel.Handle(err)
+		evictions, err := meter.Int64Counter("ttlmap.evictions",
+			metric.WithDescription("Number of entries that left the map, by reason."))
+		otel.Handle(err)
+		advance, err := meter.Float64Histogram("ttlmap.advance.duration",
+			metric.WithDescription("Duration of advancing the map a tick."), metric.WithUnit("s"))
+		otel.Handle(err)
+
+		registration, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			stats := m.Stats()
+			attrs := metric.WithAttributes(attributes(m)...)
+			o.ObserveInt64(entries, stats.Entries, attrs)
+			o.ObserveInt64(hits, int64(stats.Hits), attrs)
+			o.ObserveInt64(misses, int64(stats.Misses), attrs)
+			o.ObserveFloat64(hitRatio, stats.HitRatio(), attrs)
+			return nil
+		}, entries, hits, misses, hitRatio)
+		otel.Handle(err)
+
+		ttlmap.WithHooks[K, V](ttlmap.Hooks[K]{
+			Removed: func(reason ttlmap.EvictionReason) {
+				attrs := append(attributes(m), attribute.String("reason", reason.String()))
+				evictions.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+			},
+			Advanced: func(_ int, duration time.Duration) {
+				advance.Record(context.Background(), duration.Seconds(), metric.WithAttributes(attributes(m)...))
+			},
+			Closed: func() {
+				if registration != nil {
+					otel.Handle(registration.Unregister())
+				}
+			},
+		})(m)
+	}
+}
+
+// attributes returns the attributes of the map. The name is
+// read on use, since options can name the map after WithMeter
+// or WithTracer.
+func attributes[K comparable, V any](m *ttlmap.TTLMap[K, V]) []attribute.KeyValue {
+	if name := m.Name(); name != "" {
+		return []attribute.KeyValue{attribute.String(attrMap, name)}
+	}
+	return nil
+}
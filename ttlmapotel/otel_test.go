@@ -0,0 +1,68 @@
+package ttlmapotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	m := ttlmap.New(time.Hour, time.Minute, WithTracer[string, string](provider.Tracer("test")))
+	defer m.Close()
+
+	_, _ = m.LoadOrCompute("key", func(string) (string, error) { return "value", nil })
+	_, _ = m.LoadOrCompute("key", func(string) (string, error) { return "value", nil })
+	_, _ = m.LoadOrCompute("failing", func(string) (string, error) { return "", errors.New("failed") })
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Errorf("Expected a span for 2 loader calls, but got %d", len(spans))
+	} else if spans[0].Name() != "ttlmap.load" || spans[0].Status().Code == codes.Error {
+		t.Errorf("Expected a successful ttlmap.load span, but got %s with %v", spans[0].Name(), spans[0].Status())
+	} else if spans[1].Status().Code != codes.Error {
+		t.Errorf("Expected the span of the failing loader to have an error status, but got %v", spans[1].Status())
+	}
+}
+
+func TestWithMeter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	m := ttlmap.NewManual(time.Second, time.Second, WithMeter[string, string](provider.Meter("test")), ttlmap.WithName[string, string]("test"))
+	defer m.Close()
+
+	m.Store("key", "value")
+	m.Load("key")
+	m.Load("missing")
+	m.Advance(1)
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Expected to collect metrics, but got '%v'", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, scope := range data.ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			metrics[metric.Name] = metric.Data
+		}
+	}
+
+	if ratio, ok := metrics["ttlmap.hit_ratio"].(metricdata.Gauge[float64]); !ok || ratio.DataPoints[0].Value != 0.5 {
+		t.Errorf("Expected a hit ratio of 0.5, but got %v", metrics["ttlmap.hit_ratio"])
+	} else if name, _ := ratio.DataPoints[0].Attributes.Value(attrMap); name.AsString() != "test" {
+		t.Errorf("Expected the metrics to have the name of the map, but got '%s'", name.AsString())
+	} else if evictions, ok := metrics["ttlmap.evictions"].(metricdata.Sum[int64]); !ok || evictions.DataPoints[0].Value != 1 {
+		t.Errorf("Expected 1 eviction, but got %v", metrics["ttlmap.evictions"])
+	} else if advance, ok := metrics["ttlmap.advance.duration"].(metricdata.Histogram[float64]); !ok || advance.DataPoints[0].Count != 1 {
+		t.Errorf("Expected 1 advance, but got %v", metrics["ttlmap.advance.duration"])
+	}
+}
@@ -0,0 +1,93 @@
+// Package ttlmapprom exposes the statistics of ttlmap maps as
+// Prometheus metrics.
+package ttlmapprom
+
+import (
+	"strconv"
+
+	"github.com/job79/ttlmap"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector that reads the Stats of
+// a map on every scrape.
+type Collector struct {
+	source ttlmap.StatsSource
+
+	entries            *prometheus.Desc
+	generationKeys     *prometheus.Desc
+	hits               *prometheus.Desc
+	misses             *prometheus.Desc
+	stores             *prometheus.Desc
+	deletes            *prometheus.Desc
+	expirations        *prometheus.Desc
+	droppedExpirations *prometheus.Desc
+	duration           *prometheus.Desc
+}
+
+// NewCollector creates a Collector for source. The name is
+// added to every metric as the "map" label, which allows
+// registering collectors of multiple maps.
+func NewCollector(name string, source ttlmap.StatsSource) *Collector {
+	labels := prometheus.Labels{"map": name}
+	desc := func(metric, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("ttlmap_"+metric, help, variableLabels, labels)
+	}
+
+	return &Collector{
+		source:             source,
+		entries:            desc("entries", "Number of entries in the map."),
+		generationKeys:     desc("generation_keys", "Number of keys scheduled in a generation, by generations until expiry.", "generation"),
+		hits:               desc("hits_total", "Number of loads that found an entry."),
+		misses:             desc("misses_total", "Number of loads that did not find an entry."),
+		stores:             desc("stores_total", "Number of entries added to the map."),
+		deletes:            desc("deletes_total", "Number of entries deleted explicitly."),
+		expirations:        desc("expirations_total", "Number of entries that expired."),
+		droppedExpirations: desc("dropped_expirations_total", "Number of expired entries dropped by a full expired channel."),
+		duration:           desc("operation_duration_seconds", "Latency of sampled operations of the map.", "operation"),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.generationKeys
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.stores
+	ch <- c.deletes
+	ch <- c.expirations
+	ch <- c.droppedExpirations
+	ch <- c.duration
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source.Stats()
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	for i, keys := range stats.Generations {
+		ch <- prometheus.MustNewConstMetric(c.generationKeys, prometheus.GaugeValue, float64(keys), strconv.Itoa(i))
+	}
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.stores, prometheus.CounterValue, float64(stats.Stores))
+	ch <- prometheus.MustNewConstMetric(c.deletes, prometheus.CounterValue, float64(stats.Deletes))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.droppedExpirations, prometheus.CounterValue, float64(stats.DroppedExpirations))
+	if stats.Latency != nil {
+		c.collectLatency(ch, "load", stats.Latency.Load)
+		c.collectLatency(ch, "store", stats.Latency.Store)
+		c.collectLatency(ch, "sweep", stats.Latency.Sweep)
+	}
+}
+
+// collectLatency sends the latency histogram of an operation.
+func (c *Collector) collectLatency(ch chan<- prometheus.Metric, operation string, h ttlmap.LatencyHistogram) {
+	buckets := make(map[float64]uint64, len(h.Bounds))
+	var count uint64
+	for i, bound := range h.Bounds {
+		count += h.Counts[i]
+		buckets[bound.Seconds()] = count
+	}
+	ch <- prometheus.MustNewConstHistogram(c.duration, h.Count, h.Sum.Seconds(), buckets, operation)
+}
@@ -0,0 +1,41 @@
+package ttlmapprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	m := ttlmap.New[string, string](time.Hour, time.Minute)
+	defer m.Close()
+	m.Store("key", "value")
+	m.Load("key")
+	m.Load("missing")
+
+	expected := `
+# HELP ttlmap_hits_total Number of loads that found an entry.
+# TYPE ttlmap_hits_total counter
+ttlmap_hits_total{map="test"} 1
+# HELP ttlmap_misses_total Number of loads that did not find an entry.
+# TYPE ttlmap_misses_total counter
+ttlmap_misses_total{map="test"} 1
+`
+	err := testutil.CollectAndCompare(NewCollector("test", m), strings.NewReader(expected), "ttlmap_hits_total", "ttlmap_misses_total")
+	if err != nil {
+		t.Errorf("Expected metrics to match, but got '%v'", err)
+	}
+}
+
+func TestCollectorLatency(t *testing.T) {
+	m := ttlmap.New(time.Hour, time.Minute, ttlmap.WithLatencyHistograms[string, string](1))
+	defer m.Close()
+	m.Load("key")
+
+	if n := testutil.CollectAndCount(NewCollector("test", m), "ttlmap_operation_duration_seconds"); n != 3 {
+		t.Errorf("Expected a histogram for 3 operations, but got %d", n)
+	}
+}
@@ -0,0 +1,125 @@
+// Package ttlmapredis migrates data from Redis to a TTLMap,
+// for teams that move small datasets to in-process caching.
+// It speaks the Redis protocol directly and has no
+// dependencies.
+package ttlmapredis
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"github.com/job79/ttlmap/internal/resp"
+)
+
+// Options configures Import.
+type Options struct {
+	// Match is the pattern of the keys to import, all keys
+	// are imported when it is empty.
+	Match string
+	// Count is the number of keys scanned per batch, it is
+	// 100 when 0.
+	Count int
+}
+
+// Import copies the string keys of the Redis server behind
+// conn into m, using SCAN to iterate the keys and PTTL to read
+// their TTL. Keys with a TTL are stored with StoreWithTTL, keys
+// without one get the TTL of the map. Keys that are not
+// strings, or that expire during the import, are skipped.
+//
+// The conn must be connected and authenticated, and have the
+// database to import selected. Import returns the number of
+// keys stored in m.
+func Import(conn io.ReadWriter, m ttlmap.Interface[string, string], opts Options) (int, error) {
+	if opts.Count <= 0 {
+		opts.Count = 100
+	}
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+
+	n, cursor := 0, "0"
+	for {
+		args := []string{"SCAN", cursor, "COUNT", strconv.Itoa(opts.Count)}
+		if opts.Match != "" {
+			args = append(args, "MATCH", opts.Match)
+		}
+		reply, err := call(r, w, resp.Command(args...))
+		if err != nil {
+			return n, err
+		} else if reply.Kind != resp.Array || len(reply.Array) != 2 {
+			return n, errors.New("ttlmapredis: unexpected SCAN reply")
+		}
+
+		var keys []string
+		for _, key := range reply.Array[1].Array {
+			keys = append(keys, key.Str)
+		}
+		stored, err := importKeys(r, w, m, keys)
+		n += stored
+		if err != nil {
+			return n, err
+		}
+
+		if cursor = reply.Array[0].Str; cursor == "0" {
+			return n, nil
+		}
+	}
+}
+
+// importKeys reads the values and TTLs of keys in a single
+// pipeline, and stores them in m.
+func importKeys(r *bufio.Reader, w *bufio.Writer, m ttlmap.Interface[string, string], keys []string) (int, error) {
+	for _, key := range keys {
+		if err := resp.Write(w, resp.Command("GET", key)); err != nil {
+			return 0, err
+		} else if err = resp.Write(w, resp.Command("PTTL", key)); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		value, err := resp.RedisLimits.Read(r)
+		if err != nil {
+			return n, err
+		}
+		ttl, err := resp.RedisLimits.Read(r)
+		if err != nil {
+			return n, err
+		}
+
+		// Missing keys have a null value, keys that are not
+		// strings reply with an error.
+		if value.Kind != resp.BulkString || value.Null || ttl.Kind != resp.Integer || ttl.Int == -2 {
+			continue
+		}
+		if ttl.Int == -1 {
+			m.Store(key, value.Str)
+		} else {
+			m.StoreWithTTL(key, value.Str, time.Duration(ttl.Int)*time.Millisecond)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// call sends a command and reads its reply.
+func call(r *bufio.Reader, w *bufio.Writer, command resp.Value) (resp.Value, error) {
+	if err := resp.Write(w, command); err != nil {
+		return resp.Value{}, err
+	} else if err = w.Flush(); err != nil {
+		return resp.Value{}, err
+	}
+
+	reply, err := resp.RedisLimits.Read(r)
+	if err != nil {
+		return resp.Value{}, err
+	}
+	return reply, reply.Err()
+}
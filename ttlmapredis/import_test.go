@@ -0,0 +1,64 @@
+package ttlmapredis
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"github.com/job79/ttlmap/internal/resp"
+)
+
+// serve replies to the commands of a client like a Redis
+// server that stores data, with TTLs in milliseconds.
+func serve(conn net.Conn, data map[string]string, ttls map[string]int64) {
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	for {
+		command, err := resp.Read(r)
+		if err != nil {
+			return
+		}
+
+		var reply resp.Value
+		switch args := command.Array; args[0].Str {
+		case "SCAN":
+			keys := resp.Value{Kind: resp.Array}
+			for key := range data {
+				keys.Array = append(keys.Array, resp.Bulk(key))
+			}
+			reply = resp.Value{Kind: resp.Array, Array: []resp.Value{resp.Bulk("0"), keys}}
+		case "GET":
+			value, ok := data[args[1].Str]
+			reply = resp.Value{Kind: resp.BulkString, Str: value, Null: !ok}
+		case "PTTL":
+			ttl, ok := ttls[args[1].Str]
+			if !ok {
+				ttl = -1
+			}
+			reply = resp.Value{Kind: resp.Integer, Int: ttl}
+		}
+		resp.Write(w, reply)
+		w.Flush()
+	}
+}
+
+func TestImport(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go serve(server, map[string]string{"key1": "value1", "key2": "value2"}, map[string]int64{"key2": 1000})
+
+	m := ttlmap.New[string, string](time.Hour, time.Second)
+	defer m.Close()
+	n, err := Import(client, m, Options{})
+
+	if err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	} else if n != 2 {
+		t.Errorf("Expected 2 imported keys, but got %d", n)
+	} else if value, ok := m.Load("key1"); !ok || value != "value1" {
+		t.Errorf("Expected value to be 'value1', but was '%s'", value)
+	} else if value, ok = m.LoadWithMinTTL("key2", time.Second); ok {
+		t.Errorf("Expected key2 to expire within a second, but got '%s'", value)
+	}
+}
@@ -0,0 +1,301 @@
+// Package ttlmapresp serves a TTLMap over the Redis protocol,
+// so existing Redis clients and sidecars that are not written
+// in Go can use the map, during development and in deployments
+// that only need memory. It supports GET, SET, SETEX, DEL, TTL,
+// EXPIRE and PING, and has no dependencies.
+//
+// Experimental: the set of commands may change in a minor
+// version.
+package ttlmapresp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"github.com/job79/ttlmap/internal/resp"
+)
+
+// ErrServerClosed is returned by Serve after Close.
+var ErrServerClosed = errors.New("ttlmapresp: server closed")
+
+// Server serves a map over the Redis protocol. Keys stored
+// without a TTL get the TTL of the map, the map has no keys
+// that don't expire.
+type Server struct {
+	m      *ttlmap.TTLMap[string, []byte]
+	limits resp.Limits
+
+	// mu guards listeners, conns and closed.
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithMaxBulkLength sets the maximum length of the keys and
+// values that clients send, which is 1MiB by default.
+func WithMaxBulkLength(n int) Option {
+	return func(s *Server) {
+		s.limits.BulkLength = n
+	}
+}
+
+// WithMaxArgs sets the maximum number of arguments of a
+// command, which is 1024 by default.
+func WithMaxArgs(n int) Option {
+	return func(s *Server) {
+		s.limits.ArrayLength = n
+	}
+}
+
+// NewServer creates a Server for m. Input beyond the limits of
+// the server is replied to with a protocol error, and closes
+// the connection.
+func NewServer(m *ttlmap.TTLMap[string, []byte], opts ...Option) *Server {
+	s := &Server{
+		m:         m,
+		limits:    resp.DefaultLimits,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	// Commands are arrays of bulk strings.
+	s.limits.Depth = 2
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Serve accepts connections on l and serves each of them in a
+// goroutine, until Close is called. It returns ErrServerClosed
+// after Close, or the error of l.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.listeners, l)
+			if s.closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves the commands of a client on conn, until it
+// disconnects or Close is called. It closes conn.
+func (s *Server) ServeConn(conn net.Conn) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	for {
+		command, err := s.limits.Read(r)
+		var protocolErr *resp.ProtocolError
+		if errors.As(err, &protocolErr) {
+			// The rest of the input can't be parsed, reply
+			// like Redis and close the connection.
+			_ = resp.Write(w, resp.Value{Kind: resp.Error, Str: "ERR Protocol error: " + protocolErr.Reason})
+			_ = w.Flush()
+			return
+		} else if err != nil {
+			return
+		}
+		if err = resp.Write(w, s.execute(command)); err != nil {
+			return
+		}
+		// Pipelined commands are replied to in one write.
+		if r.Buffered() == 0 {
+			if err = w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close stops the listeners of Serve and closes the
+// connections. The map is not closed.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+
+	var errs []error
+	for l := range s.listeners {
+		errs = append(errs, l.Close())
+	}
+	for conn := range s.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// execute executes a command and returns its reply.
+func (s *Server) execute(command resp.Value) resp.Value {
+	if command.Kind != resp.Array || len(command.Array) == 0 {
+		return errorf("ERR protocol error: expected a command")
+	}
+	args := make([]string, len(command.Array))
+	for i, arg := range command.Array {
+		if arg.Kind != resp.BulkString || arg.Null {
+			return errorf("ERR protocol error: expected bulk strings")
+		}
+		args[i] = arg.Str
+	}
+
+	name := strings.ToUpper(args[0])
+	switch {
+	case name == "PING" && len(args) <= 2:
+		if len(args) == 2 {
+			return resp.Bulk(args[1])
+		}
+		return resp.Value{Kind: resp.SimpleString, Str: "PONG"}
+	case name == "GET" && len(args) == 2:
+		value, ok := s.m.Load(args[1])
+		return resp.Value{Kind: resp.BulkString, Str: string(value), Null: !ok}
+	case name == "SET" && len(args) >= 3:
+		return s.set(args[1], args[2], args[3:])
+	case name == "SETEX" && len(args) == 4:
+		ttl, valid := duration(args[2], time.Second)
+		if !valid || ttl <= 0 {
+			return errorf("ERR invalid expire time in 'setex' command")
+		}
+		s.m.StoreWithTTL(args[1], []byte(args[3]), ttl)
+		return ok()
+	case name == "DEL" && len(args) >= 2:
+		n := int64(0)
+		for _, key := range args[1:] {
+			if _, deleted := s.m.LoadAndDelete(key); deleted {
+				n++
+			}
+		}
+		return resp.Value{Kind: resp.Integer, Int: n}
+	case name == "TTL" && len(args) == 2:
+		return resp.Value{Kind: resp.Integer, Int: s.ttl(args[1])}
+	case name == "EXPIRE" && len(args) == 3:
+		if _, err := strconv.ParseInt(args[2], 10, 64); err != nil {
+			return errorf("ERR value is not an integer or out of range")
+		}
+		ttl, valid := duration(args[2], time.Second)
+		if !valid {
+			return errorf("ERR invalid expire time in 'expire' command")
+		}
+		return resp.Value{Kind: resp.Integer, Int: s.expire(args[1], ttl)}
+	case name == "PING" || name == "GET" || name == "SET" || name == "SETEX" ||
+		name == "DEL" || name == "TTL" || name == "EXPIRE":
+		return errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(name))
+	default:
+		return errorf("ERR unknown command '%s'", args[0])
+	}
+}
+
+// set executes SET, with the EX and PX options.
+func (s *Server) set(key, value string, opts []string) resp.Value {
+	if len(opts) == 0 {
+		s.m.Store(key, []byte(value))
+		return ok()
+	} else if len(opts) != 2 {
+		return errorf("ERR syntax error")
+	}
+
+	var unit time.Duration
+	switch strings.ToUpper(opts[0]) {
+	case "EX":
+		unit = time.Second
+	case "PX":
+		unit = time.Millisecond
+	default:
+		return errorf("ERR syntax error")
+	}
+	ttl, valid := duration(opts[1], unit)
+	if !valid || ttl <= 0 {
+		return errorf("ERR invalid expire time in 'set' command")
+	}
+	s.m.StoreWithTTL(key, []byte(value), ttl)
+	return ok()
+}
+
+// ttl returns the remaining TTL of key in seconds, -2 when it
+// is missing and -1 when it doesn't expire, like Redis.
+func (s *Server) ttl(key string) int64 {
+	expiresAt, ok := s.m.ExpiresAt(key)
+	if !ok {
+		if _, found := s.m.Load(key); found {
+			return -1
+		}
+		return -2
+	}
+	ttl := expiresAt.Sub(s.m.Clock().Now())
+	return int64((ttl + time.Second/2) / time.Second)
+}
+
+// expire executes EXPIRE, a TTL that is not positive deletes
+// the key, like Redis.
+func (s *Server) expire(key string, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		if _, ok := s.m.LoadAndDelete(key); ok {
+			return 1
+		}
+		return 0
+	}
+	if s.m.Promote(key, ttl) {
+		return 1
+	}
+	return 0
+}
+
+// duration parses an integer argument as a number of units.
+// It reports false when the argument is not an integer, or the
+// duration doesn't fit a time.Duration.
+func duration(arg string, unit time.Duration) (time.Duration, bool) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// ok returns the OK reply.
+func ok() resp.Value {
+	return resp.Value{Kind: resp.SimpleString, Str: "OK"}
+}
+
+// errorf returns an error reply.
+func errorf(format string, args ...any) resp.Value {
+	return resp.Value{Kind: resp.Error, Str: fmt.Sprintf(format, args...)}
+}
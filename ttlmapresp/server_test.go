@@ -0,0 +1,206 @@
+package ttlmapresp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+	"github.com/job79/ttlmap/internal/resp"
+	"github.com/job79/ttlmap/ttlmaptest"
+)
+
+// client sends commands to a server and reads their replies.
+type client struct {
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (c *client) call(t *testing.T, args ...string) resp.Value {
+	t.Helper()
+	resp.Write(c.w, resp.Command(args...))
+	c.w.Flush()
+	reply, err := resp.Read(c.r)
+	if err != nil {
+		t.Fatalf("Expected reply to %v, but got '%v'", args, err)
+	}
+	return reply
+}
+
+func newClient(t *testing.T, m *ttlmap.TTLMap[string, []byte], opts ...Option) *client {
+	conn, server := net.Pipe()
+	s := NewServer(m, opts...)
+	go s.ServeConn(server)
+	t.Cleanup(func() {
+		conn.Close()
+		s.Close()
+	})
+	return &client{r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+}
+
+func TestServer(t *testing.T) {
+	m := ttlmap.New[string, []byte](time.Hour, time.Second)
+	defer m.Close()
+	c := newClient(t, m)
+
+	if reply := c.call(t, "SET", "key", "value"); reply.Str != "OK" {
+		t.Errorf("Expected SET to reply OK, but got %+v", reply)
+	} else if reply = c.call(t, "GET", "key"); reply.Str != "value" {
+		t.Errorf("Expected GET to reply 'value', but got %+v", reply)
+	} else if reply = c.call(t, "GET", "missing"); !reply.Null {
+		t.Errorf("Expected GET of a missing key to reply null, but got %+v", reply)
+	} else if reply = c.call(t, "TTL", "key"); reply.Int != 3600 {
+		t.Errorf("Expected TTL to be the TTL of the map, but got %+v", reply)
+	} else if reply = c.call(t, "TTL", "missing"); reply.Int != -2 {
+		t.Errorf("Expected TTL of a missing key to be -2, but got %+v", reply)
+	}
+
+	if reply := c.call(t, "SETEX", "short", "60", "value"); reply.Str != "OK" {
+		t.Errorf("Expected SETEX to reply OK, but got %+v", reply)
+	} else if reply = c.call(t, "TTL", "short"); reply.Int != 60 {
+		t.Errorf("Expected TTL to be 60, but got %+v", reply)
+	} else if reply = c.call(t, "SET", "px", "value", "PX", "30000"); reply.Str != "OK" {
+		t.Errorf("Expected SET with PX to reply OK, but got %+v", reply)
+	} else if reply = c.call(t, "TTL", "px"); reply.Int != 30 {
+		t.Errorf("Expected TTL to be 30, but got %+v", reply)
+	} else if reply = c.call(t, "EXPIRE", "short", "120"); reply.Int != 1 {
+		t.Errorf("Expected EXPIRE to reply 1, but got %+v", reply)
+	} else if reply = c.call(t, "TTL", "short"); reply.Int != 120 {
+		t.Errorf("Expected TTL to be 120 after EXPIRE, but got %+v", reply)
+	} else if reply = c.call(t, "EXPIRE", "missing", "120"); reply.Int != 0 {
+		t.Errorf("Expected EXPIRE of a missing key to reply 0, but got %+v", reply)
+	}
+
+	if reply := c.call(t, "DEL", "key", "short", "missing"); reply.Int != 2 {
+		t.Errorf("Expected DEL to reply 2, but got %+v", reply)
+	} else if _, ok := m.Load("key"); ok {
+		t.Errorf("Expected DEL to delete key, but it was loaded")
+	} else if reply = c.call(t, "EXPIRE", "px", "0"); reply.Int != 1 {
+		t.Errorf("Expected EXPIRE of 0 to reply 1, but got %+v", reply)
+	} else if _, ok := m.Load("px"); ok {
+		t.Errorf("Expected EXPIRE of 0 to delete px, but it was loaded")
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	m := ttlmap.New[string, []byte](time.Hour, time.Second)
+	defer m.Close()
+	c := newClient(t, m)
+
+	if reply := c.call(t, "PING"); reply.Str != "PONG" {
+		t.Errorf("Expected PING to reply PONG, but got %+v", reply)
+	} else if reply = c.call(t, "HGET", "key", "field"); reply.Err() == nil {
+		t.Errorf("Expected unknown command to reply an error, but got %+v", reply)
+	} else if reply = c.call(t, "GET"); reply.Err() == nil {
+		t.Errorf("Expected wrong number of arguments to reply an error, but got %+v", reply)
+	} else if reply = c.call(t, "SETEX", "key", "-1", "value"); reply.Err() == nil {
+		t.Errorf("Expected invalid expire time to reply an error, but got %+v", reply)
+	} else if reply = c.call(t, "SET", "key", "value", "KEEPTTL"); reply.Err() == nil {
+		t.Errorf("Expected unsupported option to reply an error, but got %+v", reply)
+	} else if reply = c.call(t, "get", "key"); !reply.Null {
+		t.Errorf("Expected commands to be case insensitive, but got %+v", reply)
+	} else if reply = c.call(t, "SETEX", "key", "9223372036854775807", "value"); reply.Err() == nil {
+		t.Errorf("Expected an overflowing expire time to reply an error, but got %+v", reply)
+	} else if reply = c.call(t, "SET", "key", "value", "EX", "9223372036854775807"); reply.Err() == nil {
+		t.Errorf("Expected an overflowing expire time to reply an error, but got %+v", reply)
+	} else if reply = c.call(t, "EXPIRE", "key", "9223372036854775807"); reply.Err() == nil {
+		t.Errorf("Expected an overflowing expire time to reply an error, but got %+v", reply)
+	}
+
+	c.w.WriteString("*2\r\n+GET\r\n$3\r\nkey\r\n")
+	c.w.Flush()
+	if reply, err := resp.Read(c.r); err != nil || reply.Err() == nil {
+		t.Errorf("Expected arguments that are not bulk strings to reply an error, but got %+v and '%v'", reply, err)
+	} else if reply = c.call(t, "PING"); reply.Str != "PONG" {
+		t.Errorf("Expected the connection to stay open, but got %+v", reply)
+	}
+}
+
+func TestServerClock(t *testing.T) {
+	clock := ttlmaptest.NewClock(time.Now().Add(-time.Hour))
+	m := ttlmap.New(time.Hour, time.Second, ttlmap.WithClock[string, []byte](clock))
+	defer m.Close()
+	c := newClient(t, m)
+
+	if reply := c.call(t, "SETEX", "key", "60", "value"); reply.Str != "OK" {
+		t.Errorf("Expected SETEX to reply OK, but got %+v", reply)
+	} else if reply = c.call(t, "TTL", "key"); reply.Int != 60 {
+		t.Errorf("Expected TTL by the clock of the map to be 60, but got %+v", reply)
+	}
+}
+
+func TestServerProtocolError(t *testing.T) {
+	m := ttlmap.New[string, []byte](time.Hour, time.Second)
+	defer m.Close()
+
+	inputs := []string{
+		"$2000000000\r\n",
+		"*2000000000\r\n",
+		"*1\r\n$1048577\r\n",
+		"*2\r\n$3\r\nGET\r\n*1\r\n$3\r\nkey\r\n",
+		"+" + strings.Repeat("a", 1<<17) + "\r\n",
+	}
+	for _, line := range inputs {
+		c := newClient(t, m)
+		go func() {
+			c.w.WriteString(line)
+			c.w.Flush()
+		}()
+
+		if reply, err := resp.Read(c.r); err != nil || reply.Err() == nil {
+			t.Errorf("Expected %q to reply a protocol error, but got %+v and '%v'", line, reply, err)
+		} else if _, err = resp.Read(c.r); err == nil {
+			t.Errorf("Expected %q to close the connection, but it is open", line)
+		}
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	m := ttlmap.New[string, []byte](time.Hour, time.Second)
+	defer m.Close()
+	c := newClient(t, m, WithMaxBulkLength(4), WithMaxArgs(2))
+
+	if reply := c.call(t, "GET", "key"); !reply.Null {
+		t.Errorf("Expected GET within the limits to reply null, but got %+v", reply)
+	}
+	go func() {
+		resp.Write(c.w, resp.Command("SET", "key", "value"))
+		c.w.Flush()
+	}()
+	if reply, err := resp.Read(c.r); err != nil || reply.Err() == nil {
+		t.Errorf("Expected a command beyond the limits to reply a protocol error, but got %+v and '%v'", reply, err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	m := ttlmap.New[string, []byte](time.Hour, time.Second)
+	defer m.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Listen failed: %v", err)
+	}
+
+	s := NewServer(m)
+	done := make(chan error)
+	go func() { done <- s.Serve(l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	defer conn.Close()
+	c := &client{r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if reply := c.call(t, "SET", "key", "value"); reply.Str != "OK" {
+		t.Errorf("Expected SET to reply OK, but got %+v", reply)
+	}
+
+	s.Close()
+	if err := <-done; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed, but got '%v'", err)
+	} else if _, err := resp.Read(c.r); err == nil {
+		t.Errorf("Expected Close to close the connection, but it is open")
+	}
+}
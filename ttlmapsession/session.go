@@ -0,0 +1,169 @@
+// Package ttlmapsession stores the sessions of a web
+// application in a TTLMap, and tracks them with a cookie.
+// Sessions expire when they are idle for the idle timeout,
+// every request that loads a session extends it. It has no
+// dependencies.
+//
+//	store := ttlmapsession.New[User](ttlmapsession.Options{Secure: true})
+//	defer store.Close()
+//
+//	// On login:
+//	store.Save(w, r, user)
+//	// On every request:
+//	user, ok := store.Get(r)
+//	// On logout:
+//	store.Destroy(w, r)
+package ttlmapsession
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Options configures a Store.
+type Options struct {
+	// Name is the name of the cookie, it is "session" when
+	// empty.
+	Name string
+	// IdleTimeout is the time after which a session that is
+	// not used expires, it is 30 minutes when 0.
+	IdleTimeout time.Duration
+	// Path and Domain scope the cookie, Path is "/" when
+	// empty.
+	Path   string
+	Domain string
+	// Secure restricts the cookie to HTTPS.
+	Secure bool
+	// SameSite is the SameSite attribute of the cookie, it is
+	// http.SameSiteLaxMode when 0.
+	SameSite http.SameSite
+}
+
+// Store stores sessions with a value of type T. The cookie only
+// contains the random id of a session, its value stays on the
+// server.
+type Store[T any] struct {
+	m    *ttlmap.TTLMap[string, T]
+	opts Options
+}
+
+// New creates a Store.
+func New[T any](opts Options) *Store[T] {
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = 30 * time.Minute
+	}
+	interval := max(opts.IdleTimeout/60, time.Millisecond)
+	return NewWithMap(ttlmap.New[string, T](opts.IdleTimeout, interval, ttlmap.WithSlidingTTL[string, T]()), opts)
+}
+
+// NewWithMap creates a Store on m, whose TTL is the idle
+// timeout, so the map can be configured, for example with
+// WithPersistence to keep sessions across restarts. The map
+// must be touched by loads, with WithSlidingTTL, for sessions
+// to expire when they are idle. IdleTimeout is ignored.
+func NewWithMap[T any](m *ttlmap.TTLMap[string, T], opts Options) *Store[T] {
+	if opts.Name == "" {
+		opts.Name = "session"
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	return &Store[T]{m: m, opts: opts}
+}
+
+// Get returns the value of the session of r, and extends it.
+// The ok result reports whether r has a session.
+func (s *Store[T]) Get(r *http.Request) (value T, ok bool) {
+	id, ok := s.id(r)
+	if !ok {
+		return value, false
+	}
+	return s.m.Load(id)
+}
+
+// Save stores value as the session of r. Requests without a
+// session get a new one, whose cookie is set on w.
+func (s *Store[T]) Save(w http.ResponseWriter, r *http.Request, value T) {
+	id, ok := s.id(r)
+	if !ok || !s.m.Touch(id) {
+		id = newID()
+		http.SetCookie(w, s.cookie(id, 0))
+	}
+	s.m.Store(id, value)
+}
+
+// Renew moves the session of r to a new id, and sets its
+// cookie on w. Renewing the session on login prevents session
+// fixation. It reports whether r has a session.
+func (s *Store[T]) Renew(w http.ResponseWriter, r *http.Request) bool {
+	id, ok := s.id(r)
+	if !ok {
+		return false
+	}
+	value, ok := s.m.LoadAndDelete(id)
+	if !ok {
+		return false
+	}
+
+	id = newID()
+	s.m.Store(id, value)
+	http.SetCookie(w, s.cookie(id, 0))
+	return true
+}
+
+// Destroy deletes the session of r, and removes its cookie
+// with w.
+func (s *Store[T]) Destroy(w http.ResponseWriter, r *http.Request) {
+	if id, ok := s.id(r); ok {
+		s.m.Delete(id)
+	}
+	http.SetCookie(w, s.cookie("", -1))
+}
+
+// Len returns the number of sessions.
+func (s *Store[T]) Len() int {
+	return s.m.Len()
+}
+
+// Close closes the map of the store.
+func (s *Store[T]) Close() {
+	s.m.Close()
+}
+
+// id returns the id in the cookie of r.
+func (s *Store[T]) id(r *http.Request) (string, bool) {
+	c, err := r.Cookie(s.opts.Name)
+	if err != nil || c.Value == "" {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// cookie returns the cookie of a session, see http.Cookie for
+// maxAge.
+func (s *Store[T]) cookie(id string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.opts.Name,
+		Value:    id,
+		Path:     s.opts.Path,
+		Domain:   s.opts.Domain,
+		MaxAge:   maxAge,
+		Secure:   s.opts.Secure,
+		HttpOnly: true,
+		SameSite: s.opts.SameSite,
+	}
+}
+
+// newID returns a random session id.
+func newID() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
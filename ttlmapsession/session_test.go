@@ -0,0 +1,91 @@
+package ttlmapsession
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// request returns a request with the cookies set by w.
+func request(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func newStore(t *testing.T) (*Store[string], *ttlmap.TTLMap[string, string]) {
+	m := ttlmap.NewManual(time.Minute, time.Second, ttlmap.WithSlidingTTL[string, string]())
+	s := NewWithMap(m, Options{})
+	t.Cleanup(s.Close)
+	return s, m
+}
+
+func TestStore(t *testing.T) {
+	s, m := newStore(t)
+
+	w := httptest.NewRecorder()
+	s.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), "alice")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" || !cookies[0].HttpOnly || cookies[0].Path != "/" {
+		t.Fatalf("Expected a session cookie, but got %v", cookies)
+	}
+
+	r := request(w)
+	if value, ok := s.Get(r); !ok || value != "alice" {
+		t.Errorf("Expected session 'alice', but got '%s'", value)
+	} else if _, ok = s.Get(httptest.NewRequest(http.MethodGet, "/", nil)); ok {
+		t.Errorf("Expected request without cookie to have no session, but it had one")
+	}
+
+	w = httptest.NewRecorder()
+	if s.Save(w, r, "bob"); len(w.Result().Cookies()) != 0 {
+		t.Errorf("Expected existing session to keep its cookie, but got %v", w.Result().Cookies())
+	} else if value, _ := s.Get(r); value != "bob" {
+		t.Errorf("Expected session 'bob', but got '%s'", value)
+	}
+
+	// Loads extend the session.
+	m.Advance(40)
+	s.Get(r)
+	m.Advance(40)
+	if _, ok := s.Get(r); !ok {
+		t.Errorf("Expected used session not to expire, but it expired")
+	}
+	m.Advance(60)
+	if _, ok := s.Get(r); ok {
+		t.Errorf("Expected idle session to expire, but it was loaded")
+	}
+}
+
+func TestStoreRenewDestroy(t *testing.T) {
+	s, _ := newStore(t)
+	w := httptest.NewRecorder()
+	s.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), "alice")
+	old := request(w)
+
+	w = httptest.NewRecorder()
+	if !s.Renew(w, old) {
+		t.Fatalf("Expected session to be renewed, but it was not")
+	}
+	renewed := request(w)
+	if _, ok := s.Get(old); ok {
+		t.Errorf("Expected old id to be invalid, but it had a session")
+	} else if value, ok := s.Get(renewed); !ok || value != "alice" {
+		t.Errorf("Expected renewed session 'alice', but got '%s'", value)
+	}
+
+	w = httptest.NewRecorder()
+	s.Destroy(w, renewed)
+	if _, ok := s.Get(renewed); ok {
+		t.Errorf("Expected destroyed session to be deleted, but it was loaded")
+	} else if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("Expected Destroy to remove the cookie, but got %v", c)
+	} else if s.Len() != 0 {
+		t.Errorf("Expected no sessions, but got %d", s.Len())
+	}
+}
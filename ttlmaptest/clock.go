@@ -0,0 +1,115 @@
+package ttlmaptest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// Clock is a fake ttlmap.Clock, which only moves when it is
+// advanced. It makes tests of expiration deterministic and
+// fast, for example:
+//
+//	clock := ttlmaptest.NewClock(time.Now())
+//	m := ttlmap.New(time.Minute, time.Second, ttlmap.WithClock[string, string](clock))
+//	m.Store("key", "value")
+//	clock.Advance(time.Minute)
+//	// "key" expired.
+//
+// Tickers are called synchronously by Advance, so a map has
+// expired its entries once Advance returns.
+type Clock struct {
+	// advanceMu serializes Advance, so calls of a ticker don't
+	// overlap.
+	advanceMu sync.Mutex
+
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*ticker
+}
+
+// NewClock creates a Clock that starts at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Ticker starts a ticker that calls f every d of the clock.
+func (c *Clock) Ticker(d time.Duration, f func()) ttlmap.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &ticker{clock: c, period: d, next: c.now.Add(d), f: f}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d. Every tick that is due
+// on the way is delivered in order, with the clock set to the
+// time of the tick.
+func (c *Clock) Advance(d time.Duration) {
+	c.advanceMu.Lock()
+	defer c.advanceMu.Unlock()
+
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		var due *ticker
+		for _, t := range c.tickers {
+			if !t.next.After(end) && (due == nil || t.next.Before(due.next)) {
+				due = t
+			}
+		}
+		if due == nil {
+			c.now = end
+			c.mu.Unlock()
+			return
+		}
+		c.now = due.next
+		due.next = due.next.Add(due.period)
+		c.mu.Unlock()
+
+		// Call the ticker without holding mu, it might
+		// read the clock or reset itself.
+		due.f()
+	}
+}
+
+// ticker is a ttlmap.Ticker of a Clock.
+type ticker struct {
+	clock  *Clock
+	period time.Duration
+	next   time.Time
+	f      func()
+}
+
+// Reset changes the period of the ticker to d, the next tick
+// is due d after the current time of the clock.
+func (t *ticker) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.period = d
+	t.next = t.clock.now.Add(d)
+}
+
+// Stop removes the ticker from the clock.
+func (t *ticker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, other := range t.clock.tickers {
+		if other == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package ttlmaptest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+func TestClock(t *testing.T) {
+	clock := NewClock(time.Now())
+	m := ttlmap.New(2*time.Minute, time.Minute, ttlmap.WithClock[string, string](clock))
+	defer m.Close()
+	m.Store("key", "value")
+
+	clock.Advance(time.Minute)
+	if _, ok := m.Load("key"); !ok {
+		t.Errorf("Expected key to be present after 1 minute, but it expired")
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := m.Load("key"); ok {
+		t.Errorf("Expected key to expire after 2 minutes, but it is present")
+	}
+}
+
+func TestClockStop(t *testing.T) {
+	clock := NewClock(time.Now())
+	calls := 0
+	ticker := clock.Ticker(time.Second, func() { calls++ })
+
+	clock.Advance(3 * time.Second)
+	ticker.Stop()
+	clock.Advance(3 * time.Second)
+
+	if calls != 3 {
+		t.Errorf("Expected 3 ticks, but got %d", calls)
+	}
+}
@@ -0,0 +1,181 @@
+// Package ttlmaptest provides utilities for testing code that
+// uses ttlmap, and for validating custom expiry engines.
+package ttlmaptest
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// conformanceTTL and conformanceInterval are the TTL and
+// interval of the maps created by Conformance. The interval is
+// long, so the internal ticker never fires during the suite.
+const (
+	conformanceTTL      = 4 * time.Hour
+	conformanceInterval = time.Hour
+)
+
+// Conformance verifies that maps created by factory follow
+// the contracts of the package: TTL semantics, expiration
+// accuracy and safety for concurrent use. It allows users of
+// custom expiry engines to validate them, for example:
+//
+//	ttlmaptest.Conformance(t, func(ttl, interval time.Duration) ttlmap.Interface[string, string] {
+//		return ttlmap.New(ttl, interval, ttlmap.WithExpirer[string, string](NewMyExpirer[string]()))
+//	})
+//
+// The factory must return a new map with the given ttl and
+// interval for every call, which can be any implementation of
+// ttlmap.Interface. Expiration is driven with AdvanceTo.
+func Conformance(t *testing.T, factory func(ttl, interval time.Duration) ttlmap.Interface[string, string]) {
+	t.Run("StoreLoad", func(t *testing.T) {
+		m, _ := newConformanceMap(factory)
+		defer m.Close()
+		m.Store("key", "value")
+
+		if value, ok := m.Load("key"); !ok || value != "value" {
+			t.Errorf("Expected value to be 'value', but was '%s'", value)
+		} else if _, ok = m.Load("missing"); ok {
+			t.Errorf("Expected to not find missing key, but did")
+		}
+	})
+
+	t.Run("ExpiryBounds", func(t *testing.T) {
+		m, advance := newConformanceMap(factory)
+		defer m.Close()
+		m.Store("key", "value")
+
+		advance(3)
+		if _, ok := m.Load("key"); !ok {
+			t.Errorf("Expected to find key before ttl passed, but did not")
+		}
+		advance(4)
+		if _, ok := m.Load("key"); ok {
+			t.Errorf("Expected to not find key after ttl passed, but did")
+		}
+	})
+
+	t.Run("DeleteReuse", func(t *testing.T) {
+		m, advance := newConformanceMap(factory)
+		defer m.Close()
+		m.Store("key", "value")
+		advance(2)
+		m.Delete("key")
+		m.Store("key", "value")
+
+		advance(5)
+		if _, ok := m.Load("key"); !ok {
+			t.Errorf("Expected reused key to get a fresh TTL, but it expired")
+		}
+		advance(6)
+		if _, ok := m.Load("key"); ok {
+			t.Errorf("Expected to not find key after ttl passed, but did")
+		}
+	})
+
+	t.Run("MissedTicks", func(t *testing.T) {
+		m, advance := newConformanceMap(factory)
+		defer m.Close()
+		m.Store("key", "value")
+
+		advance(10)
+		if _, ok := m.Load("key"); ok {
+			t.Errorf("Expected to not find key after missed ticks, but did")
+		}
+	})
+
+	t.Run("Model", func(t *testing.T) {
+		m, advance := newConformanceMap(factory)
+		defer m.Close()
+		ticks := uint64(conformanceTTL / conformanceInterval)
+		deadlines := make(map[string]uint64)
+		rand := uint64(1)
+
+		for tick := uint64(0); tick < 64; tick++ {
+			for i := 0; i < 32; i++ {
+				rand = rand*6364136223846793005 + 1442695040888963407
+				key := strconv.Itoa(int(rand>>33) % 16)
+
+				switch (rand >> 20) % 4 {
+				case 0, 1:
+					m.Store(key, key)
+					deadlines[key] = tick + ticks
+				case 2:
+					if _, ok := m.LoadAndTouch(key); ok {
+						deadlines[key] = tick + ticks
+					}
+				case 3:
+					m.Delete(key)
+					delete(deadlines, key)
+				}
+			}
+
+			advance(int(tick + 1))
+			for key, deadline := range deadlines {
+				if deadline <= tick+1 {
+					delete(deadlines, key)
+				}
+			}
+			for i := 0; i < 16; i++ {
+				key := strconv.Itoa(i)
+				_, expected := deadlines[key]
+				if _, ok := m.Load(key); ok != expected {
+					t.Fatalf("Expected presence of key %s after tick %d to be %t, but was %t", key, tick+1, expected, ok)
+				}
+			}
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		m, advance := newConformanceMap(factory)
+		defer m.Close()
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					key := strconv.Itoa((g*7 + i) % 32)
+					switch i % 4 {
+					case 0, 1:
+						m.Store(key, key)
+					case 2:
+						m.LoadAndTouch(key)
+					case 3:
+						m.Delete(key)
+					}
+				}
+			}(g)
+		}
+		for tick := 1; tick <= 8; tick++ {
+			advance(tick)
+		}
+		wg.Wait()
+
+		advance(8 + int(conformanceTTL/conformanceInterval))
+		m.Range(func(key, _ string) bool {
+			t.Errorf("Expected all keys to expire, but found %s", key)
+			return true
+		})
+	})
+}
+
+// newConformanceMap creates a map with factory. The advance
+// function advances the map to the given tick, counted from
+// the creation of the map.
+func newConformanceMap(factory func(ttl, interval time.Duration) ttlmap.Interface[string, string]) (ttlmap.Interface[string, string], func(tick int)) {
+	m := factory(conformanceTTL, conformanceInterval)
+	created := time.Now()
+
+	// Ticks are due every interval after the map was created,
+	// which happened before created but less than an interval
+	// ago.
+	return m, func(tick int) {
+		m.AdvanceTo(created.Add(time.Duration(tick) * conformanceInterval))
+	}
+}
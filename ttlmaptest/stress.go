@@ -0,0 +1,116 @@
+package ttlmaptest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/job79/ttlmap"
+)
+
+// StressOptions configures Stress. Zero fields use their
+// default.
+type StressOptions struct {
+	// Goroutines is the number of goroutines that access the
+	// map concurrently, 8 by default.
+	Goroutines int
+	// Operations is the number of operations every goroutine
+	// performs, 10000 by default.
+	Operations int
+	// Keys is the number of distinct keys, 64 by default.
+	Keys int
+
+	// Interval is the interval of the map. The fake clock
+	// advances the map by Interval every 100 operations of a
+	// goroutine. It is required.
+	Interval time.Duration
+	// TTL is the TTL of the map. When set, Stress verifies
+	// that all entries expire once the clock advanced by TTL
+	// after the last operation.
+	TTL time.Duration
+}
+
+// Stress hammers m with concurrent stores, loads, touches and
+// deletes, while advancing it with a fake clock, and verifies
+// that its invariants hold. Run it with -race to validate a
+// configuration for concurrent use. The operations are
+// deterministic per goroutine, their interleaving is not.
+//
+// The map must not be used by others during Stress, and must
+// be created right before it, so its ticks are due every
+// interval after now.
+func Stress(t testing.TB, m ttlmap.Interface[string, string], opts StressOptions) {
+	if opts.Interval <= 0 {
+		t.Fatal("ttlmaptest: StressOptions.Interval is required")
+	}
+	if opts.Goroutines == 0 {
+		opts.Goroutines = 8
+	}
+	if opts.Operations == 0 {
+		opts.Operations = 10000
+	}
+	if opts.Keys == 0 {
+		opts.Keys = 64
+	}
+
+	var clockMu sync.Mutex
+	clock := time.Now()
+	advance := func(d time.Duration) {
+		clockMu.Lock()
+		clock = clock.Add(d)
+		now := clock
+		clockMu.Unlock()
+		m.AdvanceTo(now)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < opts.Goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			rand := uint64(g) + 1
+			for i := 0; i < opts.Operations; i++ {
+				rand = rand*6364136223846793005 + 1442695040888963407
+				key := strconv.Itoa(int(rand>>33) % opts.Keys)
+
+				var value string
+				var ok bool
+				switch (rand >> 20) % 5 {
+				case 0, 1:
+					m.Store(key, key+":"+strconv.Itoa(g))
+				case 2:
+					value, ok = m.Load(key)
+				case 3:
+					value, ok = m.LoadAndTouch(key)
+				case 4:
+					value, ok = m.LoadAndDelete(key)
+				}
+				if ok && !strings.HasPrefix(value, key+":") {
+					t.Errorf("Expected value of key %s to belong to it, but was '%s'", key, value)
+				}
+
+				if i%100 == 0 {
+					advance(opts.Interval)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	m.Range(func(key, value string) bool {
+		if !strings.HasPrefix(value, key+":") {
+			t.Errorf("Expected value of key %s to belong to it, but was '%s'", key, value)
+		}
+		return true
+	})
+
+	if opts.TTL > 0 {
+		advance(opts.TTL)
+		m.Range(func(key, _ string) bool {
+			t.Errorf("Expected all keys to expire after the TTL, but found %s", key)
+			return true
+		})
+	}
+}
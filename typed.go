@@ -0,0 +1,107 @@
+package ttlmap
+
+import "time"
+
+// String is a TTLMap with string values, which adds an atomic
+// Append. Like the other typed maps, it is a convenience
+// wrapper around Compute, its entries are stored like the
+// entries of any TTLMap.
+type String[K comparable] struct {
+	*TTLMap[K, string]
+}
+
+// NewString creates a new String map, see New.
+func NewString[K comparable](ttl, interval time.Duration, opts ...Option[K, string]) *String[K] {
+	return &String[K]{New(ttl, interval, opts...)}
+}
+
+// Append atomically appends s to the value of key and returns
+// the new value. Missing keys are stored with the full TTL,
+// existing keys keep their TTL, see OpCompute. The ok result
+// is false when the value was not stored, see Compute.
+func (m *String[K]) Append(key K, s string) (value string, ok bool) {
+	return m.update(key, func(old string, _ bool) string {
+		return old + s
+	})
+}
+
+// Bytes is a TTLMap with byte slice values, which adds an
+// atomic Append. It is a convenience wrapper, see String.
+type Bytes[K comparable] struct {
+	*TTLMap[K, []byte]
+}
+
+// NewBytes creates a new Bytes map, see New.
+func NewBytes[K comparable](ttl, interval time.Duration, opts ...Option[K, []byte]) *Bytes[K] {
+	return &Bytes[K]{New(ttl, interval, opts...)}
+}
+
+// Append atomically appends b to the value of key and returns
+// the new value. Missing keys are stored with the full TTL,
+// existing keys keep their TTL, see OpCompute. The ok result
+// is false when the value was not stored, see Compute.
+//
+// The stored slice is never modified in place, so slices
+// returned by earlier loads stay valid.
+func (m *Bytes[K]) Append(key K, b []byte) (value []byte, ok bool) {
+	return m.update(key, func(old []byte, _ bool) []byte {
+		val := make([]byte, len(old)+len(b))
+		copy(val, old)
+		copy(val[len(old):], b)
+		return val
+	})
+}
+
+// Int64 is a TTLMap with int64 values, which adds an atomic
+// Increment. It is a convenience wrapper, see String.
+type Int64[K comparable] struct {
+	*TTLMap[K, int64]
+}
+
+// NewInt64 creates a new Int64 map, see New.
+func NewInt64[K comparable](ttl, interval time.Duration, opts ...Option[K, int64]) *Int64[K] {
+	return &Int64[K]{New(ttl, interval, opts...)}
+}
+
+// Increment atomically adds delta to the value of key and
+// returns the new value. Missing keys start at zero and are
+// stored with the full TTL, existing keys keep their TTL, see
+// OpCompute. The ok result is false when the value was not
+// stored, see Compute.
+func (m *Int64[K]) Increment(key K, delta int64) (value int64, ok bool) {
+	return m.update(key, func(old int64, _ bool) int64 {
+		return old + delta
+	})
+}
+
+// Slice is a TTLMap that accumulates values under a key, like
+// the events of a user. The values of a key expire together.
+type Slice[K comparable, V any] struct {
+	*TTLMap[K, []V]
+}
+
+// NewSlice creates a new Slice map, see New.
+func NewSlice[K comparable, V any](ttl, interval time.Duration, opts ...Option[K, []V]) *Slice[K, V] {
+	return &Slice[K, V]{New(ttl, interval, opts...)}
+}
+
+// Append atomically appends values to the values of key and
+// returns the new values. Missing keys are stored with the
+// full TTL, existing keys keep their TTL, see OpCompute. The
+// ok result is false when the values were not stored, see
+// Compute.
+//
+// The stored slice is never modified in place, so slices
+// returned by earlier loads stay valid.
+func (m *Slice[K, V]) Append(key K, values ...V) (all []V, ok bool) {
+	return m.update(key, func(old []V, _ bool) []V {
+		return append(old[:len(old):len(old)], values...)
+	})
+}
+
+// LoadAll returns the values of key, or nil when the key is
+// missing. The slice must not be modified.
+func (m *Slice[K, V]) LoadAll(key K) []V {
+	val, _ := m.Load(key)
+	return val
+}
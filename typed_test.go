@@ -0,0 +1,103 @@
+package ttlmap
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStringAppend(t *testing.T) {
+	ttlmap := NewString[string](time.Hour, time.Minute)
+	ttlmap.Append("key", "a")
+
+	if value, ok := ttlmap.Append("key", "b"); !ok || value != "ab" {
+		t.Errorf("Expected value to be 'ab', but was '%s'", value)
+	} else if value, _ = ttlmap.Load("key"); value != "ab" {
+		t.Errorf("Expected value to be 'ab', but was '%s'", value)
+	}
+}
+
+func TestBytesAppend(t *testing.T) {
+	ttlmap := NewBytes[string](time.Hour, time.Minute)
+	first, _ := ttlmap.Append("key", []byte("a"))
+
+	if value, ok := ttlmap.Append("key", []byte("b")); !ok || string(value) != "ab" {
+		t.Errorf("Expected value to be 'ab', but was '%s'", value)
+	} else if string(first) != "a" {
+		t.Errorf("Expected first value to be 'a', but was '%s'", first)
+	}
+}
+
+func TestInt64Increment(t *testing.T) {
+	ttlmap := NewInt64[string](time.Hour, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				ttlmap.Increment("key", 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := ttlmap.Load("key"); value != 8000 {
+		t.Errorf("Expected value to be 8000, but was %d", value)
+	}
+}
+
+func TestTypedNotStored(t *testing.T) {
+	counters := NewInt64[string](time.Hour, time.Minute)
+	counters.Freeze(false)
+	strs := NewString[string](time.Hour, time.Minute)
+	strs.Close()
+	slice := NewSlice(time.Hour, time.Minute, WithHardLimit[string, []int](1, nil))
+	defer slice.Close()
+	slice.Append("key", 1)
+
+	if value, ok := counters.Increment("key", 1); ok || value != 0 {
+		t.Errorf("Expected Increment on a frozen map to fail, but got %d and %t", value, ok)
+	} else if _, ok = strs.Append("key", "a"); ok {
+		t.Errorf("Expected Append on a closed map to fail, but it succeeded")
+	} else if _, ok = slice.Append("other", 2); ok {
+		t.Errorf("Expected Append on a full map to fail, but it succeeded")
+	} else if _, ok = slice.Append("key", 2); !ok {
+		t.Errorf("Expected Append to an existing key of a full map to succeed, but it failed")
+	}
+}
+
+func TestIncrementKeepsTTL(t *testing.T) {
+	ttlmap := NewInt64[string](2*time.Hour, time.Hour)
+	ttlmap.Increment("key", 1)
+	ttlmap.nextGeneration()
+	ttlmap.Increment("key", 1)
+
+	ttlmap.nextGeneration()
+	if _, ok := ttlmap.Load("key"); ok {
+		t.Errorf("Expected to not find key, but did")
+	}
+}
+
+func TestSliceAppend(t *testing.T) {
+	ttlmap := NewSlice[string, int](2*time.Hour, time.Hour)
+	ttlmap.Append("key", 1)
+	first := ttlmap.LoadAll("key")
+	ttlmap.nextGeneration()
+	ttlmap.Append("key", 2, 3)
+
+	if values := ttlmap.LoadAll("key"); !slices.Equal(values, []int{1, 2, 3}) {
+		t.Errorf("Expected values to be [1 2 3], but were %v", values)
+	} else if !slices.Equal(first, []int{1}) {
+		t.Errorf("Expected earlier loads to be unchanged, but were %v", first)
+	} else if values := ttlmap.LoadAll("missing"); values != nil {
+		t.Errorf("Expected nil for a missing key, but got %v", values)
+	}
+
+	ttlmap.nextGeneration()
+	if values := ttlmap.LoadAll("key"); values != nil {
+		t.Errorf("Expected values to expire together, but got %v", values)
+	}
+}
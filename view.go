@@ -0,0 +1,46 @@
+package ttlmap
+
+// ReadOnlyView is an immutable point-in-time view of a map,
+// see View. It is safe for concurrent use.
+type ReadOnlyView[K comparable, V any] struct {
+	entries map[K]V
+}
+
+// View returns a view of the contents of the map. The view is
+// consistent: it is copied while generations are held, like
+// RangeConsistent, and does not change when the map is written
+// or entries expire afterwards. Writers are not blocked while
+// the view is copied or used, which allows exporting the map,
+// for example to analytics. Values are shared with the map,
+// like Clone, only the keys and value headers are copied.
+//
+// Unlike Freeze, View doesn't make the map read-only.
+func (m *TTLMap[K, V]) View() ReadOnlyView[K, V] {
+	entries := make(map[K]V, m.Len())
+	m.RangeConsistent(func(key K, value V) bool {
+		entries[key] = value
+		return true
+	})
+	return ReadOnlyView[K, V]{entries: entries}
+}
+
+// Load returns the value of key in the view.
+func (v ReadOnlyView[K, V]) Load(key K) (V, bool) {
+	value, ok := v.entries[key]
+	return value, ok
+}
+
+// Range calls f for every entry of the view, in unspecified
+// order, until f returns false.
+func (v ReadOnlyView[K, V]) Range(f func(key K, value V) bool) {
+	for key, value := range v.entries {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries in the view.
+func (v ReadOnlyView[K, V]) Len() int {
+	return len(v.entries)
+}
@@ -0,0 +1,36 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestView(t *testing.T) {
+	ttlmap := New[string, string](time.Hour, time.Hour)
+	defer ttlmap.Close()
+	ttlmap.Store("key1", "value1")
+	ttlmap.Store("key2", "value2")
+
+	view := ttlmap.View()
+	ttlmap.Store("key1", "other")
+	ttlmap.Delete("key2")
+	ttlmap.Store("key3", "value3")
+	ttlmap.nextGeneration()
+
+	visited := 0
+	view.Range(func(string, string) bool {
+		visited++
+		return true
+	})
+	if value, ok := view.Load("key1"); !ok || value != "value1" {
+		t.Errorf("Expected key1 to keep value1 in the view, but got '%s'", value)
+	} else if _, ok := view.Load("key2"); !ok {
+		t.Errorf("Expected key2 to stay in the view, but it was removed")
+	} else if _, ok := view.Load("key3"); ok {
+		t.Errorf("Expected key3 not to be in the view, but it was")
+	} else if view.Len() != 2 || visited != 2 {
+		t.Errorf("Expected 2 entries in the view, but got %d and visited %d", view.Len(), visited)
+	} else if ttlmap.Frozen() {
+		t.Errorf("Expected the map to stay writable, but it is frozen")
+	}
+}
@@ -0,0 +1,75 @@
+package ttlmap
+
+import (
+	"math"
+	"runtime/debug"
+	runtimemetrics "runtime/metrics"
+)
+
+// watermark evicts entries when the process is near its memory
+// limit, see WithMemoryWatermark.
+type watermark struct {
+	fraction float64
+	limit    int64
+}
+
+// WithMemoryWatermark evicts entries when the memory of the
+// process exceeds fraction of limit, so a map can be sized
+// generously in containers without running into the limit. A
+// limit of 0 uses the memory limit of the runtime, which is
+// set by GOMEMLIMIT or debug.SetMemoryLimit, the watermark has
+// no effect when neither is set.
+//
+// The memory is read on every tick. Above the watermark the
+// map evicts as many entries as a generation holds on average,
+// preferring entries that were not used recently, like
+// WithMaxEntries. Evicted entries are reported with
+// EvictionCapacity. The memory is measured like the runtime
+// measures it against its limit, the freed memory only counts
+// after the next garbage collection, so the map keeps evicting
+// for a few ticks after the collection that brings it below the
+// watermark.
+func WithMemoryWatermark[K comparable, V any](fraction float64, limit int64) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.watermark = &watermark{fraction: fraction, limit: limit}
+	}
+}
+
+// memoryUsage returns the memory of the process that counts
+// against its limit. It is a variable for tests.
+var memoryUsage = func() uint64 {
+	samples := []runtimemetrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	runtimemetrics.Read(samples)
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// exceeded reports whether the memory of the process is above
+// the watermark.
+func (w *watermark) exceeded() bool {
+	limit := w.limit
+	if limit <= 0 {
+		limit = debug.SetMemoryLimit(-1)
+	}
+	if limit <= 0 || limit == math.MaxInt64 {
+		return false
+	}
+	return float64(memoryUsage()) > w.fraction*float64(limit)
+}
+
+// checkWatermark evicts the entries of one generation when the
+// process is above the watermark. It is called after a tick.
+func (m *TTLMap[K, V]) checkWatermark() {
+	if !m.watermark.exceeded() || !m.trimming.CompareAndSwap(false, true) {
+		return
+	}
+	defer m.trimming.Store(false)
+
+	count := m.count.Load()
+	target := count - max(count/int64(m.ttlTicks.Load()), 1)
+	m.trim(func() bool {
+		return m.count.Load() > target
+	})
+}
@@ -0,0 +1,44 @@
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithMemoryWatermark(t *testing.T) {
+	usage := uint64(400)
+	defer func(f func() uint64) { memoryUsage = f }(memoryUsage)
+	memoryUsage = func() uint64 { return usage }
+
+	var evicted int
+	ttlmap := NewManual(4*time.Second, time.Second,
+		WithMemoryWatermark[int, int](0.5, 1000),
+		WithOnEvict(func(key int, value int, reason EvictionReason) {
+			if reason == EvictionCapacity {
+				evicted++
+			}
+		}))
+	defer ttlmap.Close()
+	for i := range 8 {
+		ttlmap.Store(i, i)
+	}
+
+	ttlmap.Advance(1)
+	if evicted != 0 {
+		t.Errorf("Expected no evictions below the watermark, but got %d", evicted)
+	}
+
+	usage = 600
+	ttlmap.Advance(1)
+	if evicted != 2 {
+		t.Errorf("Expected a generation of 2 entries to be evicted, but got %d", evicted)
+	} else if ttlmap.Len() != 6 {
+		t.Errorf("Expected 6 entries, but got %d", ttlmap.Len())
+	}
+}
+
+func TestMemoryUsageOfProcess(t *testing.T) {
+	if memoryUsage() == 0 {
+		t.Errorf("Expected the memory of the process, but got 0")
+	}
+}
@@ -0,0 +1,73 @@
+package ttlmap
+
+import "sync"
+
+// WithAsyncEvictions calls the eviction callbacks, the
+// functions of WithOnEvict and WithOnExpireBatch, in a pool of
+// workers goroutines, instead of in the goroutine that removed
+// the entries. This keeps the ticker from being blocked by
+// expensive callbacks, like closing connections, when a large
+// generation expires.
+//
+// The removed entries wait in a queue of queue callbacks, a
+// full queue blocks the goroutine that removed an entry until
+// a worker is free, which bounds the memory of a backlog. The
+// callbacks of different entries run concurrently and in no
+// particular order. Close waits for the queued callbacks.
+func WithAsyncEvictions[K comparable, V any](workers, queue int) Option[K, V] {
+	return func(m *TTLMap[K, V]) {
+		m.workers = &workerPool{
+			size: max(workers, 1),
+			jobs: make(chan func(), max(queue, 0)),
+		}
+	}
+}
+
+// workerPool runs callbacks, see WithAsyncEvictions.
+type workerPool struct {
+	size int
+	jobs chan func()
+	wg   sync.WaitGroup
+
+	// mu guards closed, jobs is sent to under a read lock,
+	// so it is not closed during a send.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// start starts the workers.
+func (p *workerPool) start(labeled func(f func())) {
+	p.wg.Add(p.size)
+	for range p.size {
+		go func() {
+			defer p.wg.Done()
+			labeled(func() {
+				for job := range p.jobs {
+					job()
+				}
+			})
+		}()
+	}
+}
+
+// run runs job in a worker, or in the calling goroutine once
+// the pool is stopped.
+func (p *workerPool) run(job func()) {
+	p.mu.RLock()
+	if !p.closed {
+		p.jobs <- job
+		p.mu.RUnlock()
+		return
+	}
+	p.mu.RUnlock()
+	job()
+}
+
+// stop waits for the queued jobs, and stops the workers.
+func (p *workerPool) stop() {
+	p.mu.Lock()
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+	p.wg.Wait()
+}
@@ -0,0 +1,73 @@
+package ttlmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithAsyncEvictions(t *testing.T) {
+	var evicted, batched atomic.Int64
+	release := make(chan struct{})
+	ttlmap := NewManual(time.Second, time.Second,
+		WithAsyncEvictions[int, int](2, 100),
+		WithOnEvict(func(key int, value int, reason EvictionReason) {
+			<-release
+			evicted.Add(1)
+		}),
+		WithOnExpireBatch(func(batch []ExpiredEntry[int, int]) {
+			batched.Add(int64(len(batch)))
+		}))
+
+	for i := range 10 {
+		ttlmap.Store(i, i)
+	}
+	done := make(chan struct{})
+	go func() {
+		ttlmap.Advance(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the sweep not to wait for the callbacks, but it blocked")
+	}
+	if evicted.Load() != 0 {
+		t.Errorf("Expected callbacks to wait, but %d ran", evicted.Load())
+	} else if report := ttlmap.ResourceReport(); report.Goroutines != 2 {
+		t.Errorf("Expected 2 worker goroutines, but got %d", report.Goroutines)
+	}
+
+	close(release)
+	ttlmap.Close()
+	if evicted.Load() != 10 || batched.Load() != 10 {
+		t.Errorf("Expected Close to wait for 10 callbacks, but got %d and %d", evicted.Load(), batched.Load())
+	}
+}
+
+func TestWithAsyncEvictionsConcurrent(t *testing.T) {
+	var evicted atomic.Int64
+	ttlmap := New(time.Hour, time.Hour,
+		WithAsyncEvictions[int, int](4, 0),
+		WithOnEvict(func(key int, value int, reason EvictionReason) {
+			evicted.Add(1)
+		}))
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				ttlmap.Store(g*100+i, i)
+				ttlmap.Delete(g*100 + i)
+			}
+		}()
+	}
+	wg.Wait()
+	ttlmap.Close()
+	if evicted.Load() != 400 {
+		t.Errorf("Expected 400 callbacks, but got %d", evicted.Load())
+	}
+}